@@ -6,6 +6,13 @@
 //
 
 // 并发安全的双向链表.
+//
+// 并发安全说明：
+// 1. 所有方法均在链表的互斥锁中执行，可以在多个goroutine中并发调用，调用方不需要额外加锁；
+// 2. FrontAll/BackAll返回调用时链表数据的快照(在一个读锁中生成)，遍历快照期间其他goroutine对链表的修改不会影响快照；
+// 3. Iterator为逐步加锁遍历，每一步只在获取数据项时加读锁，回调函数执行时不持有锁，因此回调函数中可以修改链表，
+//    但是遍历过程中不保证看到的是某一时刻的一致性数据；
+// 4. Front/Back返回的*list.Element指针在锁外使用(例如通过Next/Prev遍历)不是并发安全的，并发场景下请使用FrontAll/BackAll/Iterator；
 package glist
 
 import (
@@ -43,6 +50,28 @@ func (this *List) PushBack(v interface{}) *list.Element {
 	return r
 }
 
+// 当链表中不存在与v相等的数据项时往链表尾入栈数据项，返回是否入栈。
+// 使用==进行比较，因此v需要为可比较的类型(func、slice、map等类型的值请使用PushBackUniqueFunc)。
+func (this *List) PushBackUnique(v interface{}) bool {
+    return this.PushBackUniqueFunc(v, func(a, b interface{}) bool {
+        return a == b
+    })
+}
+
+// 当链表中不存在与v相等的数据项时往链表尾入栈数据项，返回是否入栈，eq为自定义的比较方法(参数a为链表中已有的数据项，b为v)。
+// 查找与入栈在同一个写锁中执行，并发调用时同一个值只会入栈一次。
+func (this *List) PushBackUniqueFunc(v interface{}, eq func(a, b interface{}) bool) bool {
+    this.mu.Lock()
+    defer this.mu.Unlock()
+    for e := this.list.Front(); e != nil; e = e.Next() {
+        if eq(e.Value, v) {
+            return false
+        }
+    }
+    this.list.PushBack(v)
+    return true
+}
+
 // 在list 中元素mark之后插入一个值为v的元素，并返回该元素，如果mark不是list中元素，则list不改变。
 func (this *List) InsertAfter(v interface{}, mark *list.Element) *list.Element {
     this.mu.Lock()
@@ -173,6 +202,33 @@ func (this *List) Remove(e *list.Element) interface{} {
 	return r
 }
 
+// 删除链表中第一个值与v相等的数据项，返回是否删除了数据项。
+// 使用==进行比较，因此v需要为可比较的类型(例如func、slice、map类型的值不能使用该方法删除，请使用RemoveElement)。
+func (this *List) RemoveByValue(v interface{}) bool {
+    this.mu.Lock()
+    defer this.mu.Unlock()
+    for e := this.list.Front(); e != nil; e = e.Next() {
+        if e.Value == v {
+            this.list.Remove(e)
+            return true
+        }
+    }
+    return false
+}
+
+// 删除指定的数据项，返回是否删除了数据项(e不属于该链表或者已经被删除时返回false)
+func (this *List) RemoveElement(e *list.Element) bool {
+    if e == nil {
+        return false
+    }
+    this.mu.Lock()
+    length := this.list.Len()
+    this.list.Remove(e)
+    removed := this.list.Len() < length
+    this.mu.Unlock()
+    return removed
+}
+
 // 删除所有数据项
 func (this *List) RemoveAll() {
 	this.mu.Lock()
@@ -180,7 +236,7 @@ func (this *List) RemoveAll() {
 	this.mu.Unlock()
 }
 
-// 从链表头获取所有数据(不删除)
+// 从链表头获取所有数据(不删除)，返回的是调用时链表数据的快照
 func (this *List) FrontAll() []interface{} {
 	this.mu.RLock()
 	count := this.list.Len()
@@ -197,7 +253,7 @@ func (this *List) FrontAll() []interface{} {
 	return items
 }
 
-// 从链表尾获取所有数据(不删除)
+// 从链表尾获取所有数据(不删除)，返回的是调用时链表数据的快照
 func (this *List) BackAll() []interface{} {
 	this.mu.RLock()
 	count := this.list.Len()
@@ -214,6 +270,26 @@ func (this *List) BackAll() []interface{} {
 	return items
 }
 
+// 从链表头开始逐项遍历，回调函数返回false时停止遍历。
+// 每一步获取数据项时加读锁，回调函数执行时不持有锁(回调函数中可以修改链表)；
+// 当前遍历到的数据项在回调执行期间被其他goroutine删除时，遍历将在该数据项处结束。
+func (this *List) Iterator(f func (v interface{}) bool) {
+    this.mu.RLock()
+    e := this.list.Front()
+    this.mu.RUnlock()
+    for e != nil {
+        this.mu.RLock()
+        v := e.Value
+        this.mu.RUnlock()
+        if !f(v) {
+            break
+        }
+        this.mu.RLock()
+        e = e.Next()
+        this.mu.RUnlock()
+    }
+}
+
 // 获取链表头值(不删除)
 func (this *List) FrontItem() interface{} {
 	this.mu.RLock()
@@ -9,6 +9,7 @@
 package glist
 
 import (
+    "sync"
     "testing"
 )
 
@@ -44,3 +45,124 @@ func BenchmarkLen(b *testing.B) {
     }
 }
 
+// Iterator逐项遍历：回调函数中可以修改链表(不会死锁)，返回false时停止遍历，当前数据项被删除时遍历结束
+func TestList_Iterator(t *testing.T) {
+    list := New()
+    for i := 0; i < 5; i++ {
+        list.PushBack(i)
+    }
+    visited := make([]interface{}, 0)
+    list.Iterator(func(v interface{}) bool {
+        visited = append(visited, v)
+        if v == 1 {
+            list.PushBack(5)
+        }
+        return true
+    })
+    if len(visited) != 6 || visited[5] != 5 {
+        t.Errorf("unexpected visited items %v", visited)
+    }
+    count := 0
+    list.Iterator(func(v interface{}) bool {
+        count++
+        return v != 2
+    })
+    if count != 3 {
+        t.Errorf("the iterator was not stopped, %d items visited", count)
+    }
+    visited = visited[:0]
+    list.Iterator(func(v interface{}) bool {
+        visited = append(visited, v)
+        if v == 2 {
+            list.RemoveByValue(2)
+        }
+        return true
+    })
+    if len(visited) != 3 || list.Len() != 5 {
+        t.Errorf("unexpected visited items %v after removing the current item", visited)
+    }
+    // 并发修改时遍历不会发生数据竞争
+    wg := sync.WaitGroup{}
+    wg.Add(1)
+    go func() {
+        defer wg.Done()
+        for i := 0; i < 1000; i++ {
+            list.PushBack(i)
+            list.PopFront()
+        }
+    }()
+    for i := 0; i < 100; i++ {
+        list.Iterator(func(v interface{}) bool {
+            return true
+        })
+    }
+    wg.Wait()
+}
+
+
+// RemoveByValue删除第一个相等的数据项，RemoveElement对不属于该链表或者已删除的数据项返回false
+func TestList_RemoveByValue(t *testing.T) {
+    list := New()
+    for _, v := range []interface{}{ 1, "a", 1, 2 } {
+        list.PushBack(v)
+    }
+    if !list.RemoveByValue(1) || list.RemoveByValue(3) || list.RemoveByValue("1") {
+        t.Error("unexpected RemoveByValue results")
+    }
+    if items := list.FrontAll(); len(items) != 3 || items[0] != "a" || items[1] != 1 || items[2] != 2 {
+        t.Errorf("unexpected items %v after RemoveByValue", items)
+    }
+    e := list.Front()
+    if !list.RemoveElement(e) || list.RemoveElement(e) || list.RemoveElement(nil) {
+        t.Error("unexpected RemoveElement results for a removed element")
+    }
+    other := New()
+    if list.RemoveElement(other.PushBack(1)) || other.Len() != 1 || list.Len() != 2 {
+        t.Error("RemoveElement removed an element of another list")
+    }
+    // 不可比较的值通过RemoveElement删除
+    if !list.RemoveElement(list.PushBack([]int{ 1 })) || list.Len() != 2 {
+        t.Error("RemoveElement failed for an element with an uncomparable value")
+    }
+}
+
+// 并发调用PushBackUnique时同一个值只会入栈一次，PushBackUniqueFunc使用自定义的比较方法
+func TestList_PushBackUnique(t *testing.T) {
+    list   := New()
+    pushed := make([]int, 10)
+    wg     := sync.WaitGroup{}
+    mu     := sync.Mutex{}
+    for i := 0; i < 20; i++ {
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            for v := 0; v < len(pushed); v++ {
+                if list.PushBackUnique(v) {
+                    mu.Lock()
+                    pushed[v]++
+                    mu.Unlock()
+                }
+            }
+        }()
+    }
+    wg.Wait()
+    for v, n := range pushed {
+        if n != 1 {
+            t.Errorf("value %d was pushed %d times", v, n)
+        }
+    }
+    if list.Len() != len(pushed) {
+        t.Errorf("unexpected length %d, expected %d", list.Len(), len(pushed))
+    }
+
+    list = New()
+    eq  := func(a, b interface{}) bool {
+        return len(a.([]int)) == len(b.([]int))
+    }
+    if !list.PushBackUniqueFunc([]int{ 1 }, eq) || list.PushBackUniqueFunc([]int{ 2 }, eq) || !list.PushBackUniqueFunc([]int{ 1, 2 }, eq) {
+        t.Error("unexpected PushBackUniqueFunc results")
+    }
+    if list.Len() != 2 {
+        t.Errorf("unexpected length %d, expected 2", list.Len())
+    }
+}
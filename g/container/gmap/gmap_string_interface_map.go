@@ -23,18 +23,25 @@ func NewStringInterfaceMap(safe...bool) *StringInterfaceMap {
 	}
 }
 
-// 给定回调函数对原始内容进行遍历，回调函数返回true表示继续遍历，否则停止遍历
+// 给定回调函数对哈希表的快照进行遍历，回调函数返回true表示继续遍历，否则停止遍历。
+// 遍历过程中不持有锁，因此回调函数中可以执行耗时操作或者再次操作该哈希表(修改不会影响本次遍历的内容)。
 func (this *StringInterfaceMap) Iterator(f func (k string, v interface{}) bool) {
 	this.mu.RLock()
-	defer this.mu.RUnlock()
+	keys   := make([]string, 0, len(this.m))
+	values := make([]interface{}, 0, len(this.m))
 	for k, v := range this.m {
-		if !f(k, v) {
+		keys   = append(keys, k)
+		values = append(values, v)
+	}
+	this.mu.RUnlock()
+	for i, k := range keys {
+		if !f(k, values[i]) {
 			break
 		}
 	}
 }
 
-// 哈希表克隆
+// 哈希表克隆，返回普通map类型的快照，注意是浅拷贝(键值为指针/引用类型时与原哈希表共享同一个对象)
 func (this *StringInterfaceMap) Clone() map[string]interface{} {
     m := make(map[string]interface{})
     this.mu.RLock()
@@ -111,6 +118,33 @@ func (this *StringInterfaceMap) GetOrSetFuncLock(key string, f func() interface{
     }
 }
 
+// 当键名存在时返回其键值，否则写入指定的键值，检索及写入在同一个写锁中完成(原子操作)；
+// 返回值created表示键值是否为本次新写入的(false表示返回的是已存在的键值)。
+// 注意：与GetOrSet不同，value为func() interface{}类型时不会执行该函数，而是直接写入该函数。
+func (this *StringInterfaceMap) GetOrSetCheck(key string, value interface{}) (result interface{}, created bool) {
+    this.mu.Lock()
+    defer this.mu.Unlock()
+    if v, ok := this.m[key]; ok {
+        return v, false
+    }
+    this.m[key] = value
+    return value, true
+}
+
+// 当键名存在时返回其键值，否则写入指定函数生成的键值，检索及写入在同一个写锁中完成(原子操作)，
+// 函数f只在键名不存在时执行(在写锁中执行，因此f中不能再操作该哈希表)；
+// 返回值created表示键值是否为本次新写入的(false表示返回的是已存在的键值)。
+func (this *StringInterfaceMap) GetOrSetFuncCheck(key string, f func() interface{}) (result interface{}, created bool) {
+    this.mu.Lock()
+    defer this.mu.Unlock()
+    if v, ok := this.m[key]; ok {
+        return v, false
+    }
+    value := f()
+    this.m[key] = value
+    return value, true
+}
+
 // 当键名不存在时写入，并返回true；否则返回false。
 func (this *StringInterfaceMap) SetIfNotExist(key string, value interface{}) bool {
     if !this.Contains(key) {
@@ -140,10 +174,10 @@ func (this *StringInterfaceMap) Remove(key string) interface{} {
 	return val
 }
 
-// 返回键列表
+// 返回键列表(注意是随机排序)，在一个读锁中生成，为调用时的一致性快照
 func (this *StringInterfaceMap) Keys() []string {
 	this.mu.RLock()
-	keys := make([]string, 0)
+	keys := make([]string, 0, len(this.m))
 	for key, _ := range this.m {
 		keys = append(keys, key)
 	}
@@ -151,10 +185,10 @@ func (this *StringInterfaceMap) Keys() []string {
 	return keys
 }
 
-// 返回值列表(注意是随机排序)
+// 返回值列表(注意是随机排序)，在一个读锁中生成，为调用时的一致性快照
 func (this *StringInterfaceMap) Values() []interface{} {
 	this.mu.RLock()
-	vals := make([]interface{}, 0)
+	vals := make([]interface{}, 0, len(this.m))
 	for _, val := range this.m {
 		vals = append(vals, val)
 	}
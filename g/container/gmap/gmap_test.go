@@ -0,0 +1,131 @@
+// Copyright 2018 gf Author(https://gitee.com/johng/gf). All Rights Reserved.
+//
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT was not distributed with this file,
+// You can obtain one at https://gitee.com/johng/gf.
+
+// go test *.go -v
+
+package gmap
+
+import (
+    "sync"
+    "sync/atomic"
+    "testing"
+)
+
+// 并发执行GetOrSetCheck/GetOrSetFuncCheck时只有一个调用端写入成功(created为true)，函数只执行一次
+func TestStringInterfaceMap_GetOrSetCheck(t *testing.T) {
+    m       := NewStringInterfaceMap()
+    created := int32(0)
+    calls   := int32(0)
+    results := make([]interface{}, 100)
+    wg      := sync.WaitGroup{}
+    for i := 0; i < len(results); i++ {
+        wg.Add(1)
+        go func(i int) {
+            defer wg.Done()
+            var ok bool
+            if i % 2 == 0 {
+                results[i], ok = m.GetOrSetCheck("key", i)
+            } else {
+                results[i], ok = m.GetOrSetFuncCheck("key", func() interface{} {
+                    atomic.AddInt32(&calls, 1)
+                    return i
+                })
+            }
+            if ok {
+                atomic.AddInt32(&created, 1)
+            }
+        }(i)
+    }
+    wg.Wait()
+    if created != 1 || calls > 1 {
+        t.Errorf("unexpected created count %d and function calls %d", created, calls)
+    }
+    for i, v := range results {
+        if v != m.Get("key") {
+            t.Errorf("caller %d got %v, expected the stored value %v", i, v, m.Get("key"))
+        }
+    }
+    // 键名已存在时不执行函数
+    if v, ok := m.GetOrSetFuncCheck("key", func() interface{} {
+        t.Error("the function was called for an existing key")
+        return nil
+    }); ok || v != m.Get("key") {
+        t.Errorf("unexpected result %v, %v for an existing key", v, ok)
+    }
+    // 与GetOrSet不同，函数类型的键值直接写入而不会被执行
+    f := func() interface{} { return "called" }
+    if v, ok := m.GetOrSetCheck("func", f); !ok || v == "called" {
+        t.Errorf("unexpected result %v, %v for a function value", v, ok)
+    }
+}
+
+// Iterator遍历调用时的快照，回调函数中可以修改哈希表(不会死锁，也不影响本次遍历)，返回false时停止遍历
+func TestStringInterfaceMap_Iterator(t *testing.T) {
+    m := NewStringInterfaceMap()
+    for _, k := range []string{ "a", "b", "c" } {
+        m.Set(k, k)
+    }
+    visited := make(map[string]interface{})
+    m.Iterator(func(k string, v interface{}) bool {
+        visited[k] = v
+        m.Set(k + k, v)
+        m.Remove("a")
+        m.Remove("b")
+        m.Remove("c")
+        return true
+    })
+    if len(visited) != 3 || visited["a"] != "a" || visited["b"] != "b" || visited["c"] != "c" {
+        t.Errorf("unexpected visited items %v", visited)
+    }
+    if m.Size() != 3 || m.Contains("a") {
+        t.Errorf("unexpected items %v after modifying in the iterator", m.Clone())
+    }
+    count := 0
+    m.Iterator(func(k string, v interface{}) bool {
+        count++
+        return false
+    })
+    if count != 1 {
+        t.Errorf("the iterator was not stopped, %d items visited", count)
+    }
+}
+
+// Keys/Values/Clone为一致性快照，并发写入时键名列表与键值列表的长度与快照时的大小一致
+func TestStringInterfaceMap_Snapshot(t *testing.T) {
+    m    := NewStringInterfaceMap()
+    done := make(chan struct{})
+    go func() {
+        for i := 0; ; i++ {
+            select {
+                case <-done:
+                    return
+                default:
+                    m.Set(string(rune('a' + i % 26)), i)
+                    m.Remove(string(rune('a' + (i + 13) % 26)))
+            }
+        }
+    }()
+    for i := 0; i < 1000; i++ {
+        keys  := m.Keys()
+        seen  := make(map[string]bool, len(keys))
+        for _, k := range keys {
+            if seen[k] {
+                t.Fatalf("duplicated key %s in the snapshot", k)
+            }
+            seen[k] = true
+        }
+        clone := m.Clone()
+        clone["clone"] = true
+        if m.Contains("clone") {
+            t.Fatal("the clone shares the underlying map")
+        }
+    }
+    close(done)
+    m.Set("x", 1)
+    if keys, values := m.Keys(), m.Values(); len(keys) != m.Size() || len(values) != m.Size() {
+        t.Errorf("unexpected snapshot lengths %d, %d of size %d", len(keys), len(values), m.Size())
+    }
+}
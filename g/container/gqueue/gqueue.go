@@ -9,11 +9,16 @@
 // 1、动态队列初始化速度快；
 // 2、动态的队列大小(不限大小)；
 // 3、取数据时如果队列为空那么会阻塞等待；
+// 4、关闭队列后已有的数据仍然可以取出，取完后不再阻塞；
 package gqueue
 
 import (
+    "context"
+    "time"
     "gitee.com/johng/gf/g/container/glist"
+    "gitee.com/johng/gf/g/container/gtype"
     "math"
+    "sync"
 )
 
 // 0、这是一个先进先出的队列(chan <-- list)；
@@ -25,7 +30,11 @@ type Queue struct {
     queue     chan interface{} // 用于队列写入限制
     list      *glist.List      // 数据链表
     events    chan struct{}    // 通知chan，当不限制队列大小时的写入事件通知
-    closeChan chan struct{}    // 关闭channel
+    closeChan chan struct{}    // 关闭channel，通知阻塞等待中的写入退出
+    stopChan  chan struct{}    // 动态队列通知后台goroutine退出，在关闭队列并且正在执行的写入全部完成后关闭
+    length    *gtype.Int       // 动态队列中的数据条数(写入时增加，读取时减少)，有界队列的数据条数即chan中的数据条数
+    closed    *gtype.Bool      // 队列是否已关闭
+    mu        sync.RWMutex     // 写入时加读锁，关闭时加写锁，保证关闭chan时没有正在执行的写入
 }
 
 const (
@@ -44,6 +53,9 @@ func New(limit...int) *Queue {
         queue     : make(chan interface{}, size),
         events    : make(chan struct{}, math.MaxInt32),
         closeChan : make(chan struct{}, 0),
+        stopChan  : make(chan struct{}, 0),
+        length    : gtype.NewInt(),
+        closed    : gtype.NewBool(),
     }
     if len(limit) > 0 {
         q.limit = size
@@ -54,53 +66,174 @@ func New(limit...int) *Queue {
     return q
 }
 
-// 异步list->chan同步队列
+// 创建有界队列，队列中的数据达到capacity条时Push阻塞等待，TryPush返回false，同New(capacity)
+func NewBounded(capacity int) *Queue {
+    return New(capacity)
+}
+
+// 异步list->chan同步队列，队列关闭时将链表中剩余的数据全部写入chan后关闭chan(该goroutine为动态队列chan唯一的写入方)
 func (q *Queue) startAsyncLoop() {
     for {
         select {
-            case <- q.closeChan:
+            case <- q.stopChan:
+                q.transfer()
+                close(q.queue)
                 return
             case <- q.events:
-                // 循环读取链表，直到为空才跳出
-                for {
-                    if v := q.list.PopFront(); v != nil {
-                        q.queue <- v
-                    } else {
-                        break
-                    }
-                }
+                q.transfer()
         }
     }
 }
 
-// 将数据压入队列, 队头
+// 循环读取链表写入chan，直到链表为空
+func (q *Queue) transfer() {
+    for {
+        if v := q.list.PopFront(); v != nil {
+            q.queue <- v
+        } else {
+            break
+        }
+    }
+}
+
+// 将数据压入队列, 队头；有界队列已满时阻塞等待。
+// 队列关闭后写入的数据将被丢弃(阻塞等待中的写入在队列关闭时同样被丢弃)。
 func (q *Queue) Push(v interface{}) {
+    q.mu.RLock()
+    defer q.mu.RUnlock()
+    if q.closed.Val() {
+        return
+    }
     if q.limit > 0 {
-        q.queue <- v
+        select {
+            case q.queue <- v:
+            case <- q.closeChan:
+        }
     } else {
-        q.list.PushBack(v)
-        if len(q.events) == 0 {
-            q.events <- struct{}{}
+        q.pushList(v)
+    }
+}
+
+// 尝试将数据压入队列，有界队列已满(或者队列已关闭)时不阻塞，直接返回false(数据未写入)；动态队列未关闭时总是写入成功
+func (q *Queue) TryPush(v interface{}) bool {
+    // 关闭状态的判断与写入在同一个读锁中执行，返回true时数据一定已写入队列
+    q.mu.RLock()
+    defer q.mu.RUnlock()
+    if q.closed.Val() {
+        return false
+    }
+    if q.limit > 0 {
+        select {
+            case q.queue <- v:
+                return true
+            default:
+                return false
         }
     }
+    q.pushList(v)
+    return true
+}
+
+// 动态队列将数据写入链表并通知后台goroutine转移到chan，调用端需要持有读锁并且已判断队列未关闭
+func (q *Queue) pushList(v interface{}) {
+    q.length.Add(1)
+    q.list.PushBack(v)
+    if len(q.events) == 0 {
+        q.events <- struct{}{}
+    }
 }
 
 // 从队头先进先出地从队列取出一项数据
 func (q *Queue) Pop() interface{} {
-    return <- q.queue
+    v, ok := <- q.queue
+    q.popped(v, ok)
+    return v
+}
+
+// 从队头取出一项数据，队列为空时最多阻塞等待timeout时间；超时或者队列已关闭时返回nil, false
+func (q *Queue) PopTimeout(timeout time.Duration) (interface{}, bool) {
+    // 队列中有数据时不创建定时器
+    select {
+        case v, ok := <- q.queue:
+            return q.popped(v, ok)
+        default:
+    }
+    timer := time.NewTimer(timeout)
+    defer timer.Stop()
+    select {
+        case v, ok := <- q.queue:
+            return q.popped(v, ok)
+        case <- timer.C:
+            return nil, false
+    }
+}
+
+// 从队头取出一项数据，队列为空时阻塞等待直到ctx被取消；ctx被取消或者队列已关闭时返回nil, false
+func (q *Queue) PopCtx(ctx context.Context) (interface{}, bool) {
+    select {
+        case v, ok := <- q.queue:
+            return q.popped(v, ok)
+        case <- ctx.Done():
+            return nil, false
+    }
 }
 
-// 关闭队列(通知所有通过Pop*阻塞的协程退出)
+// 读取到数据后更新动态队列的数据条数
+func (q *Queue) popped(v interface{}, ok bool) (interface{}, bool) {
+    if ok && q.limit == 0 {
+        q.length.Add(-1)
+    }
+    return v, ok
+}
+
+// 关闭队列，关闭后：
+// 1、Push/TryPush写入的数据将被丢弃，阻塞等待中的Push立即返回(数据同样被丢弃)；
+// 2、队列中已有的数据不会被丢弃，Pop*仍然按照先进先出的顺序返回这些数据，直到队列为空；
+// 3、队列为空后，Pop返回nil，PopTimeout/PopCtx返回nil, false(不再阻塞)，阻塞等待中的Pop*同样被唤醒返回。
+// 重复关闭不会产生错误。
 func (q *Queue) Close() {
-    q.list.RemoveAll()
-    close(q.queue)
-    close(q.events)
+    if q.closed.Set(true) {
+        return
+    }
+    // 通知阻塞等待中的写入退出，并等待正在执行的写入完成后再关闭chan；
+    // 动态队列同样需要等待正在执行的写入完成后再通知后台goroutine，保证写入链表的数据全部转移到chan
     close(q.closeChan)
+    q.mu.Lock()
+    if q.limit > 0 {
+        close(q.queue)
+    } else {
+        close(q.stopChan)
+    }
+    q.mu.Unlock()
+}
+
+// 关闭队列，并取出队列中剩余的所有数据(按照先进先出的顺序)返回，常用于关闭时同步处理剩余的数据。
+// 并发执行Pop*的协程同样可能取到剩余的数据，这部分数据不会包含在返回结果中。
+func (q *Queue) CloseAndDrain() []interface{} {
+    q.Close()
+    items := make([]interface{}, 0)
+    for {
+        v, ok := <- q.queue
+        if _, ok = q.popped(v, ok); !ok {
+            break
+        }
+        items = append(items, v)
+    }
+    return items
+}
+
+// 获取当前队列中的数据条数，并发写入/读取时同样准确(包括动态队列从链表转移到chan过程中的数据)；
+// 有界队列为chan中的数据条数，阻塞等待中的Push不计算在内，因此不会超过队列大小
+func (q *Queue) Len() int {
+    if q.limit > 0 {
+        return len(q.queue)
+    }
+    return q.length.Val()
 }
 
-// 获取当前队列大小
+// 获取当前队列大小，同Len
 func (q *Queue) Size() int {
-    return len(q.queue) + q.list.Len()
+    return q.Len()
 }
 
 
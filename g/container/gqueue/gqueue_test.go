@@ -9,7 +9,11 @@
 package gqueue_test
 
 import (
+    "context"
+    "runtime"
+    "sync"
     "testing"
+    "time"
     "gitee.com/johng/gf/g/container/gqueue"
 )
 
@@ -46,3 +50,225 @@ func Benchmark_ChannelIntPushAndPop(b *testing.B) {
     }
 }
 
+// 并发写入/读取时Len同样准确(包括动态队列从链表转移到chan过程中的数据)
+func TestQueue_Len(t *testing.T) {
+    for name, q := range map[string]*gqueue.Queue { "dynamic" : gqueue.New(), "bounded" : gqueue.NewBounded(1000) } {
+        wg := sync.WaitGroup{}
+        for i := 0; i < 4; i++ {
+            wg.Add(2)
+            go func() {
+                defer wg.Done()
+                for j := 0; j < 1000; j++ {
+                    q.Push(j)
+                }
+            }()
+            go func() {
+                defer wg.Done()
+                for j := 0; j < 900; j++ {
+                    q.Pop()
+                }
+            }()
+        }
+        wg.Wait()
+        if n := q.Len(); n != 400 {
+            t.Errorf("%s: unexpected length %d, expected 400", name, n)
+        }
+        q.Close()
+    }
+}
+
+// 有界队列已满时阻塞等待中的Push不计算在Len中，Len不会超过队列大小
+func TestQueue_LenBlockedPush(t *testing.T) {
+    q  := gqueue.NewBounded(1)
+    q.Push(0)
+    wg := sync.WaitGroup{}
+    for i := 1; i <= 2; i++ {
+        wg.Add(1)
+        go func(i int) {
+            defer wg.Done()
+            q.Push(i)
+        }(i)
+    }
+    time.Sleep(20*time.Millisecond)
+    if n := q.Len(); n != 1 {
+        t.Errorf("unexpected length %d with blocked pushers, expected 1", n)
+    }
+    for i := 0; i < 3; i++ {
+        q.Pop()
+        if n := q.Len(); n > 1 {
+            t.Errorf("length %d exceeds the capacity 1", n)
+        }
+    }
+    wg.Wait()
+    if n := q.Len(); n != 0 {
+        t.Errorf("unexpected length %d, expected 0", n)
+    }
+    q.Close()
+}
+
+// 有界队列已满时TryPush不阻塞并返回false，队列关闭后TryPush总是返回false
+func TestQueue_TryPush(t *testing.T) {
+    q := gqueue.NewBounded(2)
+    for i, expect := range []bool{ true, true, false } {
+        if q.TryPush(i) != expect {
+            t.Errorf("unexpected TryPush result for item %d, expected %v", i, expect)
+        }
+    }
+    if n := q.Len(); n != 2 {
+        t.Errorf("unexpected length %d, expected 2", n)
+    }
+    if v := q.Pop(); v != 0 {
+        t.Errorf("unexpected popped item %v, expected 0", v)
+    }
+    if !q.TryPush(3) {
+        t.Error("TryPush failed after popping from the full queue")
+    }
+    q.Close()
+    if q.TryPush(4) {
+        t.Error("TryPush succeeded on the closed bounded queue")
+    }
+
+    q = gqueue.New()
+    for i := 0; i < 100; i++ {
+        if !q.TryPush(i) {
+            t.Fatalf("TryPush failed on the dynamic queue at item %d", i)
+        }
+    }
+    q.Close()
+    if q.TryPush(100) {
+        t.Error("TryPush succeeded on the closed dynamic queue")
+    }
+    if n := q.Len(); n != 100 {
+        t.Errorf("unexpected length %d, expected 100", n)
+    }
+}
+
+// PopTimeout在队列为空时最多等待timeout时间，有数据写入时立即返回
+func TestQueue_PopTimeout(t *testing.T) {
+    q     := gqueue.NewBounded(10)
+    start := time.Now()
+    if v, ok := q.PopTimeout(50*time.Millisecond); ok || v != nil {
+        t.Errorf("unexpected result %v, %v from the empty queue", v, ok)
+    }
+    if d := time.Since(start); d < 50*time.Millisecond {
+        t.Errorf("PopTimeout returned after %v, before the timeout", d)
+    }
+    go func() {
+        time.Sleep(20*time.Millisecond)
+        q.Push(1)
+    }()
+    start = time.Now()
+    if v, ok := q.PopTimeout(time.Second); !ok || v != 1 {
+        t.Errorf("unexpected result %v, %v, expected 1, true", v, ok)
+    }
+    if d := time.Since(start); d > 500*time.Millisecond {
+        t.Errorf("PopTimeout waited %v for the pushed item", d)
+    }
+    if n := q.Len(); n != 0 {
+        t.Errorf("unexpected length %d, expected 0", n)
+    }
+    q.Close()
+    if v, ok := q.PopTimeout(time.Second); ok || v != nil {
+        t.Errorf("unexpected result %v, %v from the closed queue", v, ok)
+    }
+}
+
+// PopCtx在ctx被取消时返回，取消后不会遗留阻塞等待的goroutine
+func TestQueue_PopCtx(t *testing.T) {
+    q    := gqueue.New()
+    base := runtime.NumGoroutine()
+    for i := 0; i < 10; i++ {
+        ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+        if v, ok := q.PopCtx(ctx); ok || v != nil {
+            t.Errorf("unexpected result %v, %v after the ctx timeout", v, ok)
+        }
+        cancel()
+    }
+    if n := runtime.NumGoroutine(); n > base {
+        t.Errorf("goroutines leaked after PopCtx: %d > %d", n, base)
+    }
+    ctx, cancel := context.WithCancel(context.Background())
+    defer cancel()
+    q.Push("a")
+    if v, ok := q.PopCtx(ctx); !ok || v != "a" {
+        t.Errorf(`unexpected result %v, %v, expected "a", true`, v, ok)
+    }
+    done := make(chan bool)
+    go func() {
+        _, ok := q.PopCtx(ctx)
+        done <- ok
+    }()
+    time.Sleep(10*time.Millisecond)
+    cancel()
+    select {
+        case ok := <-done:
+            if ok {
+                t.Error("PopCtx returned ok after the ctx was cancelled")
+            }
+        case <-time.After(time.Second):
+            t.Error("PopCtx was not unblocked by the ctx cancellation")
+    }
+    q.Close()
+}
+
+// 关闭后已有的数据仍然按照先进先出的顺序取出，取完后Pop*不再阻塞
+func TestQueue_CloseThenDrain(t *testing.T) {
+    for name, q := range map[string]*gqueue.Queue { "dynamic" : gqueue.New(), "bounded" : gqueue.NewBounded(10) } {
+        for i := 0; i < 5; i++ {
+            q.Push(i)
+        }
+        q.Close()
+        q.Push(5)
+        for i := 0; i < 3; i++ {
+            if v := q.Pop(); v != i {
+                t.Errorf("%s: unexpected popped item %v, expected %d", name, v, i)
+            }
+        }
+        items := q.CloseAndDrain()
+        if len(items) != 2 || items[0] != 3 || items[1] != 4 {
+            t.Errorf("%s: unexpected drained items %v, expected [3 4]", name, items)
+        }
+        if v := q.Pop(); v != nil {
+            t.Errorf("%s: unexpected popped item %v from the drained queue", name, v)
+        }
+        if v, ok := q.PopTimeout(time.Second); ok || v != nil {
+            t.Errorf("%s: unexpected result %v, %v from the drained queue", name, v, ok)
+        }
+        if n := q.Len(); n != 0 {
+            t.Errorf("%s: unexpected length %d, expected 0", name, n)
+        }
+    }
+}
+
+// 与Close并发执行的写入：TryPush返回true的数据在关闭后一定能够取出，Len与取出的数据条数一致
+func TestQueue_CloseConcurrentPush(t *testing.T) {
+    for round := 0; round < 20; round++ {
+        for name, q := range map[string]*gqueue.Queue { "dynamic" : gqueue.New(), "bounded" : gqueue.NewBounded(100000) } {
+            pushed := make([]int, 8)
+            wg     := sync.WaitGroup{}
+            for i := 0; i < len(pushed); i++ {
+                wg.Add(1)
+                go func(i int) {
+                    defer wg.Done()
+                    for q.TryPush(i) {
+                        pushed[i]++
+                    }
+                }(i)
+            }
+            time.Sleep(time.Millisecond)
+            q.Close()
+            wg.Wait()
+            total := 0
+            for _, n := range pushed {
+                total += n
+            }
+            if n := q.Len(); n != total {
+                t.Fatalf("%s: unexpected length %d after close, expected %d", name, n, total)
+            }
+            if items := q.CloseAndDrain(); len(items) != total {
+                t.Fatalf("%s: drained %d items, expected %d", name, len(items), total)
+            }
+        }
+    }
+}
+
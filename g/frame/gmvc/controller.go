@@ -8,6 +8,9 @@
 package gmvc
 
 import (
+    "strings"
+    "errors"
+    "fmt"
     "gitee.com/johng/gf/g/net/ghttp"
 )
 
@@ -21,7 +24,8 @@ type Controller struct {
     View     *View           // 视图对象
 }
 
-// 控制器初始化接口方法
+// 控制器初始化接口方法，在路由方法之前执行，控制器可以覆盖该方法实现每一次请求的初始化逻辑(例如鉴权、开启事务)，
+// 覆盖时需要调用c.Controller.Init(r)完成基类的初始化；调用Exit()可以中止请求，后续的路由方法及Shut方法都不会执行
 func (c *Controller) Init(r *ghttp.Request) {
     c.Request  = r
     c.Response = r.Response
@@ -31,14 +35,51 @@ func (c *Controller) Init(r *ghttp.Request) {
     c.Session  = r.Session
 }
 
-// 控制器结束请求接口方法
+// 控制器结束请求接口方法，在路由方法之后执行(即使路由方法调用了Exit()或者产生了panic)，
+// 控制器可以覆盖该方法实现每一次请求的清理逻辑(例如提交/回滚事务)
 func (c *Controller) Shut(r *ghttp.Request) {
 
 }
 
+// 将请求提交的数据(JSON或者表单)解析到struct对象上，参数pointer应当为一个struct对象的指针，同Request.Parse
+func (c *Controller) Parse(pointer interface{}) error {
+    return c.Request.Parse(pointer)
+}
+
 // 退出请求执行
 func (c *Controller) Exit() {
     c.Request.Exit()
 }
 
 
+
+// 显式绑定路由处理函数，每一次请求都会初始化一个新的基类控制器对象传递给handler，不依赖于反射的方法名称匹配，
+// method为HTTP Method(不区分大小写，ALL表示所有的HTTP Method)，例如：
+// gmvc.BindHandler(s, "POST", "/user/login", func(c *gmvc.Controller) { ... })
+// 显式绑定的路由优先于BindController/BindControllerRest等反射绑定的路由(与注册的先后顺序无关)
+func BindHandler(s *ghttp.Server, method, pattern string, handler func(c *Controller)) error {
+    return s.BindControllerFunc(method, pattern, &Controller{}, func(c ghttp.Controller) {
+        handler(c.(*Controller))
+    })
+}
+
+// 通过map批量显式绑定路由处理函数，键名格式为"method:pattern"(method不区分大小写)，
+// 不带method前缀时表示所有的HTTP Method，例如：
+// gmvc.BindMap(s, map[string]func(c *gmvc.Controller){ "GET:/user" : ..., "DELETE:/user/{id}" : ... })
+func BindMap(s *ghttp.Server, m map[string]func(c *Controller)) error {
+    for key, handler := range m {
+        method  := ""
+        pattern := key
+        if pos := strings.Index(key, ":"); pos > 0 && !strings.Contains(key[0 : pos], "/") {
+            method  = key[0 : pos]
+            pattern = key[pos + 1:]
+        }
+        if handler == nil {
+            return errors.New(fmt.Sprintf(`nil handler for pattern "%s"`, key))
+        }
+        if err := BindHandler(s, method, pattern, handler); err != nil {
+            return err
+        }
+    }
+    return nil
+}
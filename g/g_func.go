@@ -21,6 +21,9 @@ const (
     LOG_LEVEL_WARN = glog.LEVEL_WARN
     LOG_LEVEL_ERRO = glog.LEVEL_ERRO
     LOG_LEVEL_CRIT = glog.LEVEL_CRIT
+    LOG_LEVEL_NONE = glog.LEVEL_NONE
+    LOG_LEVEL_DEV  = glog.LEVEL_DEV
+    LOG_LEVEL_PROD = glog.LEVEL_PROD
 )
 
 // 动态变量
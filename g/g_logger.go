@@ -32,6 +32,11 @@ func SetLogLevel(level int) {
     glog.SetLevel(level)
 }
 
+// 设置最低的日志显示等级，低于该等级的日志不显示
+func SetLogMinLevel(level int) {
+    glog.SetMinLevel(level)
+}
+
 // 获取设置的日志显示等级
 func GetLogLevel() int {
     return glog.GetLevel()
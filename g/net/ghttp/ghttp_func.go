@@ -8,6 +8,7 @@ package ghttp
 
 import (
     "gitee.com/johng/gf/g/encoding/gurl"
+    "strconv"
     "strings"
 )
 
@@ -25,4 +26,9 @@ func BuildParams(params map[string]string) string {
         }
     }
     return s
-}
\ No newline at end of file
+}
+// 判断参数值是否为合法的数值(整数或者浮点数)，用于数值类型的参数获取方法：参数值不是合法的数值时返回给定的默认值
+func isNumericValue(value string) bool {
+    _, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+    return err == nil
+}
@@ -39,6 +39,7 @@ type Request struct {
     clientIp      *gtype.String       // 解析过后的客户端IP地址
     isFileRequest bool                // 是否为静态文件请求(非服务请求，当静态文件存在时，优先级会被服务请求高，被识别为文件请求)
     isFileServe   bool                // 是否为文件处理(调用Server.serveFile时设置为true), isFileRequest为true时isFileServe也为true
+    body          *bodyLimitReader    // 带有大小限制的请求内容读取对象(Request.Body)
 }
 
 // 创建一个Request对象
@@ -57,6 +58,14 @@ func newRequest(s *Server, r *http.Request, w http.ResponseWriter) *Request {
         parsedHost : gtype.NewString(),
         clientIp   : gtype.NewString(),
     }
+    // 请求内容大小限制
+    if r.Body != nil {
+        request.body = &bodyLimitReader {
+            ReadCloser : r.Body,
+            limit      : s.config.MaxRequestBody,
+        }
+        request.Body = request.body
+    }
     // 会话处理
     request.Cookie           = GetCookie(request)
     request.Session          = GetSession(request)
@@ -64,17 +73,23 @@ func newRequest(s *Server, r *http.Request, w http.ResponseWriter) *Request {
     return request
 }
 
-// 获取Web Socket连接对象(如果是非WS请求会失败，注意检查然会的error结果)
+// 获取Web Socket连接对象(如果是非WS请求会失败，注意检查然会的error结果)，
+// 连接使用完毕后(例如ReadMessage返回错误表示客户端已断开)需要调用Close关闭连接，需要心跳检测时调用KeepAlive
 func (r *Request) WebSocket() (*WebSocket, error) {
     if conn, err := wsUpgrader.Upgrade(r.Response.ResponseWriter.ResponseWriter, &r.Request, nil); err == nil {
-        return &WebSocket {
-            conn,
-        }, nil
+        return newWebSocket(conn), nil
     } else {
         return nil, err
     }
 }
 
+// 获取底层的*http.Request对象，用于需要标准库请求对象的第三方库(例如OAuth)。
+// 返回的对象与当前Request共享请求数据(Header/URL/Body等)，修改后对框架同样生效；
+// 请求内容(Body)只能读取一次，读取后框架的参数解析(POST参数/GetRaw等)将无法再获取请求内容。
+func (r *Request) Raw() *http.Request {
+    return &r.Request
+}
+
 // 获得指定名称的参数字符串(Router/GET/POST)，同 GetRequestString
 // 这是常用方法的简化别名
 func (r *Request) Get(key string, def ... string) string {
@@ -85,9 +100,12 @@ func (r *Request) GetVar(key string, def ... interface{}) *gvar.Var {
     return r.GetRequestVar(key, def...)
 }
 
-// 获取原始请求输入字符串，注意：只能获取一次，读完就没了
+// 获取原始请求输入字符串，注意：只能获取一次，读完就没了；
+// 请求内容超出大小限制(MaxRequestBody)时返回413状态码并退出当前请求的执行
 func (r *Request) GetRaw() []byte {
+    r.checkRequestBody()
     result, _ := ioutil.ReadAll(r.Body)
+    r.checkRequestBody()
     return result
 }
 
@@ -0,0 +1,95 @@
+// Copyright 2018 gf Author(https://gitee.com/johng/gf). All Rights Reserved.
+//
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT was not distributed with this file,
+// You can obtain one at https://gitee.com/johng/gf.
+// 请求内容大小限制.
+
+package ghttp
+
+import (
+    "errors"
+    "io"
+    "net/http"
+)
+
+// 请求内容超出大小限制时读取返回的错误
+var errRequestBodyTooLarge = errors.New("http: request body too large")
+
+// 带有大小限制的请求内容读取对象，超出限制时不再读取后续的内容
+type bodyLimitReader struct {
+    io.ReadCloser
+    limit    int64 // 请求内容的最大长度(byte)，小于等于0表示不限制
+    read     int64 // 已读取的内容长度
+    exceeded bool  // 是否超出了大小限制
+}
+
+// 读取请求内容，超出大小限制时返回错误errRequestBodyTooLarge。
+// 请求头中的Content-Length已经超出限制时不读取任何内容；分块传输(chunked)的请求在读取到超出限制的位置时返回错误。
+func (b *bodyLimitReader) Read(p []byte) (int, error) {
+    if b.exceeded {
+        return 0, errRequestBodyTooLarge
+    }
+    if b.limit <= 0 {
+        n, err := b.ReadCloser.Read(p)
+        b.read += int64(n)
+        return n, err
+    }
+    // 多读取一个字节用于判断是否超出限制
+    if remain := b.limit - b.read + 1; int64(len(p)) > remain {
+        p = p[:remain]
+    }
+    n, err := b.ReadCloser.Read(p)
+    b.read += int64(n)
+    if b.read > b.limit {
+        n        -= int(b.read - b.limit)
+        b.read    = b.limit
+        b.exceeded = true
+        return n, errRequestBodyTooLarge
+    }
+    return n, err
+}
+
+// 设置当前请求内容的最大长度(byte)，覆盖Server的MaxRequestBody配置，小于等于0表示不限制，
+// 例如在上传接口的控制器Init方法中调用以允许更大的请求内容；需要在读取请求内容之前调用。
+func (r *Request) SetMaxRequestBody(size int64) {
+    if r.body != nil {
+        r.body.limit = size
+    }
+}
+
+// 获取当前请求内容的最大长度(byte)，0表示不限制
+func (r *Request) GetMaxRequestBody() int64 {
+    if r.body != nil && r.body.limit > 0 {
+        return r.body.limit
+    }
+    return 0
+}
+
+// 判断请求体是否超出了内容大小限制，请求头中的Content-Length超出限制时同样返回true；
+// 超出限制时返回413状态码并退出当前请求的执行，同时关闭连接而不再读取剩余的请求内容
+func (r *Request) checkRequestBody() {
+    if r.body == nil || r.body.limit <= 0 {
+        return
+    }
+    if !r.body.exceeded && r.ContentLength > r.body.limit {
+        r.body.exceeded = true
+    }
+    if r.body.exceeded {
+        r.Response.Header().Set("Connection", "close")
+        r.Response.ClearBuffer()
+        r.Response.WriteStatus(http.StatusRequestEntityTooLarge)
+        r.Exit()
+    }
+}
+
+// 中间件：设置路由的请求内容最大长度，覆盖Server的MaxRequestBody配置，
+// 例如：s.BindMiddleware("/upload", ghttp.MaxRequestBody(100*1024*1024))
+func MaxRequestBody(size int64) Middleware {
+    return func(next HandlerFunc) HandlerFunc {
+        return func(r *Request) {
+            r.SetMaxRequestBody(size)
+            next(r)
+        }
+    }
+}
@@ -0,0 +1,35 @@
+// Copyright 2018 gf Author(https://gitee.com/johng/gf). All Rights Reserved.
+//
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT was not distributed with this file,
+// You can obtain one at https://gitee.com/johng/gf.
+
+package ghttp
+
+import (
+    "context"
+)
+
+// 获取请求的上下文对象，当客户端连接断开、请求处理完成或者Web Server关闭时(见Server.Shutdown)，上下文会被取消，
+// 常用于向数据库等下游调用传递超时控制，以及检测客户端是否已断开连接
+func (r *Request) Context() context.Context {
+    return r.Request.Context()
+}
+
+// 替换请求的上下文对象，常用于在中间件中设置超时控制或者附加自定义的上下文变量，
+// 后续的中间件及路由处理方法通过Context()获取到的是替换后的上下文
+func (r *Request) SetContext(ctx context.Context) {
+    if ctx != nil {
+        r.Request = *r.Request.WithContext(ctx)
+    }
+}
+
+// 在请求的上下文中附加自定义变量
+func (r *Request) SetCtxVar(key, value interface{}) {
+    r.SetContext(context.WithValue(r.Context(), key, value))
+}
+
+// 获取请求上下文中的自定义变量，不存在时返回nil
+func (r *Request) GetCtxVar(key interface{}) interface{} {
+    return r.Context().Value(key)
+}
@@ -0,0 +1,75 @@
+// Copyright 2018 gf Author(https://gitee.com/johng/gf). All Rights Reserved.
+//
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT was not distributed with this file,
+// You can obtain one at https://gitee.com/johng/gf.
+
+package ghttp
+
+import (
+    "encoding/json"
+    "errors"
+    "fmt"
+    "gitee.com/johng/gf/g/util/gconv"
+    "gitee.com/johng/gf/g/util/gvalid"
+    "mime"
+    "reflect"
+    "strings"
+)
+
+// 将请求提交的数据解析到struct对象上，参数pointer应当为一个struct对象的指针，根据请求的Content-Type进行解析：
+// 1、application/json(以及+json后缀的类型)：解析请求的JSON内容，属性映射按照encoding/json的规则(支持json标签)；
+// 2、其他类型(例如application/x-www-form-urlencoded、multipart/form-data以及没有提交内容的请求)：
+// 将router、get、post参数(同GetRequestMap)映射到struct属性上，支持params标签自定义参数与属性的映射关系。
+// 请求内容格式错误时返回错误信息，不会产生panic。注意请求内容只能读取一次。
+func (r *Request) Parse(pointer interface{}) error {
+    if v := reflect.ValueOf(pointer); v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+        return errors.New(fmt.Sprintf(`invalid parse target type "%T", while struct pointer is required`, pointer))
+    }
+    if r.isJsonContentType() {
+        body := r.GetRaw()
+        if len(body) == 0 {
+            return errors.New("empty JSON request body")
+        }
+        if err := json.Unmarshal(body, pointer); err != nil {
+            return errors.New(fmt.Sprintf(`invalid JSON request body: %v`, err))
+        }
+        return nil
+    }
+    tagmap := r.getStructParamsTagMap(pointer)
+    params := make(map[string]interface{})
+    for k, v := range r.GetRequestMap() {
+        params[k] = v
+    }
+    return gconv.Struct(params, pointer, tagmap)
+}
+
+// 将请求提交的数据解析到struct对象上(同Parse)，随后按照struct属性的gvalid标签(以及rules参数给定的校验规则)进行数据校验，
+// 例如：Name string `gvalid:"name@required|length:3,30#请输入名称|名称长度为:min到:max个字符"`，规则写法详见gvalid包。
+// 请求内容格式错误时返回解析的错误信息；校验失败时返回gvalid.Error类型的错误，包含每一个属性校验失败的规则及错误信息，
+// 可以通过类型断言获取，例如：if e, ok := err.(gvalid.Error); ok { r.Response.WriteStatus(400, e.String()) }。
+func (r *Request) GetStruct(pointer interface{}, rules...map[string]string) error {
+    if err := r.Parse(pointer); err != nil {
+        return err
+    }
+    // 校验时会修改规则参数，因此使用规则的拷贝
+    checkRules := make(map[string]string)
+    if len(rules) > 0 {
+        for k, v := range rules[0] {
+            checkRules[k] = v
+        }
+    }
+    if e := gvalid.CheckStruct(pointer, checkRules); e != nil {
+        return e
+    }
+    return nil
+}
+
+// 判断请求提交的内容是否为JSON格式
+func (r *Request) isJsonContentType() bool {
+    mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+    if err != nil {
+        return false
+    }
+    return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
@@ -15,8 +15,11 @@ func (r *Request) initPost() {
     if !r.parsedPost.Val() {
         // 快速保存，尽量避免并发问题
         r.parsedPost.Set(true)
-        // MultiMedia表单请求解析允许最大使用内存：1GB
-        r.ParseMultipartForm(1024*1024*1024)
+        r.checkRequestBody()
+        // MultiMedia表单请求解析允许最大使用内存(UploadMaxMemory)，超出部分保存到临时文件中
+        r.ParseMultipartForm(r.Server.config.UploadMaxMemory)
+        // 请求内容超出大小限制(MaxRequestBody)时返回413状态码并退出当前请求的执行
+        r.checkRequestBody()
     }
 }
 
@@ -67,7 +70,7 @@ func (r *Request) GetPostBool(key string, def ... bool) bool {
 
 func (r *Request) GetPostInt(key string, def ... int) int {
     value := r.GetPostString(key)
-    if value != "" {
+    if value != "" && isNumericValue(value) {
         return gconv.Int(value)
     }
     if len(def) > 0 {
@@ -89,7 +92,7 @@ func (r *Request) GetPostInts(key string, def ... []int) []int {
 
 func (r *Request) GetPostUint(key string, def ... uint) uint {
     value := r.GetPostString(key)
-    if value != "" {
+    if value != "" && isNumericValue(value) {
         return gconv.Uint(value)
     }
     if len(def) > 0 {
@@ -100,7 +103,7 @@ func (r *Request) GetPostUint(key string, def ... uint) uint {
 
 func (r *Request) GetPostFloat32(key string, def ... float32) float32 {
     value := r.GetPostString(key)
-    if value != "" {
+    if value != "" && isNumericValue(value) {
         return gconv.Float32(value)
     }
     if len(def) > 0 {
@@ -111,7 +114,7 @@ func (r *Request) GetPostFloat32(key string, def ... float32) float32 {
 
 func (r *Request) GetPostFloat64(key string, def ... float64) float64 {
     value := r.GetPostString(key)
-    if value != "" {
+    if value != "" && isNumericValue(value) {
         return gconv.Float64(value)
     }
     if len(def) > 0 {
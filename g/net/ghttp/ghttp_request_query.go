@@ -66,7 +66,7 @@ func (r *Request) GetQueryBool(key string, def ... bool) bool {
 
 func (r *Request) GetQueryInt(key string, def ... int) int {
     value := r.GetQueryString(key)
-    if value != "" {
+    if value != "" && isNumericValue(value) {
         return gconv.Int(value)
     }
     if len(def) > 0 {
@@ -88,7 +88,7 @@ func (r *Request) GetQueryInts(key string, def ... []int) []int {
 
 func (r *Request) GetQueryUint(key string, def ... uint) uint {
     value := r.GetQueryString(key)
-    if value != "" {
+    if value != "" && isNumericValue(value) {
         return gconv.Uint(value)
     }
     if len(def) > 0 {
@@ -99,7 +99,7 @@ func (r *Request) GetQueryUint(key string, def ... uint) uint {
 
 func (r *Request) GetQueryFloat32(key string, def ... float32) float32 {
     value := r.GetQueryString(key)
-    if value != "" {
+    if value != "" && isNumericValue(value) {
         return gconv.Float32(value)
     }
     if len(def) > 0 {
@@ -110,7 +110,7 @@ func (r *Request) GetQueryFloat32(key string, def ... float32) float32 {
 
 func (r *Request) GetQueryFloat64(key string, def ... float64) float64 {
     value := r.GetQueryString(key)
-    if value != "" {
+    if value != "" && isNumericValue(value) {
         return gconv.Float64(value)
     }
     if len(def) > 0 {
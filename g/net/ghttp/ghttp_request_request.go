@@ -61,7 +61,7 @@ func (r *Request) GetRequestBool(key string, def ... bool) bool {
 
 func (r *Request) GetRequestInt(key string, def ... int) int {
     value := r.GetRequestString(key)
-    if value != "" {
+    if value != "" && isNumericValue(value) {
         return gconv.Int(value)
     }
     if len(def) > 0 {
@@ -83,7 +83,7 @@ func (r *Request) GetRequestInts(key string, def ... []int) []int {
 
 func (r *Request) GetRequestUint(key string, def ... uint) uint {
     value := r.GetRequestString(key)
-    if value != "" {
+    if value != "" && isNumericValue(value) {
         return gconv.Uint(value)
     }
     if len(def) > 0 {
@@ -94,7 +94,7 @@ func (r *Request) GetRequestUint(key string, def ... uint) uint {
 
 func (r *Request) GetRequestFloat32(key string, def ... float32) float32 {
     value := r.GetRequestString(key)
-    if value != "" {
+    if value != "" && isNumericValue(value) {
         return gconv.Float32(value)
     }
     if len(def) > 0 {
@@ -105,7 +105,7 @@ func (r *Request) GetRequestFloat32(key string, def ... float32) float32 {
 
 func (r *Request) GetRequestFloat64(key string, def ... float64) float64 {
     value := r.GetRequestString(key)
-    if value != "" {
+    if value != "" && isNumericValue(value) {
         return gconv.Float64(value)
     }
     if len(def) > 0 {
@@ -6,6 +6,10 @@
 
 package ghttp
 
+import (
+    "gitee.com/johng/gf/g/container/gvar"
+)
+
 func (r *Request) SetRouterString(key, value string) {
     r.routerVars[key] = []string{value}
 }
@@ -14,11 +18,15 @@ func (r *Request) AddRouterString(key, value string) {
     r.routerVars[key] = append(r.routerVars[key], value)
 }
 
-// 获得路由解析参数
-func (r *Request) GetRouterString(key string) string {
+// 获得路由解析参数，例如：路由规则/user/:id匹配/user/1时，GetRouterString("id")返回"1"；
+// 路由规则/file/*path匹配/file/a/b.txt时，GetRouterString("path")返回"a/b.txt"
+func (r *Request) GetRouterString(key string, def ... string) string {
     if v := r.GetRouterArray(key); v != nil {
         return v[0]
     }
+    if len(def) > 0 {
+        return def[0]
+    }
     return ""
 }
 
@@ -30,3 +38,24 @@ func (r *Request) GetRouterArray(key string) []string {
     return nil
 }
 
+// 获得路由解析参数的泛型变量对象，便于进行类型转换，例如：GetRouterVar("id").Int()
+func (r *Request) GetRouterVar(key string, def ... interface{}) *gvar.Var {
+    if v := r.GetRouterArray(key); v != nil {
+        return gvar.New(v[0])
+    }
+    if len(def) > 0 {
+        return gvar.New(def[0])
+    }
+    return gvar.New(nil)
+}
+
+// 获得所有的路由解析参数，同名参数只返回第一个值
+func (r *Request) GetRouterMap() map[string]string {
+    m := make(map[string]string, len(r.routerVars))
+    for k, v := range r.routerVars {
+        if len(v) > 0 {
+            m[k] = v[0]
+        }
+    }
+    return m
+}
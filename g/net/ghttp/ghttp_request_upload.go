@@ -0,0 +1,110 @@
+// Copyright 2018 gf Author(https://gitee.com/johng/gf). All Rights Reserved.
+//
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT was not distributed with this file,
+// You can obtain one at https://gitee.com/johng/gf.
+// 文件上传处理.
+
+package ghttp
+
+import (
+    "errors"
+    "fmt"
+    "io"
+    "mime/multipart"
+    "os"
+    "path/filepath"
+    "strings"
+    "gitee.com/johng/gf/g/os/gfile"
+)
+
+// 上传文件对象
+type UploadFile struct {
+    Filename    string                // 客户端上传的文件名称(不包含路径)
+    Size        int64                 // 文件大小(byte)
+    ContentType string                // 客户端提交的文件类型
+    header      *multipart.FileHeader // 底层的上传文件信息
+}
+
+// 获取指定表单字段名称的上传文件(multipart/form-data)，字段包含多个文件时返回第一个文件，
+// 没有上传该字段的文件时返回nil；文件大小超出UploadMaxSize配置时返回错误信息。
+// 上传文件解析时超出UploadMaxMemory配置的部分会保存到临时文件中，临时文件在请求结束后自动删除。
+func (r *Request) GetUploadFile(name string) (*UploadFile, error) {
+    files, err := r.GetUploadFiles(name)
+    if err != nil || len(files) == 0 {
+        return nil, err
+    }
+    return files[0], nil
+}
+
+// 获取指定表单字段名称的所有上传文件，没有上传该字段的文件时返回nil，其他同GetUploadFile
+func (r *Request) GetUploadFiles(name string) ([]*UploadFile, error) {
+    r.initPost()
+    if r.MultipartForm == nil {
+        return nil, nil
+    }
+    headers := r.MultipartForm.File[name]
+    if len(headers) == 0 {
+        return nil, nil
+    }
+    files   := make([]*UploadFile, 0, len(headers))
+    maxSize := r.Server.config.UploadMaxSize
+    for _, h := range headers {
+        if maxSize > 0 && h.Size > maxSize {
+            return nil, errors.New(fmt.Sprintf(`upload file "%s" size %d exceeds the limit %d`, h.Filename, h.Size, maxSize))
+        }
+        files = append(files, &UploadFile {
+            Filename    : filepath.Base(strings.Replace(h.Filename, "\\", "/", -1)),
+            Size        : h.Size,
+            ContentType : h.Header.Get("Content-Type"),
+            header      : h,
+        })
+    }
+    return files, nil
+}
+
+// 打开上传文件用于读取内容，使用完毕需要关闭
+func (f *UploadFile) Open() (multipart.File, error) {
+    return f.header.Open()
+}
+
+// 将上传文件保存到目录dir中(目录不存在时自动创建)，返回保存的文件绝对路径；
+// 可选参数name为保存的文件名称，默认使用客户端上传的文件名称，同名文件会被覆盖。
+func (f *UploadFile) Save(dir string, name...string) (path string, err error) {
+    filename := f.Filename
+    if len(name) > 0 && name[0] != "" {
+        filename = filepath.Base(name[0])
+    }
+    if filename == "" || filename == "." || filename == ".." || filename == string(filepath.Separator) {
+        return "", errors.New(fmt.Sprintf(`invalid upload file name "%s"`, filename))
+    }
+    if !gfile.Exists(dir) {
+        if err = gfile.Mkdir(dir); err != nil {
+            return "", err
+        }
+    } else if !gfile.IsDir(dir) {
+        return "", errors.New(fmt.Sprintf(`"%s" is not a directory`, dir))
+    }
+    src, err := f.Open()
+    if err != nil {
+        return "", err
+    }
+    defer src.Close()
+    path = gfile.RealPath(dir) + gfile.Separator + filename
+    dst, err := os.Create(path)
+    if err != nil {
+        return "", err
+    }
+    defer dst.Close()
+    if _, err = io.Copy(dst, src); err != nil {
+        return "", err
+    }
+    return path, nil
+}
+
+// 删除上传文件解析时产生的临时文件
+func (r *Request) removeUploadFiles() {
+    if r.MultipartForm != nil {
+        r.MultipartForm.RemoveAll()
+    }
+}
@@ -0,0 +1,129 @@
+// Copyright 2018 gf Author(https://gitee.com/johng/gf). All Rights Reserved.
+//
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT was not distributed with this file,
+// You can obtain one at https://gitee.com/johng/gf.
+
+// go test *.go -v
+
+package ghttp
+
+import (
+    "bytes"
+    "io/ioutil"
+    "mime/multipart"
+    "net/http"
+    "net/http/httptest"
+    "os"
+    "path/filepath"
+    "strings"
+    "testing"
+    "gitee.com/johng/gf/g/os/gfile"
+)
+
+// 构造上传文件的multipart/form-data请求，files为文件名称与文件内容的映射
+func newUploadRequest(t *testing.T, uri string, field string, files [][2]string) *http.Request {
+    body   := new(bytes.Buffer)
+    writer := multipart.NewWriter(body)
+    for _, file := range files {
+        part, err := writer.CreateFormFile(field, file[0])
+        if err != nil {
+            t.Fatal(err)
+        }
+        part.Write([]byte(file[1]))
+    }
+    writer.WriteField("name", "john")
+    if err := writer.Close(); err != nil {
+        t.Fatal(err)
+    }
+    request := httptest.NewRequest("POST", uri, body)
+    request.Header.Set("Content-Type", writer.FormDataContentType())
+    return request
+}
+
+// 上传两个文件并保存到目录中，保存的文件内容需要与上传的内容一致，同时普通的表单字段仍然可以获取
+func TestRequest_GetUploadFiles(t *testing.T) {
+    dir, err := ioutil.TempDir("", "ghttp")
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer os.RemoveAll(dir)
+
+    files := [][2]string {
+        {"a.txt",  "content of file a"},
+        {"b.json", `{"name":"b"}`},
+    }
+    saved := make([]string, 0)
+    s     := testServer("TestRequest_GetUploadFiles")
+    s.BindHandler("/upload", func(r *Request) {
+        uploads, err := r.GetUploadFiles("file")
+        if err != nil {
+            t.Error(err)
+            return
+        }
+        if len(uploads) != len(files) {
+            t.Errorf("expected %d upload files, got %d", len(files), len(uploads))
+            return
+        }
+        for i, f := range uploads {
+            if f.Filename != files[i][0] || f.Size != int64(len(files[i][1])) {
+                t.Errorf(`unexpected upload file "%s" with size %d`, f.Filename, f.Size)
+            }
+            path, err := f.Save(dir)
+            if err != nil {
+                t.Error(err)
+                continue
+            }
+            saved = append(saved, path)
+        }
+        if name := r.GetPostString("name"); name != "john" {
+            t.Errorf(`expected form field "name" to be "john", got "%s"`, name)
+        }
+    })
+    w := httptest.NewRecorder()
+    s.handleRequest(w, newUploadRequest(t, "/upload", "file", files))
+    if w.Code != http.StatusOK {
+        t.Fatalf("unexpected status code %d: %s", w.Code, w.Body.String())
+    }
+    if len(saved) != len(files) {
+        t.Fatalf("expected %d saved files, got %d", len(files), len(saved))
+    }
+    for i, path := range saved {
+        if filepath.Dir(path) != gfile.RealPath(dir) {
+            t.Errorf(`file saved to unexpected path "%s"`, path)
+        }
+        content, err := ioutil.ReadFile(path)
+        if err != nil {
+            t.Error(err)
+            continue
+        }
+        if string(content) != files[i][1] {
+            t.Errorf(`unexpected content of saved file "%s": %s`, path, content)
+        }
+    }
+}
+
+// 单个文件超出UploadMaxSize时返回错误，并且不能为上传文件名称中携带的路径创建文件
+func TestRequest_GetUploadFile(t *testing.T) {
+    s := testServer("TestRequest_GetUploadFile")
+    s.SetUploadMaxSize(10)
+    s.BindHandler("/upload", func(r *Request) {
+        if f, err := r.GetUploadFile("small"); err != nil || f == nil {
+            t.Errorf("expected upload file, got %v, %v", f, err)
+        } else if f.Filename != "passwd" {
+            t.Errorf(`expected file name without path, got "%s"`, f.Filename)
+        }
+        if f, err := r.GetUploadFile("none"); err != nil || f != nil {
+            t.Errorf("expected no upload file, got %v, %v", f, err)
+        }
+    })
+    s.BindHandler("/upload-large", func(r *Request) {
+        if _, err := r.GetUploadFile("large"); err == nil {
+            t.Error("expected error for upload file exceeding the size limit")
+        }
+    })
+    w := httptest.NewRecorder()
+    s.handleRequest(w, newUploadRequest(t, "/upload", "small", [][2]string{{"../../etc/passwd", "small"}}))
+    w  = httptest.NewRecorder()
+    s.handleRequest(w, newUploadRequest(t, "/upload-large", "large", [][2]string{{"large.txt", strings.Repeat("x", 100)}}))
+}
@@ -12,6 +12,7 @@ import (
     "gitee.com/johng/gf/g/util/gconv"
     "gitee.com/johng/gf/g/encoding/gparser"
     "strconv"
+    "strings"
     "fmt"
 )
 
@@ -61,6 +62,18 @@ func (r *Response) Write(content ... interface{}) {
     r.length = len(r.buffer)
 }
 
+// 获取底层的http.ResponseWriter对象，用于需要直接操作标准库返回对象的场景(例如第三方库的处理方法、自定义的流式输出)，
+// 仍然可以在路由处理方法及中间件中使用，路由及中间件的执行流程不受影响。
+// 注意：一旦通过返回的对象写入了返回头信息(WriteHeader)或者返回内容(Write)，框架将不再输出自身的返回头信息
+// (Server、Cookie、压缩等)及缓冲区内容，通过Response.Write等方法写入缓冲区的内容将被丢弃，状态码以直接写入的为准；
+// 返回对象支持http.Flusher及http.Hijacker接口(底层对象支持时)。
+func (r *Response) Raw() http.ResponseWriter {
+    return &rawResponseWriter {
+        ResponseWriter : r.ResponseWriter.ResponseWriter,
+        writer         : &r.ResponseWriter,
+    }
+}
+
 // 返回信息，支持自定义format格式
 func (r *Response) Writef(format string, params ... interface{}) {
     r.Write(fmt.Sprintf(format, params...))
@@ -81,9 +94,10 @@ func (r *Response) Writefln(format string, params ... interface{}) {
     r.Writeln(fmt.Sprintf(format, params...))
 }
 
-// 返回JSON
+// 返回JSON，内容编码失败时返回500状态码及错误信息
 func (r *Response) WriteJson(content interface{}) error {
     if b, err := gparser.VarToJson(content); err != nil {
+        r.WriteStatus(http.StatusInternalServerError, err.Error())
         return err
     } else {
         r.Header().Set("Content-Type", "application/json")
@@ -92,9 +106,19 @@ func (r *Response) WriteJson(content interface{}) error {
     return nil
 }
 
-// 返回JSONP
+// 返回JSON，并退出当前请求的后续执行
+func (r *Response) WriteJsonExit(content interface{}) error {
+    if err := r.WriteJson(content); err != nil {
+        return err
+    }
+    r.request.Exit()
+    return nil
+}
+
+// 返回JSONP，内容编码失败时返回500状态码及错误信息
 func (r *Response) WriteJsonP(content interface{}) error {
     if b, err := gparser.VarToJson(content); err != nil {
+        r.WriteStatus(http.StatusInternalServerError, err.Error())
         return err
     } else {
         //r.Header().Set("Content-Type", "application/json")
@@ -111,9 +135,10 @@ func (r *Response) WriteJsonP(content interface{}) error {
     return nil
 }
 
-// 返回XML
+// 返回XML，内容编码失败时返回500状态码及错误信息
 func (r *Response) WriteXml(content interface{}, rootTag...string) error {
     if b, err := gparser.VarToXml(content, rootTag...); err != nil {
+        r.WriteStatus(http.StatusInternalServerError, err.Error())
         return err
     } else {
         r.Header().Set("Content-Type", "application/xml")
@@ -122,6 +147,49 @@ func (r *Response) WriteXml(content interface{}, rootTag...string) error {
     return nil
 }
 
+// 返回XML，并退出当前请求的后续执行
+func (r *Response) WriteXmlExit(content interface{}, rootTag...string) error {
+    if err := r.WriteXml(content, rootTag...); err != nil {
+        return err
+    }
+    r.request.Exit()
+    return nil
+}
+
+// 根据请求的Accept头信息返回JSON或者XML格式的内容：
+// 当客户端接受XML(application/xml或者text/xml)且优先级不低于JSON时返回XML，其他情况默认返回JSON
+func (r *Response) WriteByAccept(content interface{}, rootTag...string) error {
+    if acceptXml(r.request.Header.Get("Accept")) {
+        return r.WriteXml(content, rootTag...)
+    }
+    return r.WriteJson(content)
+}
+
+// 判断Accept头信息是否优先接受XML格式
+func acceptXml(accept string) bool {
+    xmlQ, jsonQ := -1.0, -1.0
+    for _, v := range strings.Split(accept, ",") {
+        array := strings.Split(strings.TrimSpace(v), ";")
+        q     := 1.0
+        for _, p := range array[1:] {
+            if p = strings.TrimSpace(p); strings.HasPrefix(p, "q=") {
+                q = gconv.Float64(p[2:])
+            }
+        }
+        switch strings.ToLower(strings.TrimSpace(array[0])) {
+            case "application/xml", "text/xml":
+                if q > xmlQ {
+                    xmlQ = q
+                }
+            case "application/json":
+                if q > jsonQ {
+                    jsonQ = q
+                }
+        }
+    }
+    return xmlQ > 0 && xmlQ >= jsonQ
+}
+
 // 允许AJAX跨域访问
 func (r *Response) SetAllowCrossDomainRequest(allowOrigin string, allowMethods string, maxAge...int) {
     age := 3628800
@@ -206,11 +274,45 @@ func (r *Response) ClearBuffer() {
 
 // 输出缓冲区数据到客户端
 func (r *Response) OutputBuffer() {
+    // 已经直接写入了底层的返回对象(Raw)
+    if r.isRaw() {
+        r.ClearBuffer()
+        return
+    }
     r.Header().Set("Server", r.Server.config.ServerAgent)
-    //r.handleGzip()
+    // HEAD请求不输出内容，只输出内容类型及长度
+    if r.request.Method == "HEAD" && r.BufferLength() > 0 {
+        if r.Header().Get("Content-Type") == "" {
+            r.Header().Set("Content-Type", http.DetectContentType(r.Buffer()))
+        }
+        r.Header().Set("Content-Length", strconv.Itoa(r.BufferLength()))
+        r.ClearBuffer()
+    }
+    // 返回内容压缩
+    if encoding := r.compressEncoding(r.BufferLength()); encoding != "" {
+        r.compressBuffer(encoding)
+    }
     r.Writer.OutputBuffer()
 }
 
+// 立即输出缓冲区数据到客户端(流式输出)，返回头信息(包括Cookie)在第一次调用时输出，此后的修改不再生效。
+// 开启了压缩特性时，流式输出的内容同样会被压缩(由于无法预知内容总长度，不判断最小压缩长度)，每次调用都会刷新压缩数据。
+func (r *Response) Flush() {
+    if r.isRaw() {
+        r.ClearBuffer()
+        return
+    }
+    if !r.wroteHeader {
+        r.Header().Set("Server", r.Server.config.ServerAgent)
+        r.request.Cookie.Output()
+        if encoding := r.compressEncoding(-1); encoding != "" {
+            r.setCompressHeader(encoding)
+            r.encoder = newCompressWriter(r.ResponseWriter.ResponseWriter, encoding, r.Server.config.GzipLevel)
+        }
+    }
+    r.Writer.Flush()
+}
+
 // 获取输出到客户端的数据大小
 func (r *Response) ContentSize() int {
     if r.Status == http.StatusOK && r.length > 0 {
@@ -7,6 +7,28 @@
 
 package ghttp
 
+import (
+    "bytes"
+    "compress/gzip"
+    "compress/zlib"
+    "io"
+    "net/http"
+    "strconv"
+    "strings"
+    "gitee.com/johng/gf/g/util/gconv"
+)
+
+const (
+    gDEFAULT_GZIP_MIN_LENGTH = 1024 // 默认进行压缩的最小内容长度(byte)
+)
+
+// 返回内容的压缩输出对象(gzip/deflate)
+type compressWriter interface {
+    io.Writer
+    Flush() error
+    Close() error
+}
+
 // 默认的gzip压缩文件类型
 var defaultGzipContentTypes = []string{
     "application/atom+xml",
@@ -44,27 +66,107 @@ var defaultGzipContentTypes = []string{
     "text/xml",
 }
 
-//// 返回内容gzip检查处理
-//func (r *Response) handleGzip() {
-//    // 如果客户端支持gzip压缩，并且服务端设置开启gzip压缩特性，那么执行压缩
-//    encoding := r.request.Header.Get("Accept-Encoding")
-//    if encoding != "" && strings.Contains(encoding, "gzip") {
-//        mimeType := ""
-//        ext := gfile.Ext(r.request.URL.Path)
-//        if ext != "" {
-//            mimeType = strings.Split(mime.TypeByExtension(ext), ";")[0]
-//        }
-//        if mimeType == "" {
-//            contentType := r.Header().Get("Content-Type")
-//            if contentType != "" {
-//                mimeType = strings.Split(contentType, ";")[0]
-//            }
-//        }
-//
-//        if _, ok := r.Server.gzipMimesMap[mimeType]; ok {
-//            r.SetBuffer(gcompress.Gzip(r.buffer))
-//            r.Header().Set("Content-Length",   gconv.String(len(r.buffer)))
-//            r.Header().Set("Content-Encoding", "gzip")
-//        }
-//    }
-//}
\ No newline at end of file
+// 判断返回内容是否需要压缩，返回压缩编码(gzip/deflate)，返回空字符串表示不压缩。
+// 只有开启了压缩特性、客户端支持压缩、返回头信息尚未输出、内容类型属于允许压缩的类型(GzipContentTypes)，
+// 并且内容长度不小于最小压缩长度(GzipMinLength)时才进行压缩；length小于0表示不判断内容长度(流式输出)。
+// 已经设置了Content-Encoding的内容(例如已压缩的数据)、分段内容以及HEAD请求不会被压缩。
+func (r *Response) compressEncoding(length int) string {
+    config := r.Server.config
+    if !config.GzipEnabled || r.wroteHeader || r.request.Method == "HEAD" {
+        return ""
+    }
+    if length >= 0 && (length == 0 || length < config.GzipMinLength) {
+        return ""
+    }
+    header := r.Header()
+    if header.Get("Content-Encoding") != "" || header.Get("Content-Range") != "" {
+        return ""
+    }
+    encoding := acceptEncoding(r.request.Header.Get("Accept-Encoding"))
+    if encoding == "" {
+        return ""
+    }
+    // 压缩后无法再根据内容判断内容类型，因此需要提前设置Content-Type
+    contentType := header.Get("Content-Type")
+    if contentType == "" {
+        contentType = http.DetectContentType(r.buffer)
+        header.Set("Content-Type", contentType)
+    }
+    mimeType := strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+    for _, v := range config.GzipContentTypes {
+        if strings.EqualFold(v, mimeType) {
+            return encoding
+        }
+    }
+    return ""
+}
+
+// 设置压缩输出的返回头信息，压缩后的内容长度与原始内容不同，因此删除已设置的Content-Length
+func (r *Response) setCompressHeader(encoding string) {
+    header := r.Header()
+    header.Set("Content-Encoding", encoding)
+    header.Add("Vary", "Accept-Encoding")
+    header.Del("Content-Length")
+}
+
+// 压缩缓冲区的内容
+func (r *Response) compressBuffer(encoding string) {
+    var buffer bytes.Buffer
+    writer := newCompressWriter(&buffer, encoding, r.Server.config.GzipLevel)
+    writer.Write(r.Buffer())
+    writer.Close()
+    r.SetBuffer(buffer.Bytes())
+    r.setCompressHeader(encoding)
+    r.Header().Set("Content-Length", strconv.Itoa(buffer.Len()))
+}
+
+// 创建压缩输出对象，压缩级别不合法时使用默认的压缩级别
+func newCompressWriter(w io.Writer, encoding string, level int) compressWriter {
+    if encoding == "deflate" {
+        if writer, err := zlib.NewWriterLevel(w, level); err == nil {
+            return writer
+        }
+        return zlib.NewWriter(w)
+    }
+    if writer, err := gzip.NewWriterLevel(w, level); err == nil {
+        return writer
+    }
+    return gzip.NewWriter(w)
+}
+
+// 根据Accept-Encoding头信息判断客户端支持的压缩编码，优先使用gzip，q=0表示不接受该编码；
+// "*"只对没有明确列出的编码生效，例如"gzip;q=0, *"不使用gzip
+func acceptEncoding(accept string) string {
+    gzipQ, deflateQ, anyQ := -1.0, -1.0, -1.0
+    for _, v := range strings.Split(accept, ",") {
+        array := strings.Split(strings.TrimSpace(v), ";")
+        q     := 1.0
+        for _, p := range array[1:] {
+            if p = strings.TrimSpace(p); strings.HasPrefix(p, "q=") {
+                q = gconv.Float64(p[2:])
+            }
+        }
+        switch strings.ToLower(strings.TrimSpace(array[0])) {
+            case "gzip", "x-gzip":
+                gzipQ = q
+            case "deflate":
+                deflateQ = q
+            case "*":
+                anyQ = q
+        }
+    }
+    // 小于0表示没有明确列出该编码
+    if gzipQ < 0 {
+        gzipQ = anyQ
+    }
+    if deflateQ < 0 {
+        deflateQ = anyQ
+    }
+    if gzipQ > 0 && gzipQ >= deflateQ {
+        return "gzip"
+    }
+    if deflateQ > 0 {
+        return "deflate"
+    }
+    return ""
+}
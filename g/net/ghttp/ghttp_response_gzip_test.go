@@ -0,0 +1,33 @@
+// Copyright 2018 gf Author(https://gitee.com/johng/gf). All Rights Reserved.
+//
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT was not distributed with this file,
+// You can obtain one at https://gitee.com/johng/gf.
+
+// go test *.go -v
+
+package ghttp
+
+import (
+    "testing"
+)
+
+// "*"只对没有明确列出的编码生效，明确列出的q=0不会被"*"覆盖
+func TestAcceptEncoding(t *testing.T) {
+    for accept, expect := range map[string]string {
+        ""                         : "",
+        "*"                        : "gzip",
+        "gzip, deflate"            : "gzip",
+        "deflate"                  : "deflate",
+        "gzip;q=0.5, deflate"      : "deflate",
+        "gzip;q=0, *"              : "deflate",
+        "*, gzip;q=0"              : "deflate",
+        "gzip;q=0, *;q=0"          : "",
+        "gzip;q=0, deflate;q=0, *" : "",
+        "*;q=0, gzip"              : "gzip",
+    } {
+        if encoding := acceptEncoding(accept); encoding != expect {
+            t.Errorf(`Accept-Encoding "%s": unexpected encoding "%s", expected "%s"`, accept, encoding, expect)
+        }
+    }
+}
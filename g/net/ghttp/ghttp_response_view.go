@@ -8,6 +8,8 @@
 package ghttp
 
 import (
+    "fmt"
+    "net/http"
     "gitee.com/johng/gf/g/os/gview"
     "gitee.com/johng/gf/g/frame/gins"
 )
@@ -27,6 +29,27 @@ func (r *Response) WriteTpl(tpl string, params map[string]interface{}, funcmap..
     return nil
 }
 
+// 展示模板，模板文件从Server的模板文件目录(ViewPath)中检索，使用Server默认的布局模板(ViewLayout)，
+// 可选参数layout用于指定本次使用的布局模板，为空字符串表示不使用布局模板，布局模板的使用方式详见gview.ParseLayout。
+// 模板解析或者执行失败时返回500状态码及错误信息。
+func (r *Response) WriteTemplate(name string, params gview.Params, layout...string) error {
+    tplLayout := r.Server.config.ViewLayout
+    if len(layout) > 0 {
+        tplLayout = layout[0]
+    }
+    b, err := r.Server.getView().ParseLayout(tplLayout, name, r.buildInVars(params), r.buildInFuncs(nil))
+    if err != nil {
+        r.ClearBuffer()
+        r.WriteStatus(http.StatusInternalServerError, fmt.Sprintf(`template "%s" error: %s`, name, err.Error()))
+        return err
+    }
+    if r.Header().Get("Content-Type") == "" {
+        r.Header().Set("Content-Type", "text/html; charset=utf-8")
+    }
+    r.Write(b)
+    return nil
+}
+
 // 展示模板内容，可以给定模板参数，及临时的自定义模板函数
 func (r *Response) WriteTplContent(content string, params map[string]interface{}, funcmap...map[string]interface{}) error {
     fmap := make(gview.FuncMap)
@@ -101,4 +124,11 @@ func (r *Response) funcPost(key string, def...string) string {
 // 模板内置函数: request
 func (r *Response) funcRequest(key string, def...string) string {
     return r.request.Get(key, def...)
-}
\ No newline at end of file
+}
+// 获取Server的视图对象，设置了模板文件目录(ViewPath)时使用该目录的视图对象，否则使用框架默认的视图对象
+func (s *Server) getView() *gview.View {
+    if s.config.ViewPath != "" {
+        return gview.Get(s.config.ViewPath)
+    }
+    return gins.View()
+}
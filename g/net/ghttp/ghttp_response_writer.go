@@ -8,6 +8,9 @@
 package ghttp
 
 import (
+    "bufio"
+    "errors"
+    "net"
     "net/http"
     "sync"
 )
@@ -15,29 +18,134 @@ import (
 // 自定义的ResponseWriter，用于写入流的控制
 type ResponseWriter struct {
     http.ResponseWriter
-    mu     sync.RWMutex    // 缓冲区互斥锁
-    Status int             // http status
-    buffer []byte          // 缓冲区内容
+    mu          sync.RWMutex   // 缓冲区互斥锁
+    Status      int            // http status
+    buffer      []byte         // 缓冲区内容
+    wroteHeader bool           // 是否已经输出返回头信息
+    encoder     compressWriter // 流式输出时的压缩输出对象(未开启压缩时为nil)
+    raw         bool           // 是否已经通过Response.Raw直接写入了底层的返回对象
 }
 
-// 覆盖父级的WriteHeader方法
+// 直接写入底层返回对象的ResponseWriter(Response.Raw)，写入后框架不再输出返回头信息及缓冲区内容
+type rawResponseWriter struct {
+    http.ResponseWriter
+    writer *ResponseWriter // 所属的ResponseWriter
+}
+
+// 覆盖父级的Write方法，内容写入到缓冲区中
 func (w *ResponseWriter) Write(buffer []byte) (int, error) {
     w.buffer = append(w.buffer, buffer...)
     return len(buffer), nil
 }
 
+// 写入字符串到缓冲区中，实现io.StringWriter接口
+func (w *ResponseWriter) WriteString(s string) (int, error) {
+    return w.Write([]byte(s))
+}
+
 // 覆盖父级的WriteHeader方法
 func (w *ResponseWriter) WriteHeader(code int) {
-    w.Status = code
+    // 已经直接写入了底层的返回对象时，返回头信息已经输出
+    if w.isRaw() {
+        return
+    }
+    w.Status      = code
+    w.wroteHeader = true
     w.ResponseWriter.WriteHeader(code)
 }
 
-// 输出buffer数据到客户端
+// 输出buffer数据到客户端(流式输出开启压缩时同时结束压缩输出)
 func (w *ResponseWriter) OutputBuffer() {
-    if len(w.buffer) > 0 {
-        w.mu.Lock()
-        w.ResponseWriter.Write(w.buffer)
+    w.mu.Lock()
+    defer w.mu.Unlock()
+    w.writeBuffer()
+    if w.encoder != nil {
+        w.encoder.Close()
+        w.encoder = nil
+    }
+}
+
+// 立即输出buffer数据到客户端，用于流式输出，第一次调用时会同时输出返回头信息
+func (w *ResponseWriter) Flush() {
+    w.mu.Lock()
+    defer w.mu.Unlock()
+    w.writeBuffer()
+    if w.encoder != nil {
+        w.encoder.Flush()
+    }
+    w.wroteHeader = true
+    if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+        flusher.Flush()
+    }
+}
+
+// 输出并清空buffer数据，调用方需要加锁
+func (w *ResponseWriter) writeBuffer() {
+    if len(w.buffer) == 0 {
+        return
+    }
+    if w.raw {
         w.buffer = make([]byte, 0)
-        w.mu.Unlock()
+        return
+    }
+    if w.encoder != nil {
+        w.encoder.Write(w.buffer)
+    } else {
+        w.ResponseWriter.Write(w.buffer)
     }
+    w.wroteHeader = true
+    w.buffer      = make([]byte, 0)
+}
+
+// 是否已经直接写入了底层的返回对象
+func (w *ResponseWriter) isRaw() bool {
+    w.mu.RLock()
+    defer w.mu.RUnlock()
+    return w.raw
+}
+
+// 标记已经直接写入了底层的返回对象
+func (w *ResponseWriter) setRaw() {
+    w.mu.Lock()
+    w.raw         = true
+    w.wroteHeader = true
+    w.mu.Unlock()
+}
+
+// 写入返回内容，第一次写入时未调用WriteHeader则使用200状态码
+func (w *rawResponseWriter) Write(buffer []byte) (int, error) {
+    w.writer.setRaw()
+    return w.ResponseWriter.Write(buffer)
+}
+
+// 写入返回头信息，并记录状态码(用于日志等)
+func (w *rawResponseWriter) WriteHeader(code int) {
+    if !w.writer.isRaw() {
+        w.writer.Status = code
+    }
+    w.writer.setRaw()
+    w.ResponseWriter.WriteHeader(code)
+}
+
+// 实现http.Flusher接口
+func (w *rawResponseWriter) Flush() {
+    if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+        w.writer.setRaw()
+        flusher.Flush()
+    }
+}
+
+// 实现http.Hijacker接口，接管连接后框架不再输出任何内容
+func (w *rawResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+    hijacker, ok := w.ResponseWriter.(http.Hijacker)
+    if !ok {
+        return nil, nil, errors.New("the underlying ResponseWriter does not implement http.Hijacker")
+    }
+    w.writer.setRaw()
+    return hijacker.Hijack()
+}
+
+// 获取底层的返回对象，用于http.ResponseController
+func (w *rawResponseWriter) Unwrap() http.ResponseWriter {
+    return w.ResponseWriter
 }
@@ -8,6 +8,7 @@ package ghttp
 
 import (
     "os"
+    "context"
     "sync"
     "errors"
     "strings"
@@ -60,6 +61,8 @@ type Server struct {
     // 基本属性变量
     name             string                         // 服务名称，方便识别
     paths            *gspath.SPath                  // 静态文件检索对象(类似nginx tryfile功能)
+    staticMu         sync.RWMutex                   // 静态目录映射互斥锁
+    staticPaths      []*staticPathItem              // 静态目录映射列表(SetStaticPath，按照URI前缀长度从长到短排列)
     config           ServerConfig                   // 配置对象
     servers          []*gracefulServer              // 底层http.Server列表
     methodsMap       map[string]struct{}            // 所有支持的HTTP Method(初始化时自动填充)
@@ -68,16 +71,26 @@ type Server struct {
     // 服务注册相关
     serveTree        map[string]interface{}         // 所有注册的服务回调函数(路由表，树型结构，哈希表+链表优先级匹配)
     hooksTree        map[string]interface{}         // 所有注册的事件回调函数(路由表，树型结构，哈希表+链表优先级匹配)
+    serveTrie        map[string]*routeTrie          // 所有注册的服务回调函数(路由表，前缀树结构，按照域名区分，ROUTER_MODE_TRIE时使用)
     serveCache       *gcache.Cache                  // 服务注册路由内存缓存
     hooksCache       *gcache.Cache                  // 事件回调路由内存缓存
     routesMap        map[string]registeredRouteItem // 已经注册的路由及对应的注册方法文件地址(用以路由重复注册判断)
+    // 中间件
+    middleware       []Middleware                   // 全局中间件(指定路由注册的中间件存放于hooksTree中)
+    // 跨域请求(CORS)配置
+    corsMu           sync.RWMutex                   // 跨域配置互斥锁
+    corsItems        []*corsItem                    // 跨域配置列表(按照URI前缀长度从长到短排列)
     // 自定义状态码回调
     hsmu             sync.RWMutex                   // status handler互斥锁
     statusHandlerMap map[string]HandlerFunc         // 不同状态码下的注册处理方法(例如404状态时的处理方法)
+    panicHandler     PanicHandler                   // 路由方法产生panic时的处理方法
     // SESSION
-    sessions         *gcache.Cache                  // Session内存缓存
+    sessionStorage   SessionStorage                 // Session存储对象(默认为内存存储)
     // Logger
     logger           *glog.Logger                   // 日志管理对象
+    // 服务上下文，服务关闭时取消，所有请求的上下文都继承于该上下文
+    ctx              context.Context                // 服务上下文
+    cancel           context.CancelFunc             // 服务上下文的取消方法
 }
 
 // 路由对象
@@ -102,6 +115,11 @@ type handlerItem struct {
     faddr    HandlerFunc  // 准确的执行方法内存地址(与以上两个参数二选一)
     finit    HandlerFunc  // 初始化请求回调方法(执行对象注册方式下有效)
     fshut    HandlerFunc  // 完成请求回调方法(执行对象注册方式下有效)
+    mware    []Middleware // 注册的中间件列表(中间件注册方式下有效)
+    mfilter  []MethodFilter // 注册的HTTP Method过滤方法列表(BindMethodFilter注册方式下有效)
+    cfunc    func(c Controller) // 显式绑定的控制器处理方法(BindControllerFunc注册方式下有效)
+    explicit bool         // 是否为显式绑定(BindControllerMap/BindControllerFunc)，显式绑定优先于反射绑定
+    auto     bool         // 是否为自动生成的OPTIONS处理方法(不计入路由支持的HTTP Method列表)
     router   *Router      // 注册时绑定的路由对象
 }
 
@@ -120,6 +138,9 @@ type registeredRouteItem struct {
 // HTTP注册函数
 type HandlerFunc func(r *Request)
 
+// 路由方法(包括中间件)产生panic时的处理方法，参数err为recover获取到的错误信息
+type PanicHandler func(r *Request, err interface{})
+
 // 文件描述符map
 type listenerFdMap map[string]string
 
@@ -180,14 +201,17 @@ func GetServer(name...interface{}) (*Server) {
         statusHandlerMap : make(map[string]HandlerFunc),
         serveTree        : make(map[string]interface{}),
         hooksTree        : make(map[string]interface{}),
+        serveTrie        : make(map[string]*routeTrie),
         serveCache       : gcache.New(),
         hooksCache       : gcache.New(),
         routesMap        : make(map[string]registeredRouteItem),
-        sessions         : gcache.New(),
+        middleware       : make([]Middleware, 0),
+        sessionStorage   : NewSessionStorageMemory(),
         servedCount      : gtype.NewInt(),
         closeQueue       : gqueue.New(),
         logger           : glog.New(),
     }
+    s.ctx, s.cancel = context.WithCancel(context.Background())
     // 日志的标准输出默认关闭，但是错误信息会特殊处理
     s.logger.SetStdPrint(false)
     for _, v := range strings.Split(gHTTP_METHODS, ",") {
@@ -239,13 +263,6 @@ func (s *Server) Start() error {
             })
         }
     }
-    // gzip压缩文件类型
-    //if s.config.GzipContentTypes != nil {
-    //    for _, v := range s.config.GzipContentTypes {
-    //        s.gzipMimesMap[v] = struct{}{}
-    //    }
-    //}
-
     // 启动http server
     reloaded := false
     fdMapStr := genv.Get(gADMIN_ACTION_RELOAD_ENVKEY)
@@ -379,7 +396,7 @@ func Wait() {
 // 开启底层Web Server执行
 func (s *Server) startServer(fdMap listenerFdMap) {
     var httpsEnabled bool
-    if len(s.config.HTTPSCertPath) > 0 && len(s.config.HTTPSKeyPath) > 0 {
+    if s.tlsEnabled() {
         // ================
         // HTTPS
         // ================
@@ -418,6 +435,7 @@ func (s *Server) startServer(fdMap listenerFdMap) {
                 s.servers = append(s.servers, s.newGracefulServer(addr))
             }
             s.servers[len(s.servers) - 1].isHttps = true
+            s.servers[len(s.servers) - 1].httpServer.TLSConfig = s.getTLSConfig()
         }
     }
     // ================
@@ -453,6 +471,35 @@ func (s *Server) startServer(fdMap listenerFdMap) {
             s.servers = append(s.servers, s.newGracefulServer(addr))
         }
     }
+    // ================
+    // HTTP跳转HTTPS
+    // ================
+    if httpsEnabled && len(s.config.HTTPRedirectAddr) > 0 {
+        if v, ok := fdMap["redirect"]; ok && len(v) > 0 {
+            array = strings.Split(v, ",")
+        } else {
+            array = strings.Split(s.config.HTTPRedirectAddr, ",")
+        }
+        handler := newHTTPSRedirectHandler(strings.Split(s.config.HTTPSAddr, ",")[0])
+        for _, v := range array {
+            if len(v) == 0 {
+                continue
+            }
+            fd    := 0
+            addr  := v
+            array := strings.Split(v, "#")
+            if len(array) > 1 {
+                addr = array[0]
+                if runtime.GOOS != "windows" {
+                    fd = gconv.Int(array[1])
+                }
+            }
+            server := s.newGracefulServer(addr, fd)
+            server.isRedirect         = true
+            server.httpServer.Handler = handler
+            s.servers = append(s.servers, server)
+        }
+    }
     // 开始执行异步监听
     for _, v := range s.servers {
         go func(server *gracefulServer) {
@@ -494,14 +541,17 @@ func (s *Server) Status() int {
 // 获取当前监听的文件描述符信息，构造成map返回
 func (s *Server) getListenerFdMap() map[string]string {
     m := map[string]string {
-        "https" : "",
-        "http"  : "",
+        "https"    : "",
+        "http"     : "",
+        "redirect" : "",
     }
     // s.servers是从HTTPS到HTTP优先级遍历，解析的时候也应当按照这个顺序读取fd
     for _, v := range s.servers {
         str := v.addr + "#" + gconv.String(v.Fd()) + ","
         if v.isHttps {
             m["https"] += str
+        } else if v.isRedirect {
+            m["redirect"] += str
         } else {
             m["http"]  += str
         }
@@ -513,6 +563,9 @@ func (s *Server) getListenerFdMap() map[string]string {
     if len(m["http"]) > 0 {
         m["http"] = m["http"][0 : len(m["http"]) - 1]
     }
+    if len(m["redirect"]) > 0 {
+        m["redirect"] = m["redirect"][0 : len(m["redirect"]) - 1]
+    }
 
     return m
 }
@@ -8,6 +8,7 @@
 package ghttp
 
 import (
+    "context"
     "strings"
     "gitee.com/johng/gf/g/os/gview"
     "gitee.com/johng/gf/g/os/gproc"
@@ -121,8 +122,16 @@ func (s *Server) Restart(newExeFilePath...string) error {
     return restartWebServers("", newExeFilePath...)
 }
 
-// 关闭Web Server
-func (s *Server) Shutdown() error {
+// 关闭Web Server。
+// 不传递ctx参数时，关闭进程中所有的Web Server(异步执行，处理中的请求会被强制中断)；
+// 传递ctx参数时，优雅关闭当前的Web Server：不再接受新的连接，并阻塞等待处理中的请求完成后返回，
+// 如果在等待期间ctx超时或者被取消，那么通过请求的上下文(Request.Context())通知处理中的请求取消执行，
+// 并强制关闭剩余的连接，返回ctx的错误信息。
+// 常用于自定义的信号处理中实现平滑下线，例如：收到SIGTERM信号后调用Shutdown(ctx)，也可以通过SetGracefulTimeout配置信号处理时的优雅关闭。
+func (s *Server) Shutdown(ctx...context.Context) error {
+    if len(ctx) > 0 && ctx[0] != nil {
+        return s.shutdownWithContext(ctx[0])
+    }
     serverActionLocker.Lock()
     defer serverActionLocker.Unlock()
     if err := s.checkActionStatus(); err != nil {
@@ -135,6 +144,36 @@ func (s *Server) Shutdown() error {
     return nil
 }
 
+// 优雅关闭当前的Web Server
+func (s *Server) shutdownWithContext(ctx context.Context) error {
+    var (
+        wg     sync.WaitGroup
+        mu     sync.Mutex
+        result error
+    )
+    for _, v := range s.servers {
+        wg.Add(1)
+        go func(server *gracefulServer) {
+            defer wg.Done()
+            if err := server.shutdownWithContext(ctx); err != nil {
+                mu.Lock()
+                result = err
+                mu.Unlock()
+            }
+        }(v)
+    }
+    wg.Wait()
+    // 通知所有处理中的请求取消执行
+    s.cancel()
+    if result != nil {
+        for _, v := range s.servers {
+            v.close()
+        }
+        glog.Errorfln("%d: server [%s] graceful shutdown error: %v", gproc.Pid(), s.name, result)
+    }
+    return result
+}
+
 // 检测当前操作的频繁度
 func (s *Server) checkActionFrequence() error {
     interval := gtime.Millisecond() - serverActionLastTime.Val()
@@ -276,8 +315,8 @@ func shutdownWebServers(signal string) {
     serverProcessStatus.Set(gADMIN_ACTION_SHUTINGDOWN)
     if len(signal) > 0 {
         glog.Printfln("%d: server shutting down by signal: %s", gproc.Pid(), signal)
-        // 在终端信号下，立即执行关闭操作
-        forcedlyCloseWebServers()
+        // 在终端信号下，立即执行关闭操作(配置了优雅关闭超时时间的Web Server会等待处理中的请求完成)
+        closeWebServersBySignal()
         doneChan <- struct{}{}
     } else {
         glog.Printfln("%d: server shutting down by web admin", gproc.Pid())
@@ -301,6 +340,33 @@ func gracefulShutdownWebServers() {
     })
 }
 
+// 终端信号下关闭进程所有端口的Web Server服务，配置了GracefulTimeout的Web Server执行优雅关闭，其他的强制关闭
+func closeWebServersBySignal() {
+    servers := make([]*Server, 0)
+    serverMapping.RLockFunc(func(m map[string]interface{}) {
+        for _, v := range m {
+            servers = append(servers, v.(*Server))
+        }
+    })
+    var wg sync.WaitGroup
+    for _, s := range servers {
+        wg.Add(1)
+        go func(s *Server) {
+            defer wg.Done()
+            if s.config.GracefulTimeout > 0 {
+                ctx, cancel := context.WithTimeout(context.Background(), s.config.GracefulTimeout)
+                defer cancel()
+                s.shutdownWithContext(ctx)
+            } else {
+                for _, v := range s.servers {
+                    v.close()
+                }
+            }
+        }(s)
+    }
+    wg.Wait()
+}
+
 // 强制关闭进程所有端口的Web Server服务
 // 注意，只是关闭Web Server服务，并不是退出进程
 func forcedlyCloseWebServers() {
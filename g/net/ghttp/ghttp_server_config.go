@@ -8,7 +8,11 @@
 package ghttp
 
 import (
+    "errors"
+    "fmt"
     "time"
+    "compress/gzip"
+    "crypto/tls"
     "net/http"
     "strconv"
     "strings"
@@ -23,10 +27,13 @@ const (
     NAME_TO_URI_TYPE_FULLNAME          = 1                // 不处理名称，以原有名称构建成URI
     NAME_TO_URI_TYPE_ALLLOWER          = 2                // 仅转为小写，单词间不使用连接符号
     NAME_TO_URI_TYPE_CAMEL             = 3                // 采用驼峰命名方式
+    ROUTER_MODE_TREE                   = 0                // 路由检索方式：哈希表+链表优先级匹配(默认)
+    ROUTER_MODE_TRIE                   = 1                // 路由检索方式：前缀树匹配，检索耗时与路由数量无关，适用于路由数量较多的场景
     gDEFAULT_COOKIE_PATH               = "/"              // 默认path
     gDEFAULT_COOKIE_MAX_AGE            = 86400*365        // 默认cookie有效期(一年)
     gDEFAULT_SESSION_MAX_AGE           = 600              // 默认session有效期(600秒)
     gDEFAULT_SESSION_ID_NAME           = "gfsessionid"    // 默认存放Cookie中的SessionId名称
+    gDEFAULT_UPLOAD_MAX_MEMORY         = 32*1024*1024     // 默认上传文件解析时允许使用的最大内存(32MB)
     gCHANGE_CONFIG_WHILE_RUNNING_ERROR = "cannot be changed while running"
 )
 
@@ -40,11 +47,17 @@ type ServerConfig struct {
     HTTPSAddr        string        // HTTPS服务监听地址(支持多个地址，使用","号分隔)
     HTTPSCertPath    string        // HTTPS证书文件路径
     HTTPSKeyPath     string        // HTTPS签名文件路径
+    TLSConfig        *tls.Config   // HTTPS的TLS配置，为nil时使用默认的TLS配置(见SetTLSConfig)
+    HTTPRedirectAddr string        // HTTP跳转HTTPS的监听地址(支持多个地址，使用","号分隔)，该地址的所有请求均301跳转到HTTPS服务，为空表示不开启
     Handler          http.Handler  // 默认的处理函数
     ReadTimeout      time.Duration // 读取超时
     WriteTimeout     time.Duration // 写入超时
     IdleTimeout      time.Duration // 等待超时
     MaxHeaderBytes   int           // 最大的header长度
+    MaxRequestBody   int64         // 请求内容的最大长度(byte)，超出时返回413状态码，零值表示不限制
+    UploadMaxMemory  int64         // 上传文件解析时允许使用的最大内存(byte)，超出部分保存到临时文件中
+    UploadMaxSize    int64         // 单个上传文件的最大长度(byte)，零值表示不限制
+    GracefulTimeout  time.Duration // 收到终端信号(例如SIGTERM)时优雅关闭等待处理中请求完成的最长时间，零值表示立即强制关闭
 
     // 静态文件配置
     IndexFiles       []string      // 默认访问的文件列表
@@ -52,6 +65,10 @@ type ServerConfig struct {
     ServerAgent      string        // server agent
     ServerRoot       string        // 服务器服务的本地目录根路径
 
+    // 模板配置
+    ViewPath         string       // 模板文件目录(Response.WriteTemplate)，为空时使用框架默认的视图对象(gins.View)的目录
+    ViewLayout       string       // 默认的布局模板文件(Response.WriteTemplate)，为空表示不使用布局模板
+
     // COOKIE
     CookieMaxAge     int          // Cookie有效期
     CookiePath       string       // Cookie有效Path(注意同时也会影响SessionID)
@@ -72,11 +89,17 @@ type ServerConfig struct {
     LogHandler       LogHandler   // 自定义日志处理回调方法
     ErrorLogEnabled  bool         // 是否开启error log
     AccessLogEnabled bool         // 是否开启access log
+    AccessLogFormat  string       // access log的格式(ACCESS_LOG_FORMAT_*)，为空时使用默认格式
+    AccessLogSkip    []string     // 不记录access log的请求路径(例如健康检查)，以"*"结尾时表示前缀匹配，例如："/health", "/static/*"
 
     // 其他设置
     NameToUriType    int          // 服务注册时对象和方法名称转换为URI时的规则
+    GzipEnabled      bool         // 是否开启返回内容压缩(根据客户端的Accept-Encoding使用gzip或者deflate压缩)
     GzipContentTypes []string     // 允许进行gzip压缩的文件类型
+    GzipMinLength    int          // 进行压缩的最小内容长度(byte)，小于该长度的内容不压缩
+    GzipLevel        int          // 压缩级别(1-9)，-1表示默认的压缩级别
     DumpRouteMap     bool         // 是否在程序启动时默认打印路由表信息
+    RouterMode       int          // 服务路由的检索方式(ROUTER_MODE_*)，默认为ROUTER_MODE_TREE
 }
 
 // 默认HTTP Server
@@ -88,6 +111,7 @@ var defaultServerConfig = ServerConfig {
     WriteTimeout     : 60 * time.Second,
     IdleTimeout      : 60 * time.Second,
     MaxHeaderBytes   : 1024,
+    UploadMaxMemory  : gDEFAULT_UPLOAD_MAX_MEMORY,
     IndexFiles       : []string{"index.html", "index.htm"},
     IndexFolder      : false,
     ServerAgent      : "gf",
@@ -103,6 +127,8 @@ var defaultServerConfig = ServerConfig {
     ErrorLogEnabled  : true,
 
     GzipContentTypes : defaultGzipContentTypes,
+    GzipMinLength    : gDEFAULT_GZIP_MIN_LENGTH,
+    GzipLevel        : gzip.DefaultCompression,
 
     DumpRouteMap     : true,
 }
@@ -218,6 +244,39 @@ func (s *Server)SetMaxHeaderBytes(b int) {
     
 }
 
+// 设置http server参数 - MaxRequestBody，请求内容的最大长度(byte)，超出时返回413状态码，
+// 可以通过Request.SetMaxRequestBody或者MaxRequestBody中间件对指定的路由进行覆盖
+func (s *Server)SetMaxRequestBody(size int64) {
+    if s.Status() == SERVER_STATUS_RUNNING {
+        glog.Error(gCHANGE_CONFIG_WHILE_RUNNING_ERROR)
+    }
+    s.config.MaxRequestBody = size
+}
+
+// 设置http server参数 - UploadMaxMemory，上传文件解析时允许使用的最大内存(byte)
+func (s *Server)SetUploadMaxMemory(size int64) {
+    if s.Status() == SERVER_STATUS_RUNNING {
+        glog.Error(gCHANGE_CONFIG_WHILE_RUNNING_ERROR)
+    }
+    s.config.UploadMaxMemory = size
+}
+
+// 设置http server参数 - UploadMaxSize，单个上传文件的最大长度(byte)，零值表示不限制
+func (s *Server)SetUploadMaxSize(size int64) {
+    if s.Status() == SERVER_STATUS_RUNNING {
+        glog.Error(gCHANGE_CONFIG_WHILE_RUNNING_ERROR)
+    }
+    s.config.UploadMaxSize = size
+}
+
+// 设置http server参数 - GracefulTimeout，收到终端信号时优雅关闭等待处理中请求完成的最长时间
+func (s *Server)SetGracefulTimeout(t time.Duration) {
+    if s.Status() == SERVER_STATUS_RUNNING {
+        glog.Error(gCHANGE_CONFIG_WHILE_RUNNING_ERROR)
+    }
+    s.config.GracefulTimeout = t
+}
+
 // 设置http server参数 - IndexFiles，默认展示文件，如：index.html, index.htm
 func (s *Server)SetIndexFiles(index []string) {
     if s.Status() == SERVER_STATUS_RUNNING {
@@ -285,6 +344,30 @@ func (s *Server) SetGzipContentTypes(types []string) {
     s.config.GzipContentTypes = types
 }
 
+// 是否开启返回内容压缩，默认关闭
+func (s *Server) SetGzipEnabled(enabled bool) {
+    if s.Status() == SERVER_STATUS_RUNNING {
+        glog.Error(gCHANGE_CONFIG_WHILE_RUNNING_ERROR)
+    }
+    s.config.GzipEnabled = enabled
+}
+
+// 设置进行压缩的最小内容长度(byte)
+func (s *Server) SetGzipMinLength(length int) {
+    if s.Status() == SERVER_STATUS_RUNNING {
+        glog.Error(gCHANGE_CONFIG_WHILE_RUNNING_ERROR)
+    }
+    s.config.GzipMinLength = length
+}
+
+// 设置压缩级别(1-9)，-1表示默认的压缩级别
+func (s *Server) SetGzipLevel(level int) {
+    if s.Status() == SERVER_STATUS_RUNNING {
+        glog.Error(gCHANGE_CONFIG_WHILE_RUNNING_ERROR)
+    }
+    s.config.GzipLevel = level
+}
+
 // 服务注册时对象和方法名称转换为URI时的规则
 func (s *Server) SetNameToUriType(t int) {
     if s.Status() == SERVER_STATUS_RUNNING {
@@ -301,6 +384,36 @@ func (s *Server) SetDumpRouteMap(enabled bool) {
     s.config.DumpRouteMap = enabled
 }
 
+// 设置服务路由的检索方式(ROUTER_MODE_TREE/ROUTER_MODE_TRIE)，两种方式的匹配结果及优先级规则一致，
+// 路由注册时同时维护两种路由表，因此可以在注册路由之前或者之后设置。
+// 前缀树支持精准匹配、:name、{field}以及位于末尾层级的*any规则，注册了其他规则(例如/user/*any/edit)的域名仍然使用哈希表+链表检索。
+func (s *Server) SetRouterMode(mode int) {
+    if s.Status() == SERVER_STATUS_RUNNING {
+        glog.Error(gCHANGE_CONFIG_WHILE_RUNNING_ERROR)
+    }
+    s.config.RouterMode = mode
+}
+
+// 设置模板文件目录(Response.WriteTemplate)
+func (s *Server) SetViewPath(path string) error {
+    if s.Status() == SERVER_STATUS_RUNNING {
+        glog.Error(gCHANGE_CONFIG_WHILE_RUNNING_ERROR)
+    }
+    if !gfile.IsDir(path) {
+        return errors.New(fmt.Sprintf(`invalid view path "%s": directory does not exist`, path))
+    }
+    s.config.ViewPath = path
+    return nil
+}
+
+// 设置默认的布局模板文件(Response.WriteTemplate)，模板文件相对于模板文件目录
+func (s *Server) SetViewLayout(layout string) {
+    if s.Status() == SERVER_STATUS_RUNNING {
+        glog.Error(gCHANGE_CONFIG_WHILE_RUNNING_ERROR)
+    }
+    s.config.ViewLayout = layout
+}
+
 // 添加静态文件搜索目录，必须给定目录的绝对路径
 func (s *Server) AddSearchPath(path string) error {
     if rp, err := s.paths.Add(path); err != nil {
@@ -31,6 +31,22 @@ func (s *Server)SetAccessLogEnabled(enabled bool) {
     s.config.AccessLogEnabled = enabled
 }
 
+// 设置access log的格式(ACCESS_LOG_FORMAT_*)
+func (s *Server) SetAccessLogFormat(format string) {
+    if s.Status() == SERVER_STATUS_RUNNING {
+        glog.Error(gCHANGE_CONFIG_WHILE_RUNNING_ERROR)
+    }
+    s.config.AccessLogFormat = format
+}
+
+// 设置不记录access log的请求路径(例如健康检查)，以"*"结尾时表示前缀匹配
+func (s *Server) SetAccessLogSkip(paths...string) {
+    if s.Status() == SERVER_STATUS_RUNNING {
+        glog.Error(gCHANGE_CONFIG_WHILE_RUNNING_ERROR)
+    }
+    s.config.AccessLogSkip = paths
+}
+
 // 设置是否开启error log日志功能
 func (s *Server)SetErrorLogEnabled(enabled bool) {
     if s.Status() == SERVER_STATUS_RUNNING {
@@ -62,6 +78,11 @@ func (s *Server)IsAccessLogEnabled() bool {
     return s.config.AccessLogEnabled
 }
 
+// 获取access log的格式
+func (s *Server) GetAccessLogFormat() string {
+    return s.config.AccessLogFormat
+}
+
 // error log日志功能是否开启
 func (s *Server)IsErrorLogEnabled() bool {
     return s.config.ErrorLogEnabled
@@ -34,3 +34,16 @@ func (s *Server) GetSessionMaxAge() int {
 func (s *Server) GetSessionIdName() string {
     return s.config.SessionIdName
 }
+
+// 设置Session存储对象，用于实现自定义的Session存储(例如Redis、文件)，默认使用内存存储
+func (s *Server) SetSessionStorage(storage SessionStorage) {
+    if s.Status() == SERVER_STATUS_RUNNING {
+        glog.Error(gCHANGE_CONFIG_WHILE_RUNNING_ERROR)
+    }
+    s.sessionStorage = storage
+}
+
+// 获取Session存储对象
+func (s *Server) GetSessionStorage() SessionStorage {
+    return s.sessionStorage
+}
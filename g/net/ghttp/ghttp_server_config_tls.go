@@ -0,0 +1,106 @@
+// Copyright 2018 gf Author(https://gitee.com/johng/gf). All Rights Reserved.
+//
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT was not distributed with this file,
+// You can obtain one at https://gitee.com/johng/gf.
+// HTTPS/TLS配置管理.
+
+package ghttp
+
+import (
+    "crypto/tls"
+    "net"
+    "net/http"
+    "strings"
+    "gitee.com/johng/gf/g/os/glog"
+)
+
+// 默认使用的加密套件(仅使用支持前向安全的ECDHE密钥交换以及AEAD加密算法，TLS1.3的加密套件不可配置)
+var defaultTLSCipherSuites = []uint16 {
+    tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+    tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+    tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+    tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+    tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305,
+    tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305,
+}
+
+// 设置HTTPS证书文件及签名文件，同EnableHTTPS
+func (s *Server)SetTLS(certFile, keyFile string) {
+    s.EnableHTTPS(certFile, keyFile)
+}
+
+// 设置HTTPS的TLS配置，用于覆盖默认的TLS配置(最低版本TLS1.2，仅使用安全的加密套件，见DefaultTLSConfig)。
+// 当config中已包含证书(Certificates/GetCertificate)时，不需要再通过SetTLS/EnableHTTPS设置证书文件即可开启HTTPS；
+// 同时设置了证书文件时，证书文件将覆盖config中的Certificates。
+// config的MinVersion为零值时将被设置为TLS1.2，如需支持更低的版本请显式设置MinVersion。
+func (s *Server)SetTLSConfig(config *tls.Config) {
+    if s.Status() == SERVER_STATUS_RUNNING {
+        glog.Error(gCHANGE_CONFIG_WHILE_RUNNING_ERROR)
+    }
+    s.config.TLSConfig = config
+}
+
+// 开启HTTP跳转HTTPS，在addr(默认为":80"，支持多个地址，使用","号分隔)上监听HTTP请求，并将所有请求301跳转到HTTPS服务的相同地址。
+// 仅当HTTPS开启时生效，注意addr不能与通过SetAddr/SetPort设置的HTTP服务地址相同。
+func (s *Server)SetHTTPSRedirect(addr...string) {
+    if s.Status() == SERVER_STATUS_RUNNING {
+        glog.Error(gCHANGE_CONFIG_WHILE_RUNNING_ERROR)
+    }
+    if len(addr) > 0 {
+        s.config.HTTPRedirectAddr = strings.Join(addr, ",")
+    } else {
+        s.config.HTTPRedirectAddr = gDEFAULT_HTTP_ADDR
+    }
+}
+
+// 获取默认的TLS配置：最低版本TLS1.2，优先使用服务端的加密套件顺序，仅使用支持前向安全的AEAD加密套件
+func DefaultTLSConfig() *tls.Config {
+    return &tls.Config {
+        MinVersion               : tls.VersionTLS12,
+        PreferServerCipherSuites : true,
+        CipherSuites             : defaultTLSCipherSuites,
+        CurvePreferences         : []tls.CurveID{ tls.X25519, tls.CurveP256 },
+    }
+}
+
+// 是否开启HTTPS(设置了证书文件，或者TLS配置中包含证书)
+func (s *Server) tlsEnabled() bool {
+    if len(s.config.HTTPSCertPath) > 0 && len(s.config.HTTPSKeyPath) > 0 {
+        return true
+    }
+    if c := s.config.TLSConfig; c != nil && (len(c.Certificates) > 0 || c.GetCertificate != nil) {
+        return true
+    }
+    return false
+}
+
+// 获取HTTPS服务使用的TLS配置(复制后返回，不会修改用户设置的配置对象)
+func (s *Server) getTLSConfig() *tls.Config {
+    if s.config.TLSConfig == nil {
+        return DefaultTLSConfig()
+    }
+    config := s.config.TLSConfig.Clone()
+    if config.MinVersion == 0 {
+        config.MinVersion = tls.VersionTLS12
+    }
+    return config
+}
+
+// 生成HTTP跳转HTTPS的处理函数，httpsAddr为HTTPS服务的监听地址，用于确定跳转的端口
+func newHTTPSRedirectHandler(httpsAddr string) http.Handler {
+    port := ""
+    if _, p, err := net.SplitHostPort(httpsAddr); err == nil && p != "443" {
+        port = p
+    }
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        host := r.Host
+        if h, _, err := net.SplitHostPort(host); err == nil {
+            host = h
+        }
+        if len(port) > 0 {
+            host = net.JoinHostPort(host, port)
+        }
+        http.Redirect(w, r, "https://" + host + r.URL.RequestURI(), http.StatusMovedPermanently)
+    })
+}
@@ -31,10 +31,22 @@ type Cookie struct {
 // cookie项
 type CookieItem struct {
     value    string
-    domain   string // 有效域名
-    path     string // 有效路径
-    expire   int    // 过期时间
+    domain   string        // 有效域名
+    path     string        // 有效路径
+    expire   int           // 过期时间
     httpOnly bool
+    secure   bool          // 是否只在HTTPS请求中发送
+    sameSite http.SameSite // 跨站请求时是否发送
+}
+
+// 设置cookie时的可选参数，零值表示使用默认配置
+type CookieOptions struct {
+    Path     string        // 有效路径，为空时使用默认配置(CookiePath)
+    Domain   string        // 有效域名，为空时使用默认配置(CookieDomain，未配置时为当前请求的域名)
+    MaxAge   int           // 有效期(秒)，0表示使用默认配置(CookieMaxAge)，小于0表示删除该cookie
+    Secure   bool          // 是否只在HTTPS请求中发送
+    HttpOnly bool          // 是否禁止客户端脚本访问
+    SameSite http.SameSite // 跨站请求时是否发送，例如：http.SameSiteLaxMode
 }
 
 // 获取或者创建一个cookie对象，与传入的请求对应
@@ -63,7 +75,11 @@ func GetCookie(r *Request) *Cookie {
 func (c *Cookie) init() {
     for _, v := range c.request.Cookies() {
         c.data[v.Name] = CookieItem {
-            v.Value, v.Domain, v.Path, v.Expires.Second(), v.HttpOnly,
+            value    : v.Value,
+            domain   : v.Domain,
+            path     : v.Path,
+            expire   : v.Expires.Second(),
+            httpOnly : v.HttpOnly,
         }
     }
 }
@@ -89,9 +105,13 @@ func (c *Cookie) SessionId() string {
     return v
 }
 
-// 设置SessionId
+// 设置SessionId，SessionId的cookie禁止客户端脚本访问，并且在HTTPS请求中只通过HTTPS发送
 func (c *Cookie) SetSessionId(id string)  {
-    c.Set(c.server.GetSessionIdName(), id)
+    c.SetWithOptions(c.server.GetSessionIdName(), id, CookieOptions {
+        Secure   : c.request.TLS != nil,
+        HttpOnly : true,
+        SameSite : http.SameSiteLaxMode,
+    })
 }
 
 // 设置cookie，使用默认参数
@@ -107,7 +127,37 @@ func (c *Cookie) SetCookie(key, value, domain, path string, maxAge int, httpOnly
         isHttpOnly = httpOnly[0]
     }
     c.data[key] = CookieItem {
-        value, domain, path, int(gtime.Second()) + maxAge, isHttpOnly,
+        value    : value,
+        domain   : domain,
+        path     : path,
+        expire   : int(gtime.Second()) + maxAge,
+        httpOnly : isHttpOnly,
+    }
+    c.mu.Unlock()
+}
+
+// 设置cookie，使用给定的可选参数，未设置的参数使用默认配置
+func (c *Cookie) SetWithOptions(key, value string, options CookieOptions) {
+    if options.Path == "" {
+        options.Path = c.path
+    }
+    if options.Domain == "" {
+        options.Domain = c.domain
+    }
+    if options.MaxAge == 0 {
+        options.MaxAge = c.server.GetCookieMaxAge()
+    } else if options.MaxAge < 0 {
+        options.MaxAge = -86400
+    }
+    c.mu.Lock()
+    c.data[key] = CookieItem {
+        value    : value,
+        domain   : options.Domain,
+        path     : options.Path,
+        expire   : int(gtime.Second()) + options.MaxAge,
+        httpOnly : options.HttpOnly,
+        secure   : options.Secure,
+        sameSite : options.SameSite,
     }
     c.mu.Unlock()
 }
@@ -150,6 +200,8 @@ func (c *Cookie) Output() {
                 Path     : v.path,
                 Expires  : time.Unix(int64(v.expire), 0),
                 HttpOnly : v.httpOnly,
+                Secure   : v.secure,
+                SameSite : v.sameSite,
             },
         )
     }
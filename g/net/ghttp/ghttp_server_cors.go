@@ -0,0 +1,164 @@
+// Copyright 2018 gf Author(https://gitee.com/johng/gf). All Rights Reserved.
+//
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT was not distributed with this file,
+// You can obtain one at https://gitee.com/johng/gf.
+// 跨域请求(CORS)处理.
+
+package ghttp
+
+import (
+    "net/http"
+    "path"
+    "sort"
+    "strconv"
+    "strings"
+)
+
+// 跨域请求(CORS)配置
+type CORSOptions struct {
+    AllowOrigins     []string // 允许跨域访问的来源列表，例如：https://example.com，支持"*"通配符(例如：https://*.example.com)，为空或者包含"*"时允许所有来源
+    AllowMethods     []string // 允许跨域访问的HTTP Method列表，为空时允许所有的HTTP Method；预检请求返回的是该列表与路由实际注册的HTTP Method的交集
+    AllowHeaders     []string // 允许跨域请求携带的头信息列表，为空时允许预检请求中请求的所有头信息
+    ExposeHeaders    []string // 允许客户端读取的返回头信息列表
+    AllowCredentials bool     // 是否允许跨域请求携带Cookie等凭证信息，开启时Access-Control-Allow-Origin返回请求的来源而不是"*"
+    MaxAge           int      // 预检请求结果的缓存时间(秒)，零值表示不返回该头信息
+}
+
+// 跨域配置项，prefix为生效的URI前缀(空字符串表示对整个Server生效)
+type corsItem struct {
+    prefix  string
+    options CORSOptions
+}
+
+// 设置Server的跨域请求配置，对所有的请求生效(分组SetCORS的配置优先)，
+// 跨域请求的返回中会自动增加Access-Control-*头信息，预检请求(OPTIONS)会被自动响应，不会执行路由方法
+func (s *Server) SetCORS(options CORSOptions) {
+    s.setCORS("", options)
+}
+
+// 设置分组的跨域请求配置，对分组URI前缀下的请求生效，覆盖Server及上级分组的配置
+func (g *RouterGroup) SetCORS(options CORSOptions) {
+    g.server.setCORS(g.prefix, options)
+}
+
+// 设置指定URI前缀的跨域请求配置，按照前缀长度从长到短排列，便于检索时优先匹配最长前缀
+func (s *Server) setCORS(prefix string, options CORSOptions) {
+    s.corsMu.Lock()
+    defer s.corsMu.Unlock()
+    for _, item := range s.corsItems {
+        if item.prefix == prefix {
+            item.options = options
+            return
+        }
+    }
+    s.corsItems = append(s.corsItems, &corsItem{prefix, options})
+    sort.SliceStable(s.corsItems, func(i, j int) bool {
+        return len(s.corsItems[i].prefix) > len(s.corsItems[j].prefix)
+    })
+}
+
+// 获取请求路径对应的跨域请求配置，没有配置时返回nil
+func (s *Server) getCORSOptions(uri string) *CORSOptions {
+    s.corsMu.RLock()
+    defer s.corsMu.RUnlock()
+    for _, item := range s.corsItems {
+        if item.prefix == "" || uri == item.prefix || strings.HasPrefix(uri, item.prefix + "/") {
+            options := item.options
+            return &options
+        }
+    }
+    return nil
+}
+
+// 跨域请求处理，当请求为预检请求并且已经被响应时返回true(后续不再执行路由方法)
+func (s *Server) handleCORS(r *Request) bool {
+    origin := r.Header.Get("Origin")
+    if origin == "" {
+        return false
+    }
+    options := s.getCORSOptions(r.URL.Path)
+    if options == nil {
+        return false
+    }
+    preflight := r.Method == "OPTIONS" && r.Header.Get("Access-Control-Request-Method") != ""
+    if !options.allowOrigin(origin) {
+        if preflight {
+            r.Response.WriteStatus(http.StatusForbidden)
+            return true
+        }
+        return false
+    }
+    header := r.Response.Header()
+    header.Add("Vary", "Origin")
+    if options.AllowCredentials || !options.allowAllOrigins() {
+        header.Set("Access-Control-Allow-Origin", origin)
+    } else {
+        header.Set("Access-Control-Allow-Origin", "*")
+    }
+    if options.AllowCredentials {
+        header.Set("Access-Control-Allow-Credentials", "true")
+    }
+    if !preflight {
+        if len(options.ExposeHeaders) > 0 {
+            header.Set("Access-Control-Expose-Headers", strings.Join(options.ExposeHeaders, ","))
+        }
+        return false
+    }
+    // 预检请求：返回允许的HTTP Method(路由实际注册的HTTP Method与配置的交集)及头信息
+    methods := s.searchAllowedMethods(r.URL.Path, r.GetHost())
+    if len(options.AllowMethods) > 0 {
+        allowed := make([]string, 0)
+        for _, method := range methods {
+            for _, v := range options.AllowMethods {
+                if strings.EqualFold(v, method) {
+                    allowed = append(allowed, method)
+                    break
+                }
+            }
+        }
+        methods = allowed
+    }
+    header.Set("Access-Control-Allow-Methods", strings.Join(methods, ","))
+    if len(options.AllowHeaders) > 0 {
+        header.Set("Access-Control-Allow-Headers", strings.Join(options.AllowHeaders, ","))
+    } else if v := r.Header.Get("Access-Control-Request-Headers"); v != "" {
+        header.Set("Access-Control-Allow-Headers", v)
+    }
+    if options.MaxAge > 0 {
+        header.Set("Access-Control-Max-Age", strconv.Itoa(options.MaxAge))
+    }
+    r.Response.WriteHeader(http.StatusNoContent)
+    return true
+}
+
+// 是否允许所有来源的跨域请求
+func (o *CORSOptions) allowAllOrigins() bool {
+    if len(o.AllowOrigins) == 0 {
+        return true
+    }
+    for _, v := range o.AllowOrigins {
+        if v == "*" {
+            return true
+        }
+    }
+    return false
+}
+
+// 判断给定的来源是否允许跨域访问
+func (o *CORSOptions) allowOrigin(origin string) bool {
+    if o.allowAllOrigins() {
+        return true
+    }
+    for _, v := range o.AllowOrigins {
+        if strings.EqualFold(v, origin) {
+            return true
+        }
+        if strings.Contains(v, "*") {
+            if match, err := path.Match(strings.ToLower(v), strings.ToLower(origin)); err == nil && match {
+                return true
+            }
+        }
+    }
+    return false
+}
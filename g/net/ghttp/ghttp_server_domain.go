@@ -114,6 +114,26 @@ func (d *Domain) BindControllerRest(pattern string, c Controller) error {
     return nil
 }
 
+// 通过方法映射显式绑定控制器
+func (d *Domain) BindControllerMap(pattern string, c Controller, methodMap map[string]string) error {
+    for domain, _ := range d.m {
+        if err := d.s.BindControllerMap(pattern + "@" + domain, c, methodMap); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+// 显式绑定控制器处理函数
+func (d *Domain) BindControllerFunc(method, pattern string, c Controller, handler func(c Controller)) error {
+    for domain, _ := range d.m {
+        if err := d.s.BindControllerFunc(method, pattern + "@" + domain, c, handler); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
 // 绑定指定的hook回调函数, hook参数的值由ghttp server设定，参数不区分大小写
 // 目前hook支持：Init/Shut
 func (d *Domain)BindHookHandler(pattern string, hook string, handler HandlerFunc) error {
@@ -135,6 +155,16 @@ func (d *Domain)BindHookHandlerByMap(pattern string, hookmap map[string]HandlerF
     return nil
 }
 
+// 对指定的路由注册中间件
+func (d *Domain)BindMiddleware(pattern string, middleware...Middleware) error {
+    for domain, _ := range d.m {
+        if err := d.s.BindMiddleware(pattern + "@" + domain, middleware...); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
 // 绑定指定的状态码回调函数
 func (d *Domain)BindStatusHandler(status int, handler HandlerFunc) {
     for domain, _ := range d.m {
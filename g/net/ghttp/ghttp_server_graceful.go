@@ -9,6 +9,7 @@ package ghttp
 import (
     "os"
     "fmt"
+    "errors"
     "net"
     "context"
     "net/http"
@@ -26,6 +27,7 @@ type gracefulServer struct {
     rawListener  net.Listener // 原始listener
     listener     net.Listener // 接口化封装的listener
     isHttps      bool         // 是否HTTPS
+    isRedirect   bool         // 是否HTTP跳转HTTPS的服务
     status       int          // 当前Server状态(关闭/运行)
 }
 
@@ -51,6 +53,9 @@ func (s *Server) newHttpServer(addr string) *http.Server {
         WriteTimeout   : s.config.WriteTimeout,
         IdleTimeout    : s.config.IdleTimeout,
         MaxHeaderBytes : s.config.MaxHeaderBytes,
+        BaseContext    : func(net.Listener) context.Context {
+            return s.ctx
+        },
     }
 }
 
@@ -87,16 +92,20 @@ func (s *gracefulServer) ListenAndServeTLS(certFile, keyFile string) error {
     addr   := s.httpServer.Addr
     config := &tls.Config{}
     if s.httpServer.TLSConfig != nil {
-        *config = *s.httpServer.TLSConfig
+        config = s.httpServer.TLSConfig.Clone()
     }
     if config.NextProtos == nil {
         config.NextProtos = []string{"http/1.1"}
     }
-    var err error
-    config.Certificates         = make([]tls.Certificate, 1)
-    config.Certificates[0], err = tls.LoadX509KeyPair(certFile, keyFile)
-    if err != nil {
-        return err
+    // 设置了证书文件时使用证书文件，否则使用TLS配置中的证书
+    if len(certFile) > 0 && len(keyFile) > 0 {
+        cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+        if err != nil {
+            return err
+        }
+        config.Certificates = []tls.Certificate{ cert }
+    } else if len(config.Certificates) == 0 && config.GetCertificate == nil {
+        return errors.New(fmt.Sprintf(`no certificate configured for https server on [%s]`, addr))
     }
     ln, err := s.getNetListener(addr)
     if err != nil {
@@ -113,6 +122,8 @@ func (s *gracefulServer) getProto() string {
     proto := "http"
     if s.isHttps {
         proto = "https"
+    } else if s.isRedirect {
+        proto = "http redirect"
     }
     return proto
 }
@@ -170,6 +181,14 @@ func (s *gracefulServer) shutdown() {
     }
 }
 
+// 执行请求优雅关闭，等待处理中的请求完成，直到ctx超时或者被取消
+func (s *gracefulServer) shutdownWithContext(ctx context.Context) error {
+    if s.status == SERVER_STATUS_STOPPED {
+        return nil
+    }
+    return s.httpServer.Shutdown(ctx)
+}
+
 // 执行请求强制关闭
 func (s *gracefulServer) close() {
     if s.status == SERVER_STATUS_STOPPED {
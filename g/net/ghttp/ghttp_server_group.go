@@ -0,0 +1,144 @@
+// Copyright 2018 gf Author(https://gitee.com/johng/gf). All Rights Reserved.
+//
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT was not distributed with this file,
+// You can obtain one at https://gitee.com/johng/gf.
+// 分组路由管理.
+
+package ghttp
+
+import (
+    "errors"
+    "strings"
+    "gitee.com/johng/gf/g/util/gregex"
+)
+
+// 分组路由对象，分组下注册的路由会自动加上分组的URI前缀，并且可以注册分组的中间件
+type RouterGroup struct {
+    server     *Server      // 所属Server
+    prefix     string       // URI前缀(以"/"开头，不以"/"结尾，根分组为空)
+}
+
+// 创建一个分组路由对象，prefix为分组的URI前缀，例如：/api/v1
+func (s *Server) Group(prefix string) *RouterGroup {
+    return &RouterGroup {
+        server : s,
+        prefix : joinGroupPrefix("", prefix),
+    }
+}
+
+// 创建一个嵌套的分组路由对象，URI前缀为当前分组前缀与prefix的组合，
+// 父级分组的中间件同样对嵌套分组下的路由生效，并在嵌套分组的中间件外层执行
+func (g *RouterGroup) Group(prefix string) *RouterGroup {
+    return &RouterGroup {
+        server : g.server,
+        prefix : joinGroupPrefix(g.prefix, prefix),
+    }
+}
+
+// 获取分组的URI前缀
+func (g *RouterGroup) Prefix() string {
+    if g.prefix == "" {
+        return "/"
+    }
+    return g.prefix
+}
+
+// 注册分组中间件，对分组URI前缀下的所有路由生效(包括嵌套分组，与路由的注册先后顺序无关)，先注册的中间件在外层先执行。
+// 分组中间件注册为分组前缀的模糊匹配中间件(BindMiddleware)，与Server.Use一样，Server运行时不能注册。
+func (g *RouterGroup) Use(middleware...Middleware) error {
+    if len(middleware) == 0 {
+        return nil
+    }
+    if g.server.Status() == SERVER_STATUS_RUNNING {
+        return errors.New("cannot bind middleware while server running")
+    }
+    return g.server.BindMiddleware(g.prefix + "/*", middleware...)
+}
+
+// 组合分组前缀与pattern，pattern中的HTTP Method及域名保持不变，例如：分组/api下的post:/user转换为post:/api/user
+func (g *RouterGroup) pattern(pattern string) string {
+    method := ""
+    if array, err := gregex.MatchString(`^([a-zA-Z]+):(.+)$`, pattern); len(array) > 1 && err == nil {
+        method  = array[1] + ":"
+        pattern = array[2]
+    }
+    uri := "/" + strings.TrimLeft(pattern, "/")
+    if g.prefix != "" {
+        if uri == "/" || uri[1] == '@' {
+            uri = g.prefix + uri[1:]
+        } else {
+            uri = g.prefix + uri
+        }
+    }
+    return method + uri
+}
+
+// 组合分组前缀，返回以"/"开头且不以"/"结尾的前缀，根前缀返回空字符串
+func joinGroupPrefix(parent, prefix string) string {
+    prefix = strings.Trim(prefix, "/")
+    if prefix == "" {
+        return parent
+    }
+    return parent + "/" + prefix
+}
+
+// 注册分组下的路由中间件，pattern为相对于分组前缀的路由规则
+func (g *RouterGroup) BindMiddleware(pattern string, middleware...Middleware) error {
+    return g.server.BindMiddleware(g.pattern(pattern), middleware...)
+}
+
+// 注册分组下的路由HTTP Method过滤方法，pattern为相对于分组前缀的路由规则，例如"/*"对分组下的所有路由生效
+func (g *RouterGroup) BindMethodFilter(pattern string, filter...MethodFilter) error {
+    return g.server.BindMethodFilter(g.pattern(pattern), filter...)
+}
+
+// 注册分组下的回调函数
+func (g *RouterGroup) BindHandler(pattern string, handler HandlerFunc) error {
+    return g.server.BindHandler(g.pattern(pattern), handler)
+}
+
+// 注册分组下的执行对象
+func (g *RouterGroup) BindObject(pattern string, obj interface{}, methods...string) error {
+    return g.server.BindObject(g.pattern(pattern), obj, methods...)
+}
+
+// 注册分组下的执行对象方法
+func (g *RouterGroup) BindObjectMethod(pattern string, obj interface{}, method string) error {
+    return g.server.BindObjectMethod(g.pattern(pattern), obj, method)
+}
+
+// 注册分组下的RESTful执行对象
+func (g *RouterGroup) BindObjectRest(pattern string, obj interface{}) error {
+    return g.server.BindObjectRest(g.pattern(pattern), obj)
+}
+
+// 注册分组下的控制器
+func (g *RouterGroup) BindController(pattern string, c Controller, methods...string) error {
+    return g.server.BindController(g.pattern(pattern), c, methods...)
+}
+
+// 注册分组下的控制器方法
+func (g *RouterGroup) BindControllerMethod(pattern string, c Controller, method string) error {
+    return g.server.BindControllerMethod(g.pattern(pattern), c, method)
+}
+
+// 注册分组下的RESTful控制器
+func (g *RouterGroup) BindControllerRest(pattern string, c Controller) error {
+    return g.server.BindControllerRest(g.pattern(pattern), c)
+}
+
+// 通过方法映射显式绑定分组下的控制器
+func (g *RouterGroup) BindControllerMap(pattern string, c Controller, methodMap map[string]string) error {
+    return g.server.BindControllerMap(g.pattern(pattern), c, methodMap)
+}
+
+// 显式绑定分组下的控制器处理函数
+func (g *RouterGroup) BindControllerFunc(method, pattern string, c Controller, handler func(c Controller)) error {
+    return g.server.BindControllerFunc(method, g.pattern(pattern), c, handler)
+}
+
+// 注册分组下的事件回调函数
+func (g *RouterGroup) BindHookHandler(pattern string, hook string, handler HandlerFunc) error {
+    return g.server.BindHookHandler(g.pattern(pattern), hook, handler)
+}
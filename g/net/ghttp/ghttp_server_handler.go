@@ -42,10 +42,14 @@ func (s *Server)handleRequest(w http.ResponseWriter, r *http.Request) {
         if request.LeaveTime == 0 {
             request.LeaveTime = gtime.Microsecond()
         }
+        // 删除上传文件的临时文件
+        request.removeUploadFiles()
         // access log
         s.handleAccessLog(request)
-        // error log使用recover进行判断
+        // error log使用recover进行判断(路由方法的panic已经在callServeHandler中处理，这里处理事件回调等其他流程的panic)
         if e := recover(); e != nil {
+            request.Response.WriteStatus(http.StatusInternalServerError)
+            request.Response.OutputBuffer()
             s.handleErrorLog(e, request)
         }
         // 将Request对象指针丢到队列中异步关闭
@@ -83,6 +87,23 @@ func (s *Server)handleRequest(w http.ResponseWriter, r *http.Request) {
             request.Router = parsedItem.handler.router
         }
     }
+    // 请求的HTTP Method被过滤方法(BindMethodFilter)过滤时，按照路由不支持该HTTP Method处理
+    denied := handler != nil && !s.filterMethod(request, request.Method)
+
+    // 静态目录映射检索(SetStaticPath)，精确匹配的路由优先于静态文件
+    listDir := s.config.IndexFolder
+    if filePath == "" && (handler == nil || !isExactRouter(handler.router)) {
+        if path, item := s.searchStaticPath(r.URL.Path); path != "" {
+            filePath              = path
+            listDir               = item.listDir
+            request.isFileRequest = true
+        }
+    }
+
+    // 跨域请求处理，已响应的预检请求不再执行后续的服务处理
+    if s.handleCORS(request) {
+        request.exit.Set(true)
+    }
 
     // 事件 - BeforeServe
     s.callHookHandler(HOOK_BEFORE_SERVE, request)
@@ -90,10 +111,17 @@ func (s *Server)handleRequest(w http.ResponseWriter, r *http.Request) {
     // 执行静态文件服务/回调控制器/执行对象/方法
     if !request.exit.Val() {
         if filePath != "" && (request.IsFileRequest() || handler == nil) {
-            s.serveFile(request, filePath)
+            s.serveFile(request, filePath, listDir)
         } else {
-            if handler != nil {
+            if handler != nil && !denied {
                 s.callServeHandler(handler, request)
+            } else if methods := s.allowedMethods(request); len(methods) > 0 || denied {
+                // 路由存在但未注册请求的HTTP Method时返回405，并通过Allow头信息返回该路由支持的HTTP Method列表，
+                // 所有HTTP Method均被过滤时不返回空的Allow头信息
+                if len(methods) > 0 {
+                    request.Response.Header().Set("Allow", strings.Join(methods, ","))
+                }
+                request.Response.WriteStatus(http.StatusMethodNotAllowed)
             } else {
                 request.Response.WriteStatus(http.StatusNotFound)
             }
@@ -108,6 +136,8 @@ func (s *Server)handleRequest(w http.ResponseWriter, r *http.Request) {
 
     // 事件 - BeforeOutput
     s.callHookHandler(HOOK_BEFORE_OUTPUT, request)
+    // 保存Session数据(需要在输出Cookie之前，保证客户端的后续请求能够获取到最新的数据)
+    request.Session.UpdateExpire()
     // 输出Cookie
     request.Cookie.Output()
     // 输出缓冲区
@@ -116,20 +146,36 @@ func (s *Server)handleRequest(w http.ResponseWriter, r *http.Request) {
     s.callHookHandler(HOOK_AFTER_OUTPUT, request)
 }
 
-// 初始化控制器
+// 执行路由处理方法(经过中间件包装)，路由方法产生的panic在这里恢复并交由panic处理方法处理，
+// 因此后续的AfterServe等事件回调以及Cookie/缓冲区数据的输出仍然会正常执行
 func (s *Server)callServeHandler(h *handlerItem, r *Request) {
     defer func() {
         if e := recover(); e != nil && e != gEXCEPTION_EXIT {
-            panic(e)
+            s.handlePanic(r, e)
         }
     }()
+    s.wrapMiddleware(r, func(r *Request) {
+        s.doServeHandler(h, r)
+    })(r)
+}
+
+// 初始化控制器，执行路由处理方法。
+// 控制器(执行对象)的执行顺序为：Init -> 路由方法 -> Shut，均在中间件的内层执行；
+// Init方法中可以调用Request.Exit()中止请求(例如鉴权失败时输出返回内容后退出)，此时路由方法及Shut方法都不会执行；
+// Init方法正常返回后，无论路由方法是否调用Request.Exit()退出或者产生panic，Shut方法都会执行，便于执行事务回滚等清理操作。
+func (s *Server)doServeHandler(h *handlerItem, r *Request) {
     if h.faddr == nil {
         // 新建一个控制器对象处理请求
         c := reflect.New(h.ctype)
         c.MethodByName("Init").Call([]reflect.Value{reflect.ValueOf(r)})
         if !r.IsExited() {
-            c.MethodByName(h.fname).Call(nil)
-            c.MethodByName("Shut").Call([]reflect.Value{reflect.ValueOf(r)})
+            defer c.MethodByName("Shut").Call([]reflect.Value{reflect.ValueOf(r)})
+            if h.cfunc != nil {
+                // 显式绑定的控制器处理函数
+                h.cfunc(c.Interface().(Controller))
+            } else if results := c.MethodByName(h.fname).Call(nil); len(results) > 0 {
+                s.writeReturnValues(r, results)
+            }
         }
     } else {
         // 是否有初始化及完成回调方法
@@ -137,16 +183,43 @@ func (s *Server)callServeHandler(h *handlerItem, r *Request) {
             h.finit(r)
         }
         if !r.IsExited() {
-            h.faddr(r)
             if h.fshut != nil {
-                h.fshut(r)
+                defer h.fshut(r)
             }
+            h.faddr(r)
+        }
+    }
+}
+
+// 输出控制器路由方法的返回值：
+// 错误信息不为nil时返回错误状态码及错误信息(如果错误对象实现了Status() int方法，那么使用该方法返回的状态码，否则为500)；
+// 返回值为nil时不输出；string/[]byte类型直接输出；其他类型按照JSON格式输出。
+func (s *Server)writeReturnValues(r *Request, results []reflect.Value) {
+    if len(results) > 1 && !results[1].IsNil() {
+        err    := results[1].Interface().(error)
+        status := http.StatusInternalServerError
+        if v, ok := err.(interface{ Status() int }); ok {
+            status = v.Status()
         }
+        r.Response.WriteStatus(status, err.Error())
+        return
+    }
+    if results[0].IsNil() {
+        return
+    }
+    switch value := results[0].Interface().(type) {
+        case string:
+            r.Response.Write(value)
+        case []byte:
+            r.Response.Write(value)
+        default:
+            r.Response.WriteJson(value)
     }
 }
 
-// http server静态文件处理，path可以为相对路径也可以为绝对路径
-func (s *Server)serveFile(r *Request, path string) {
+// http server静态文件处理，path可以为相对路径也可以为绝对路径，
+// 可选参数listDir表示访问目录时是否显示目录列表，默认使用IndexFolder配置
+func (s *Server)serveFile(r *Request, path string, listDir...bool) {
     r.isFileServe = true
 
     // 首先判断是否给定的path已经是一个绝对路径
@@ -165,14 +238,17 @@ func (s *Server)serveFile(r *Request, path string) {
     defer f.Close()
     info, _ := f.Stat()
     if info.IsDir() {
-        if s.config.IndexFolder {
+        if (len(listDir) > 0 && listDir[0]) || (len(listDir) == 0 && s.config.IndexFolder) {
             s.listDir(r, f)
         } else {
             r.Response.WriteStatus(http.StatusForbidden)
         }
     } else {
-        // 读取文件内容返回, no buffer
+        // 读取文件内容返回, no buffer，根据文件大小及修改时间生成ETag，用于If-None-Match缓存校验
         r.Response.length = int(info.Size())
+        if r.Response.Header().Get("ETag") == "" {
+            r.Response.Header().Set("ETag", fmt.Sprintf(`W/"%x-%x"`, info.Size(), info.ModTime().UnixNano()))
+        }
         http.ServeContent(r.Response.Writer, &r.Request, info.Name(), info.ModTime(), f)
     }
 }
@@ -206,8 +282,6 @@ func (s *Server) startCloseQueueLoop() {
             if v := s.closeQueue.Pop(); v != nil {
                 r := v.(*Request)
                 s.callHookHandler(HOOK_BEFORE_CLOSE, r)
-                // 更新Session会话超时时间
-                r.Session.UpdateExpire()
                 s.callHookHandler(HOOK_AFTER_CLOSE, r)
             }
         }
@@ -8,13 +8,28 @@
 package ghttp
 
 import (
+    "encoding/json"
     "fmt"
-    "net/http"
+    "gitee.com/johng/gf/g/os/gtime"
+    "strings"
+    "time"
+)
+
+// access log的格式，每一种格式都包含请求方法、请求路径、返回状态码、返回内容长度、客户端IP以及请求耗时(毫秒)
+const (
+    // 默认格式，例如："GET localhost:8199 /user?id=1 HTTP/1.1" 200 12 0.125, 127.0.0.1, "", "curl/7.58.0"
+    ACCESS_LOG_FORMAT_DEFAULT  = ""
+    // Common Log Format(末尾附加请求耗时)，例如：127.0.0.1 - - [02/Jan/2006:15:04:05 +0800] "GET /user?id=1 HTTP/1.1" 200 12 0.125
+    ACCESS_LOG_FORMAT_COMMON   = "common"
+    // Combined Log Format(末尾附加请求耗时)，即在Common Log Format的基础上增加Referer及User-Agent
+    ACCESS_LOG_FORMAT_COMBINED = "combined"
+    // JSON格式，每一条日志为一个JSON对象
+    ACCESS_LOG_FORMAT_JSON     = "json"
 )
 
 // 处理服务错误信息，主要是panic，http请求的status由access log进行管理
 func (s *Server) handleAccessLog(r *Request) {
-    if !s.IsAccessLogEnabled() {
+    if !s.IsAccessLogEnabled() || s.isAccessLogSkipped(r.URL.Path) {
         return
     }
     // 自定义错误处理
@@ -22,20 +37,66 @@ func (s *Server) handleAccessLog(r *Request) {
         v(r)
         return
     }
-    content := fmt.Sprintf(`"%s %s %s %s" %d %d`,
-        r.Method, r.Host, r.URL.String(), r.Proto,
-        r.Response.Status,
-        r.Response.ContentSize(),
-    )
-    content += fmt.Sprintf(` %.3f`, float64(r.LeaveTime - r.EnterTime)/1000)
-    content += fmt.Sprintf(`, %s, "%s", "%s"`, r.GetClientIp(), r.Referer(), r.UserAgent())
-    s.logger.Cat("access").Backtrace(false, 2).Println(content)
+    latency   := float64(r.LeaveTime - r.EnterTime)/1000
+    enterTime := time.Unix(0, r.EnterTime*int64(time.Microsecond))
+    content   := ""
+    switch s.GetAccessLogFormat() {
+        case ACCESS_LOG_FORMAT_COMMON, ACCESS_LOG_FORMAT_COMBINED:
+            content = fmt.Sprintf(`%s - - [%s] "%s %s %s" %d %d %.3f`,
+                r.GetClientIp(),
+                enterTime.Format("02/Jan/2006:15:04:05 -0700"),
+                r.Method, r.URL.RequestURI(), r.Proto,
+                r.Response.Status,
+                r.Response.ContentSize(),
+                latency,
+            )
+            if s.GetAccessLogFormat() == ACCESS_LOG_FORMAT_COMBINED {
+                content += fmt.Sprintf(` "%s" "%s"`, r.Referer(), r.UserAgent())
+            }
+        case ACCESS_LOG_FORMAT_JSON:
+            b, _ := json.Marshal(map[string]interface{} {
+                "time"    : enterTime.Format("2006-01-02 15:04:05.000"),
+                "method"  : r.Method,
+                "host"    : r.Host,
+                "uri"     : r.URL.RequestURI(),
+                "proto"   : r.Proto,
+                "status"  : r.Response.Status,
+                "size"    : r.Response.ContentSize(),
+                "latency" : latency,
+                "ip"      : r.GetClientIp(),
+                "referer" : r.Referer(),
+                "agent"   : r.UserAgent(),
+            })
+            content = string(b)
+        default:
+            content = fmt.Sprintf(`"%s %s %s %s" %d %d`,
+                r.Method, r.Host, r.URL.String(), r.Proto,
+                r.Response.Status,
+                r.Response.ContentSize(),
+            )
+            content += fmt.Sprintf(` %.3f`, latency)
+            content += fmt.Sprintf(`, %s, "%s", "%s"`, r.GetClientIp(), r.Referer(), r.UserAgent())
+    }
+    // 非默认格式的日志内容已经包含请求时间，不再输出日志对象的时间头信息
+    s.logger.Cat("access").Backtrace(false, 2).Header(s.GetAccessLogFormat() == ACCESS_LOG_FORMAT_DEFAULT).Println(content)
+}
+
+// 判断请求路径是否不需要记录access log
+func (s *Server) isAccessLogSkipped(path string) bool {
+    for _, v := range s.config.AccessLogSkip {
+        if strings.HasSuffix(v, "*") {
+            if strings.HasPrefix(path, v[0 : len(v) - 1]) {
+                return true
+            }
+        } else if path == v {
+            return true
+        }
+    }
+    return false
 }
 
 // 处理服务错误信息，主要是panic，http请求的status由access log进行管理
 func (s *Server) handleErrorLog(error interface{}, r *Request) {
-    r.Response.WriteStatus(http.StatusInternalServerError)
-
     // 错误输出默认是开启的
     if !s.IsErrorLogEnabled() {
         return
@@ -49,7 +110,12 @@ func (s *Server) handleErrorLog(error interface{}, r *Request) {
 
     // 错误日志信息
     content := fmt.Sprintf(`%v, "%s %s %s %s"`, error, r.Method, r.Host, r.URL.String(), r.Proto)
-    content += fmt.Sprintf(` %.3f`, float64(r.LeaveTime - r.EnterTime)/1000)
+    // 路由方法产生panic时请求尚未完成，使用当前时间计算耗时
+    leaveTime := r.LeaveTime
+    if leaveTime == 0 {
+        leaveTime = gtime.Microsecond()
+    }
+    content += fmt.Sprintf(` %.3f`, float64(leaveTime - r.EnterTime)/1000)
     content += fmt.Sprintf(`, %s, "%s", "%s"`,  r.GetClientIp(), r.Referer(), r.UserAgent())
 
     if s.logger.GetPath() == "" {
@@ -0,0 +1,130 @@
+// Copyright 2018 gf Author(https://gitee.com/johng/gf). All Rights Reserved.
+//
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT was not distributed with this file,
+// You can obtain one at https://gitee.com/johng/gf.
+// 中间件注册及调用.
+
+package ghttp
+
+import (
+    "errors"
+    "reflect"
+    "runtime"
+)
+
+const (
+    // 指定路由注册的中间件存放于hooksTree中的事件名称
+    gHOOK_MIDDLEWARE    = "*middleware"
+    // 指定路由注册的HTTP Method过滤方法存放于hooksTree中的事件名称
+    gHOOK_METHOD_FILTER = "*methodfilter"
+)
+
+// 中间件，参数next为下一级的处理方法(下一个中间件，或者最终的路由处理方法)，返回包装后的处理方法。
+// 中间件中可以在调用next之前或之后执行自定义的逻辑，如果不调用next(例如鉴权失败时直接输出返回内容)，那么后续的中间件及路由处理方法都不会执行。
+type Middleware func(next HandlerFunc) HandlerFunc
+
+// HTTP Method过滤方法，返回false表示当前请求不允许使用method访问匹配的路由
+type MethodFilter func(r *Request, method string) bool
+
+// 注册全局中间件，对所有路由注册的服务方法(回调函数/执行对象/控制器)生效，静态文件服务不经过中间件。
+// 执行顺序：先注册的中间件在外层先执行，全局中间件在BindMiddleware对指定路由注册的中间件之前执行；
+// 中间件在BeforeServe事件之后、AfterServe事件之前执行，控制器的Init/Shut方法在中间件内层执行。
+func (s *Server) Use(middleware...Middleware) error {
+    if s.Status() == SERVER_STATUS_RUNNING {
+        return errors.New("cannot bind middleware while server running")
+    }
+    s.middleware = append(s.middleware, middleware...)
+    return nil
+}
+
+// 对指定的路由注册中间件，pattern参数同BindHookHandler，支持模糊匹配规则，
+// 例如：BindMiddleware("/user", auth)对BindControllerRest("/user", ...)注册的所有方法生效，
+// BindMiddleware("/api/*", auth)对/api下的所有路由生效。
+// 执行顺序：匹配的规则越模糊(层级越浅)越在外层执行，同一规则注册的多个中间件先注册的在外层先执行；
+// 中间件的注册与路由的注册没有先后顺序要求。中间件中按照HTTP Method拒绝的请求无法反映到Allow头信息中，这类规则需要使用BindMethodFilter注册。
+func (s *Server) BindMiddleware(pattern string, middleware...Middleware) error {
+    if len(middleware) == 0 {
+        return nil
+    }
+    domain, method, uri, err := s.parsePattern(pattern)
+    if err != nil {
+        return err
+    }
+    // 同一规则多次注册时追加到已注册的中间件列表中
+    if item, ok := s.routesMap[s.hookHandlerKey(gHOOK_MIDDLEWARE, method, uri, domain)]; ok {
+        if s.Status() == SERVER_STATUS_RUNNING {
+            return errors.New("cannot bind middleware while server running")
+        }
+        item.handler.mware = append(item.handler.mware, middleware...)
+        return nil
+    }
+    return s.setHandler(pattern, &handlerItem {
+        name  : runtime.FuncForPC(reflect.ValueOf(middleware[0]).Pointer()).Name(),
+        ctype : nil,
+        fname : "",
+        faddr : nil,
+        mware : append([]Middleware(nil), middleware...),
+    }, gHOOK_MIDDLEWARE)
+}
+
+// 使用全局中间件及匹配路由的中间件包装路由处理方法
+func (s *Server) wrapMiddleware(r *Request, handler HandlerFunc) HandlerFunc {
+    items := s.getHookHandlerWithCache(gHOOK_MIDDLEWARE, r)
+    if len(s.middleware) == 0 && len(items) == 0 {
+        return handler
+    }
+    middleware := make([]Middleware, 0, len(s.middleware))
+    middleware  = append(middleware, s.middleware...)
+    // 检索结果按照优先级从高到低排列，优先级越低(规则越模糊)的中间件越在外层
+    for i := len(items) - 1; i >= 0; i-- {
+        middleware = append(middleware, items[i].handler.mware...)
+    }
+    // 从内层往外层包装，保证先注册的中间件在外层
+    for i := len(middleware) - 1; i >= 0; i-- {
+        handler = middleware[i](handler)
+    }
+    return handler
+}
+
+// 对指定的路由注册HTTP Method过滤方法，pattern参数同BindMiddleware(带有HTTP Method时只对该HTTP Method生效)。
+// 中间件中按照HTTP Method拒绝请求(例如只允许管理员执行PUT/DELETE)时，需要将该规则注册为过滤方法，过滤方法在请求时执行：
+// 1、被过滤的HTTP Method不会出现在自动生成的OPTIONS响应及405响应的Allow头信息中；
+// 2、使用被过滤的HTTP Method的请求返回405(在中间件之前处理)，因此中间件中不需要重复该规则。
+// 由于浏览器的CORS预检请求不会携带Cookie等凭证信息，预检请求返回的Access-Control-Allow-Methods不经过过滤方法。
+// 例如：BindMethodFilter("/admin/*", func(r *Request, method string) bool { return method == "GET" || isAdmin(r) })
+func (s *Server) BindMethodFilter(pattern string, filter...MethodFilter) error {
+    if len(filter) == 0 {
+        return nil
+    }
+    domain, method, uri, err := s.parsePattern(pattern)
+    if err != nil {
+        return err
+    }
+    if item, ok := s.routesMap[s.hookHandlerKey(gHOOK_METHOD_FILTER, method, uri, domain)]; ok {
+        if s.Status() == SERVER_STATUS_RUNNING {
+            return errors.New("cannot bind method filter while server running")
+        }
+        item.handler.mfilter = append(item.handler.mfilter, filter...)
+        return nil
+    }
+    return s.setHandler(pattern, &handlerItem {
+        name    : runtime.FuncForPC(reflect.ValueOf(filter[0]).Pointer()).Name(),
+        ctype   : nil,
+        fname   : "",
+        faddr   : nil,
+        mfilter : filter,
+    }, gHOOK_METHOD_FILTER)
+}
+
+// 判断当前请求是否允许使用method访问匹配的路由(所有匹配的过滤方法都返回true)
+func (s *Server) filterMethod(r *Request, method string) bool {
+    for _, item := range s.getHookHandlerByMethodWithCache(gHOOK_METHOD_FILTER, method, r) {
+        for _, filter := range item.handler.mfilter {
+            if !filter(r, method) {
+                return false
+            }
+        }
+    }
+    return true
+}
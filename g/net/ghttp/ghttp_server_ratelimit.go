@@ -0,0 +1,113 @@
+// Copyright 2018 gf Author(https://gitee.com/johng/gf). All Rights Reserved.
+//
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT was not distributed with this file,
+// You can obtain one at https://gitee.com/johng/gf.
+// 请求限流中间件.
+
+package ghttp
+
+import (
+    "math"
+    "net/http"
+    "strconv"
+    "sync"
+    "time"
+)
+
+const (
+    gRATE_LIMIT_PRUNE_INTERVAL = time.Minute // 清理空闲令牌桶的时间间隔
+)
+
+// 令牌桶限流对象，按照键名(默认为客户端IP)区分令牌桶
+type rateLimiter struct {
+    mu        sync.Mutex
+    rate      float64                // 每秒生成的令牌数量
+    burst     float64                // 令牌桶容量(允许的突发请求数量)
+    buckets   map[string]*rateBucket // 令牌桶(键名为限流的键名)
+    lastPrune time.Time              // 最近一次清理空闲令牌桶的时间
+}
+
+// 令牌桶
+type rateBucket struct {
+    tokens float64   // 当前的令牌数量
+    last   time.Time // 最近一次计算令牌数量的时间
+}
+
+// 中间件：按照客户端IP进行请求限流(令牌桶算法)，rps为每秒允许的请求数量，burst为允许的突发请求数量(令牌桶容量，最小为1)，
+// key为非必需参数，用于自定义限流的键名(例如按照登录用户限流)，默认为客户端IP(Request.GetClientIp)。
+// 超出限制的请求返回429状态码，并通过Retry-After头信息返回客户端需要等待的秒数；空闲(令牌桶已满)的令牌桶会被定期清理。
+// 可以全局使用(Server.Use)，也可以对分组或者指定路由使用(RouterGroup.Use/BindMiddleware)，
+// 每一次调用RateLimit创建的中间件使用独立的令牌桶，例如：s.BindMiddleware("/api/*", ghttp.RateLimit(10, 20))。
+func RateLimit(rps float64, burst int, key...func(r *Request) string) Middleware {
+    if burst < 1 {
+        burst = 1
+    }
+    limiter := &rateLimiter {
+        rate      : rps,
+        burst     : float64(burst),
+        buckets   : make(map[string]*rateBucket),
+        lastPrune : time.Now(),
+    }
+    keyFunc := func(r *Request) string {
+        return r.GetClientIp()
+    }
+    if len(key) > 0 && key[0] != nil {
+        keyFunc = key[0]
+    }
+    return func(next HandlerFunc) HandlerFunc {
+        return func(r *Request) {
+            if rps <= 0 {
+                next(r)
+                return
+            }
+            if ok, wait := limiter.allow(keyFunc(r), time.Now()); !ok {
+                r.Response.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+                r.Response.WriteStatus(http.StatusTooManyRequests)
+                return
+            }
+            next(r)
+        }
+    }
+}
+
+// 判断指定键名的请求是否允许通过，不允许时返回需要等待的时间
+func (l *rateLimiter) allow(key string, now time.Time) (bool, time.Duration) {
+    l.mu.Lock()
+    defer l.mu.Unlock()
+    if now.Sub(l.lastPrune) >= gRATE_LIMIT_PRUNE_INTERVAL {
+        l.prune(now)
+    }
+    bucket, ok := l.buckets[key]
+    if !ok {
+        bucket = &rateBucket{ tokens : l.burst, last : now }
+        l.buckets[key] = bucket
+    } else {
+        bucket.tokens = l.refill(bucket, now)
+        bucket.last   = now
+    }
+    if bucket.tokens >= 1 {
+        bucket.tokens--
+        return true, 0
+    }
+    return false, time.Duration((1 - bucket.tokens) / l.rate * float64(time.Second))
+}
+
+// 计算令牌桶在给定时间的令牌数量
+func (l *rateLimiter) refill(bucket *rateBucket, now time.Time) float64 {
+    tokens := bucket.tokens + now.Sub(bucket.last).Seconds() * l.rate
+    if tokens > l.burst {
+        tokens = l.burst
+    }
+    return tokens
+}
+
+// 清理空闲的令牌桶：令牌桶已满时与新建的令牌桶等价，删除后不影响限流结果
+func (l *rateLimiter) prune(now time.Time) {
+    for key, bucket := range l.buckets {
+        if l.refill(bucket, now) >= l.burst {
+            delete(l.buckets, key)
+        }
+    }
+    l.lastPrune = now
+}
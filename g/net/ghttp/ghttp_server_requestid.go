@@ -0,0 +1,56 @@
+// Copyright 2018 gf Author(https://gitee.com/johng/gf). All Rights Reserved.
+//
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT was not distributed with this file,
+// You can obtain one at https://gitee.com/johng/gf.
+// 请求ID中间件.
+
+package ghttp
+
+import (
+    "strconv"
+    "time"
+    "gitee.com/johng/gf/g/os/glog"
+    "gitee.com/johng/gf/g/util/grand"
+    "gitee.com/johng/gf/g/util/gregex"
+)
+
+const (
+    gDEFAULT_REQUEST_ID_HEADER = "X-Request-Id"          // 默认的请求ID头信息名称
+    gREQUEST_ID_PATTERN        = `^[\w\-\.:]{1,128}$`    // 允许沿用的客户端请求ID格式，防止日志注入
+)
+
+// 中间件：为每一个请求设置请求ID，并注入到请求的上下文中(glog.WithRequestId)，
+// 处理方法中通过glog.Ctx(r.Context())输出的日志都会带上request_id字段，便于关联同一请求的所有日志。
+// header为非必需参数，用于自定义请求ID的头信息名称，默认为"X-Request-Id"：客户端(或者上游网关)通过该头信息传递了合法的请求ID时
+// 沿用该请求ID，否则生成新的请求ID，请求ID同时通过该头信息返回给客户端。例如：s.Use(ghttp.RequestId())
+func RequestId(header...string) Middleware {
+    name := gDEFAULT_REQUEST_ID_HEADER
+    if len(header) > 0 && header[0] != "" {
+        name = header[0]
+    }
+    return func(next HandlerFunc) HandlerFunc {
+        return func(r *Request) {
+            id := r.Header.Get(name)
+            if !gregex.IsMatchString(gREQUEST_ID_PATTERN, id) {
+                id = newRequestId()
+            }
+            r.Response.Header().Set(name, id)
+            r.SetContext(glog.WithRequestId(r.Context(), id))
+            next(r)
+        }
+    }
+}
+
+// 获取请求ID(由RequestId中间件设置)，未设置时返回空字符串
+func (r *Request) GetRequestId() string {
+    if id, ok := r.GetCtxVar(glog.CTX_KEY_REQUEST_ID).(string); ok {
+        return id
+    }
+    return ""
+}
+
+// 生成新的请求ID(纳秒时间戳的36进制表示及随机字符串)
+func newRequestId() string {
+    return strconv.FormatInt(time.Now().UnixNano(), 36) + grand.RandStr(8)
+}
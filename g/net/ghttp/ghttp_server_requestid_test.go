@@ -0,0 +1,64 @@
+// Copyright 2018 gf Author(https://gitee.com/johng/gf). All Rights Reserved.
+//
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT was not distributed with this file,
+// You can obtain one at https://gitee.com/johng/gf.
+
+// go test *.go -v
+
+package ghttp
+
+import (
+    "bytes"
+    "net/http/httptest"
+    "strings"
+    "testing"
+    "gitee.com/johng/gf/g/os/glog"
+)
+
+// RequestId中间件沿用客户端传递的合法请求ID，否则生成新的请求ID，通过glog.Ctx输出的日志带上request_id字段
+func TestServer_RequestId(t *testing.T) {
+    buffer := bytes.NewBuffer(nil)
+    logger := glog.New()
+    logger.SetWriter(buffer)
+    logger.SetStdPrint(false)
+
+    s := testServer("TestServer_RequestId")
+    s.Use(RequestId())
+    s.BindHandler("/", func(r *Request) {
+        logger.Ctx(r.Context()).Info("handled")
+        r.Response.Write(r.GetRequestId())
+    })
+
+    w := httptest.NewRecorder()
+    r := httptest.NewRequest("GET", "/", nil)
+    r.Header.Set("X-Request-Id", "abc-123")
+    s.handleRequest(w, r)
+    if w.Body.String() != "abc-123" || w.Header().Get("X-Request-Id") != "abc-123" {
+        t.Errorf(`unexpected request id "%s", header "%s"`, w.Body.String(), w.Header().Get("X-Request-Id"))
+    }
+    if !strings.Contains(buffer.String(), "request_id=abc-123") {
+        t.Errorf(`expected request_id field in log "%s"`, buffer.String())
+    }
+
+    // 非法的客户端请求ID会被替换
+    buffer.Reset()
+    w = httptest.NewRecorder()
+    r = httptest.NewRequest("GET", "/", nil)
+    r.Header.Set("X-Request-Id", "abc\nfake log")
+    s.handleRequest(w, r)
+    id := w.Header().Get("X-Request-Id")
+    if id == "" || strings.Contains(id, "fake") || w.Body.String() != id {
+        t.Errorf(`unexpected generated request id "%s"`, id)
+    }
+    if !strings.Contains(buffer.String(), "request_id=" + id) {
+        t.Errorf(`expected request_id field in log "%s"`, buffer.String())
+    }
+
+    // 上下文中不存在请求ID时不输出request_id字段
+    buffer.Reset()
+    logger.Ctx(nil).Info("no context")
+    if strings.Contains(buffer.String(), "request_id") {
+        t.Errorf(`unexpected request_id field in log "%s"`, buffer.String())
+    }
+}
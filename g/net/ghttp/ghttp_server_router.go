@@ -25,7 +25,7 @@ func (s *Server)parsePattern(pattern string) (domain, method, uri string, err er
     domain = gDEFAULT_DOMAIN
     method = gDEFAULT_METHOD
     if array, err := gregex.MatchString(`([a-zA-Z]+):(.+)`, pattern); len(array) > 1 && err == nil {
-        method = array[1]
+        method = strings.ToUpper(array[1])
         uri    = array[2]
     }
     if array, err := gregex.MatchString(`(.+)@([\w\.\-]+)`, uri); len(array) > 1 && err == nil {
@@ -72,19 +72,25 @@ func (s *Server) setHandler(pattern string, handler *handlerItem, hook ... strin
     regkey := s.hookHandlerKey(hookName, method, uri, domain)
     caller := s.getHandlerRegisterCallerLine(handler)
     if line, ok := s.routesMap[regkey]; ok {
-        s := fmt.Sprintf(`duplicated route registry "%s" in %s , former in %s`, pattern, caller, line)
-        glog.Errorfln(s)
-        return errors.New(s)
-    } else {
-        defer func() {
-            if resultErr == nil {
-                s.routesMap[regkey] = registeredRouteItem{
-                    file    : caller,
-                    handler : handler,
-                }
-            }
-        }()
+        // 显式绑定的路由优先于反射绑定的路由(与注册的先后顺序无关)：
+        // 已存在显式绑定时忽略反射绑定，已存在反射绑定时使用显式绑定进行替换
+        if line.handler.explicit && !handler.explicit {
+            return nil
+        }
+        if line.handler.explicit || !handler.explicit {
+            s := fmt.Sprintf(`duplicated route registry "%s" in %s , former in %s`, pattern, caller, line.file)
+            glog.Error(s)
+            return errors.New(s)
+        }
     }
+    defer func() {
+        if resultErr == nil {
+            s.routesMap[regkey] = registeredRouteItem{
+                file    : caller,
+                handler : handler,
+            }
+        }
+    }()
 
     // 路由对象
     handler.router = &Router {
@@ -183,6 +189,10 @@ func (s *Server) setHandler(pattern string, handler *handlerItem, hook ... strin
             l.PushBack(handler)
         }
     }
+    // 服务路由同时维护前缀树路由表(事件回调只使用哈希表+链表路由表)
+    if len(hookName) == 0 {
+        s.setTrieHandler(handler)
+    }
     //gutil.Dump(s.serveTree)
     //gutil.Dump(s.hooksTree)
     return nil
@@ -77,10 +77,15 @@ func (s *Server) callHookHandler(hook string, r *Request) {
 // 查询请求处理方法.
 // 内部带锁机制，可以并发读，但是不能并发写；并且有缓存机制，按照Host、Method、Path进行缓存.
 func (s *Server) getHookHandlerWithCache(hook string, r *Request) []*handlerParsedItem {
+    return s.getHookHandlerByMethodWithCache(hook, r.Method, r)
+}
+
+// 按照指定的HTTP Method(而不是请求的HTTP Method)查询请求的事件处理方法，缓存机制同getHookHandlerWithCache
+func (s *Server) getHookHandlerByMethodWithCache(hook, method string, r *Request) []*handlerParsedItem {
     cacheItems := ([]*handlerParsedItem)(nil)
-    cacheKey   := s.hookHandlerKey(hook, r.Method, r.URL.Path, r.GetHost())
+    cacheKey   := s.hookHandlerKey(hook, method, r.URL.Path, r.GetHost())
     if v := s.hooksCache.Get(cacheKey); v == nil {
-        cacheItems = s.searchHookHandler(r.Method, r.URL.Path, r.GetHost(), hook)
+        cacheItems = s.searchHookHandler(method, r.URL.Path, r.GetHost(), hook)
         if cacheItems != nil {
             s.hooksCache.Set(cacheKey, cacheItems, 0)
         }
@@ -8,6 +8,7 @@
 package ghttp
 
 import (
+    "sort"
     "strings"
     "container/list"
     "gitee.com/johng/gf/g/util/gregex"
@@ -29,7 +30,7 @@ func (s *Server) getServeHandlerWithCache(r *Request) *handlerParsedItem {
     return cacheItem
 }
 
-// 服务方法检索
+// 服务方法检索，按照ROUTER_MODE_TREE(哈希表+链表，见searchServeTree)或者ROUTER_MODE_TRIE(前缀树，见searchServeTrie)检索
 func (s *Server) searchServeHandler(method, path, domain string) *handlerParsedItem {
     if len(path) == 0 {
         return nil
@@ -39,6 +40,62 @@ func (s *Server) searchServeHandler(method, path, domain string) *handlerParsedI
     if !strings.EqualFold(gDEFAULT_DOMAIN, domain) {
         domains = append(domains, domain)
     }
+    for _, domain := range domains {
+        item := (*handlerParsedItem)(nil)
+        if s.config.RouterMode == ROUTER_MODE_TRIE {
+            item = s.searchServeTrie(method, path, domain)
+        } else {
+            item = s.searchServeTree(method, path, domain)
+        }
+        if item != nil {
+            return item
+        }
+    }
+    return nil
+}
+
+// 在指定域名的哈希表+链表路由表中检索服务方法
+func (s *Server) searchServeTree(method, path, domain string) *handlerParsedItem {
+    lists := s.searchServeTreeLists(path, domain)
+    // 多层链表遍历检索，从数组末尾的链表开始遍历，末尾的深度高优先级也高
+    for i := len(lists) - 1; i >= 0; i-- {
+        for e := lists[i].Front(); e != nil; e = e.Next() {
+            item := e.Value.(*handlerItem)
+            // 动态匹配规则带有gDEFAULT_METHOD的情况，不会像静态规则那样直接解析为所有的HTTP METHOD存储
+            if strings.EqualFold(item.router.Method, gDEFAULT_METHOD) || strings.EqualFold(item.router.Method, method) {
+                // 注意当不带任何动态路由规则时，len(match) == 1
+                if match, err := gregex.MatchString(item.router.RegRule, path); err == nil && len(match) > 0 {
+                    //gutil.Dump(match)
+                    //gutil.Dump(names)
+                    parsedItem := &handlerParsedItem{item, nil}
+                    // 如果需要query匹配，那么需要重新正则解析URL
+                    if len(item.router.RegNames) > 0 {
+                        if len(match) > len(item.router.RegNames) {
+                            parsedItem.values = make(map[string][]string)
+                            // 如果存在存在同名路由参数名称，那么执行数组追加
+                            for i, name := range item.router.RegNames {
+                                if _, ok := parsedItem.values[name]; ok {
+                                    parsedItem.values[name] = append(parsedItem.values[name], match[i + 1])
+                                } else {
+                                    parsedItem.values[name] = []string{match[i + 1]}
+                                }
+                            }
+                        }
+                    }
+                    return parsedItem
+                }
+            }
+        }
+    }
+    return nil
+}
+
+// 获取指定域名的哈希表+链表路由表中可能匹配URL.Path的链表，按照层级从浅到深排列(层级越深优先级越高)
+func (s *Server) searchServeTreeLists(path, domain string) []*list.List {
+    p, ok := s.serveTree[domain]
+    if !ok {
+        return nil
+    }
     // URL.Path层级拆分
     array := ([]string)(nil)
     if strings.EqualFold("/", path) {
@@ -46,73 +103,88 @@ func (s *Server) searchServeHandler(method, path, domain string) *handlerParsedI
     } else {
         array = strings.Split(path[1:], "/")
     }
-    for _, domain := range domains {
-        p, ok := s.serveTree[domain]
-        if !ok {
-            continue
+    // 多层链表(每个节点都有一个*list链表)的目的是当叶子节点未有任何规则匹配时，让父级模糊匹配规则继续处理
+    lists := make([]*list.List, 0)
+    for k, v := range array {
+        if _, ok := p.(map[string]interface{})["*list"]; ok {
+            lists = append(lists, p.(map[string]interface{})["*list"].(*list.List))
         }
-        // 多层链表(每个节点都有一个*list链表)的目的是当叶子节点未有任何规则匹配时，让父级模糊匹配规则继续处理
-        lists := make([]*list.List, 0)
-        for k, v := range array {
-            if _, ok := p.(map[string]interface{})["*list"]; ok {
-                lists = append(lists, p.(map[string]interface{})["*list"].(*list.List))
-            }
-            if _, ok := p.(map[string]interface{})[v]; ok {
-                p = p.(map[string]interface{})[v]
-                if k == len(array) - 1 {
-                    if _, ok := p.(map[string]interface{})["*list"]; ok {
-                        lists = append(lists, p.(map[string]interface{})["*list"].(*list.List))
-                        break
-                    }
-                }
-            } else {
-                if _, ok := p.(map[string]interface{})["*fuzz"]; ok {
-                    p = p.(map[string]interface{})["*fuzz"]
-                }
-            }
-            // 如果是叶子节点，同时判断当前层级的"*fuzz"键名，解决例如：/user/*action 匹配 /user 的规则
+        if _, ok := p.(map[string]interface{})[v]; ok {
+            p = p.(map[string]interface{})[v]
             if k == len(array) - 1 {
-                if _, ok := p.(map[string]interface{})["*fuzz"]; ok {
-                    p = p.(map[string]interface{})["*fuzz"]
-                }
                 if _, ok := p.(map[string]interface{})["*list"]; ok {
                     lists = append(lists, p.(map[string]interface{})["*list"].(*list.List))
+                    break
                 }
             }
+        } else {
+            if _, ok := p.(map[string]interface{})["*fuzz"]; ok {
+                p = p.(map[string]interface{})["*fuzz"]
+            }
+        }
+        // 如果是叶子节点，同时判断当前层级的"*fuzz"键名，解决例如：/user/*action 匹配 /user 的规则
+        if k == len(array) - 1 {
+            if _, ok := p.(map[string]interface{})["*fuzz"]; ok {
+                p = p.(map[string]interface{})["*fuzz"]
+            }
+            if _, ok := p.(map[string]interface{})["*list"]; ok {
+                lists = append(lists, p.(map[string]interface{})["*list"].(*list.List))
+            }
         }
+    }
+    return lists
+}
 
-        // 多层链表遍历检索，从数组末尾的链表开始遍历，末尾的深度高优先级也高
-        for i := len(lists) - 1; i >= 0; i-- {
+// 检索指定路径下注册了路由的HTTP Method列表(大写，按照字母排序)，按照ALL注册的路由匹配所有的HTTP Method；
+// 只有自动生成的OPTIONS处理方法(RESTful控制器未定义Options方法)时，OPTIONS不计入列表。
+// 每个域名只遍历一次路由表(而不是每个HTTP Method检索一次)，每个HTTP Method以检索顺序(同searchServeHandler)第一个匹配的路由项为准，
+// 前缀树检索方式下前缀树与哈希表+链表路由表同时维护并且检索结果一致，因此同样使用哈希表+链表路由表检索。
+func (s *Server) searchAllowedMethods(path, domain string) []string {
+    methods := make([]string, 0)
+    if len(path) == 0 {
+        return methods
+    }
+    domains := []string{ gDEFAULT_DOMAIN }
+    if !strings.EqualFold(gDEFAULT_DOMAIN, domain) {
+        domains = append(domains, domain)
+    }
+    // 已经确定的HTTP Method，键值表示是否计入列表
+    matched := make(map[string]bool)
+    all     := strings.Split(gHTTP_METHODS, ",")
+    for _, domain := range domains {
+        lists := s.searchServeTreeLists(path, domain)
+        for i := len(lists) - 1; i >= 0 && len(matched) < len(all); i-- {
             for e := lists[i].Front(); e != nil; e = e.Next() {
                 item := e.Value.(*handlerItem)
-                // 动态匹配规则带有gDEFAULT_METHOD的情况，不会像静态规则那样直接解析为所有的HTTP METHOD存储
-                if strings.EqualFold(item.router.Method, gDEFAULT_METHOD) || strings.EqualFold(item.router.Method, method) {
-                    // 注意当不带任何动态路由规则时，len(match) == 1
-                    if match, err := gregex.MatchString(item.router.RegRule, path); err == nil && len(match) > 0 {
-                        //gutil.Dump(match)
-                        //gutil.Dump(names)
-                        parsedItem := &handlerParsedItem{item, nil}
-                        // 如果需要query匹配，那么需要重新正则解析URL
-                        if len(item.router.RegNames) > 0 {
-                            if len(match) > len(item.router.RegNames) {
-                                parsedItem.values = make(map[string][]string)
-                                // 如果存在存在同名路由参数名称，那么执行数组追加
-                                for i, name := range item.router.RegNames {
-                                    if _, ok := parsedItem.values[name]; ok {
-                                        parsedItem.values[name] = append(parsedItem.values[name], match[i + 1])
-                                    } else {
-                                        parsedItem.values[name] = []string{match[i + 1]}
-                                    }
-                                }
-                            }
-                        }
-                        return parsedItem
+                if match, err := gregex.MatchString(item.router.RegRule, path); err != nil || len(match) == 0 {
+                    continue
+                }
+                for _, method := range all {
+                    if _, ok := matched[method]; !ok && s.matchRouterMethod(item.router, method) {
+                        matched[method] = !item.auto
                     }
                 }
             }
         }
     }
-    return nil
+    for method, allowed := range matched {
+        if allowed {
+            methods = append(methods, method)
+        }
+    }
+    sort.Strings(methods)
+    return methods
+}
+
+// 获取当前请求的路由支持的HTTP Method列表：路由注册的HTTP Method中未被过滤方法(BindMethodFilter)过滤的HTTP Method
+func (s *Server) allowedMethods(r *Request) []string {
+    methods := make([]string, 0)
+    for _, method := range s.searchAllowedMethods(r.URL.Path, r.GetHost()) {
+        if s.filterMethod(r, method) {
+            methods = append(methods, method)
+        }
+    }
+    return methods
 }
 
 // 生成回调方法查询的Key
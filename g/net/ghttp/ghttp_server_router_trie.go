@@ -0,0 +1,264 @@
+// Copyright 2018 gf Author(https://gitee.com/johng/gf). All Rights Reserved.
+//
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT was not distributed with this file,
+// You can obtain one at https://gitee.com/johng/gf.
+// 服务路由前缀树检索(ROUTER_MODE_TRIE).
+
+package ghttp
+
+import (
+    "strings"
+    "gitee.com/johng/gf/g/util/gregex"
+)
+
+const (
+    gTRIE_SEGMENT_STATIC   = iota // 精准匹配层级
+    gTRIE_SEGMENT_PARAM           // 命名匹配层级(:name)
+    gTRIE_SEGMENT_FIELD           // 包含{field}规则的层级
+    gTRIE_SEGMENT_WILDCARD        // 位于末尾层级的模糊匹配(*any)
+    gTRIE_SEGMENT_REGEX           // 无法使用前缀树表示的层级
+)
+
+// 服务路由前缀树，每个域名对应一棵
+type routeTrie struct {
+    root     *routeTrieNode            // 根节点(对应URI "/")
+    regex    bool                      // 是否注册了无法使用前缀树表示的路由项(例如不在末尾的*规则)，此时使用哈希表+链表路由表检索
+}
+
+// 前缀树节点，每个节点对应URI的一个层级
+type routeTrieNode struct {
+    static   map[string]*routeTrieNode // 精准匹配的子节点
+    param    *routeTrieNode            // 命名匹配(:name及{field}规则)的子节点
+    items    []*routeTrieItem          // 在该节点结束的路由项(按照优先级排序)
+    wildcard []*routeTrieItem          // 在该节点以模糊匹配(*any)结束的路由项(按照优先级排序)，匹配该节点之后的所有层级
+}
+
+// 前缀树路由项
+type routeTrieItem struct {
+    handler  *handlerItem // 路由注册项
+    names    []string     // 按照层级顺序排列的路由参数名称，匿名参数(单独的":"或者"*")为空
+    regex    bool         // 是否需要按照正则匹配(包含{field}规则的路由项)
+}
+
+// 前缀树检索结果
+type routeTrieMatch struct {
+    item     *routeTrieItem // 优先级最高的路由项
+    values   []string       // 与item.names一一对应的路由参数值(正则匹配的路由项为正则匹配的结果)
+    rank     int            // 路由项在哈希表+链表路由表中被检索到的链表层级，层级越深优先级越高
+}
+
+func newRouteTrieNode() *routeTrieNode {
+    return &routeTrieNode {
+        static : make(map[string]*routeTrieNode),
+    }
+}
+
+// 获取路由规则(不带method和domain)层级的前缀树节点类型：
+// 精准匹配、整个层级的":name"、位于末尾层级的"*any"、包含{field}规则的层级(与":name"相同只匹配一个层级，但是需要按照正则匹配)，
+// 其他规则(例如不在末尾层级的*规则)无法使用前缀树表示。
+func trieSegmentType(segment string, last bool) int {
+    switch segment[0] {
+        case ':':
+            return gTRIE_SEGMENT_PARAM
+        case '*':
+            if last {
+                return gTRIE_SEGMENT_WILDCARD
+            }
+            return gTRIE_SEGMENT_REGEX
+    }
+    if strings.ContainsAny(segment, `*()[]?|^$\`) {
+        return gTRIE_SEGMENT_REGEX
+    }
+    if gregex.IsMatchString(`\{[\w\.\-]+\}`, segment) {
+        return gTRIE_SEGMENT_FIELD
+    }
+    // 与patternToRegRule一致，只有"."及"+"会按照普通字符处理
+    return gTRIE_SEGMENT_STATIC
+}
+
+// 将服务路由项添加到前缀树中(在setHandler中与哈希表+链表路由表同时维护)
+func (s *Server) setTrieHandler(handler *handlerItem) {
+    trie, ok := s.serveTrie[handler.router.Domain]
+    if !ok {
+        trie = &routeTrie{ root : newRouteTrieNode() }
+        s.serveTrie[handler.router.Domain] = trie
+    }
+    node  := trie.root
+    item  := &routeTrieItem{ handler : handler, names : make([]string, 0) }
+    array := strings.Split(handler.router.Uri[1:], "/")
+    for k, v := range array {
+        if len(v) == 0 {
+            continue
+        }
+        switch trieSegmentType(v, k == len(array) - 1) {
+            case gTRIE_SEGMENT_REGEX:
+                trie.regex = true
+                return
+            case gTRIE_SEGMENT_PARAM, gTRIE_SEGMENT_FIELD:
+                if node.param == nil {
+                    node.param = newRouteTrieNode()
+                }
+                node = node.param
+                if v[0] == ':' {
+                    item.names = append(item.names, v[1:])
+                } else {
+                    item.regex = true
+                }
+            case gTRIE_SEGMENT_WILDCARD:
+                item.names    = append(item.names, v[1:])
+                node.wildcard = s.insertTrieItem(node.wildcard, item)
+                return
+            default:
+                child, ok := node.static[v]
+                if !ok {
+                    child = newRouteTrieNode()
+                    node.static[v] = child
+                }
+                node = child
+        }
+    }
+    node.items = s.insertTrieItem(node.items, item)
+}
+
+// 按照优先级将路由项插入到列表中(规则同哈希表+链表路由表)，已存在相同的路由注册项时进行替换
+func (s *Server) insertTrieItem(items []*routeTrieItem, item *routeTrieItem) []*routeTrieItem {
+    router := item.handler.router
+    for k, v := range items {
+        if strings.EqualFold(router.Method, v.handler.router.Method) && strings.EqualFold(router.Uri, v.handler.router.Uri) {
+            items[k] = item
+            return items
+        }
+    }
+    for k, v := range items {
+        if s.compareRouterPriority(router, v.handler.router) {
+            items = append(items, nil)
+            copy(items[k + 1:], items[k:])
+            items[k] = item
+            return items
+        }
+    }
+    return append(items, item)
+}
+
+// 在指定域名的前缀树路由表中检索服务方法，检索耗时只与URL.Path的层级数量有关，与注册的路由数量无关。
+// 匹配规则与哈希表+链表路由表(searchServeTree)保持一致：
+// 1、哈希表+链表路由表优先按照精准匹配逐层检索，并从最深的层级开始检索每一层级的链表，
+//    因此优先选择沿着精准匹配路径匹配层级更深的路由项，例如：/user/list/*any 比 /user/:id/profile 优先匹配 /user/list/profile；
+// 2、层级相同时按照compareRouterPriority选择优先级最高的路由项。
+// 注册了无法使用前缀树表示的路由项(例如/user/*any/edit)的域名，使用哈希表+链表路由表检索。
+func (s *Server) searchServeTrie(method, path, domain string) *handlerParsedItem {
+    trie, ok := s.serveTrie[domain]
+    if !ok {
+        return nil
+    }
+    if trie.regex {
+        return s.searchServeTree(method, path, domain)
+    }
+    segments := ([]string)(nil)
+    if path != "/" {
+        segments = strings.Split(path[1:], "/")
+    }
+    match := &routeTrieMatch{}
+    s.searchTrieNode(trie.root, true, 0, true, 0, path, segments, nil, method, match)
+    if match.item == nil {
+        return nil
+    }
+    parsedItem := &handlerParsedItem{match.item.handler, nil}
+    names      := match.item.names
+    values     := match.values
+    // 正则匹配的路由项，第一个匹配结果为完整的URL.Path
+    if match.item.regex {
+        names  = match.item.handler.router.RegNames
+        values = values[1:]
+    }
+    for i, name := range names {
+        if len(name) == 0 || i >= len(values) {
+            continue
+        }
+        if parsedItem.values == nil {
+            parsedItem.values = make(map[string][]string)
+        }
+        // 如果存在存在同名路由参数名称，那么执行数组追加
+        parsedItem.values[name] = append(parsedItem.values[name], values[i])
+    }
+    return parsedItem
+}
+
+// 递归检索前缀树，匹配结果中优先级最高的路由项记录到match中：
+// static表示当前节点是否为精准匹配节点，depth为当前节点的层级，segments为剩余未匹配的层级，values为已匹配的路由参数值；
+// greedy表示当前节点是否位于优先精准匹配的检索路径上，rank为经过当前节点的路由项在该检索路径上最深的链表层级。
+func (s *Server) searchTrieNode(node *routeTrieNode, static bool, depth int, greedy bool, rank int,
+    path string, segments []string, values []string, method string, match *routeTrieMatch) {
+    // 在当前节点产生链表(结束于当前节点，或者下一层级为模糊匹配)的路由项的链表层级
+    nodeRank := rank
+    if greedy {
+        nodeRank = depth
+    }
+    if len(segments) == 0 {
+        // 以命名匹配结束的路由项位于上一层级的链表中
+        if static {
+            s.matchTrieItems(node.items, nodeRank, path, values, method, match)
+        } else {
+            s.matchTrieItems(node.items, rank, path, values, method, match)
+        }
+    } else {
+        child, ok := node.static[segments[0]]
+        if ok {
+            s.searchTrieNode(child, true, depth + 1, greedy, rank, path, segments[1:], values, method, match)
+        }
+        // 命名匹配不能匹配空的层级
+        if node.param != nil && len(segments[0]) > 0 {
+            s.searchTrieNode(node.param, false, depth + 1, greedy && !ok, nodeRank,
+                path, segments[1:], append(values, segments[0]), method, match)
+        }
+    }
+    // 模糊匹配可以匹配零个或者多个层级，例如：/user/*action 匹配 /user
+    if len(node.wildcard) > 0 {
+        s.matchTrieItems(node.wildcard, nodeRank, path, append(values, strings.Join(segments, "/")), method, match)
+    }
+}
+
+// 从按照优先级排序的路由项列表中选择第一个匹配的路由项(匹配HTTP Method，需要正则匹配的路由项同时匹配正则)，
+// 优先级高于当前结果时替换match
+func (s *Server) matchTrieItems(items []*routeTrieItem, rank int, path string, values []string, method string, match *routeTrieMatch) {
+    for _, item := range items {
+        if !s.matchRouterMethod(item.handler.router, method) {
+            continue
+        }
+        if !s.higherTrieMatch(item, rank, match) {
+            return
+        }
+        if item.regex {
+            array, err := gregex.MatchString(item.handler.router.RegRule, path)
+            if err != nil || len(array) == 0 {
+                continue
+            }
+            match.values = array
+        } else {
+            // values的底层数组在递归检索中会被复用，需要复制
+            match.values = append([]string(nil), values...)
+        }
+        match.item = item
+        match.rank = rank
+        return
+    }
+}
+
+// 判断位于rank链表层级的路由项是否优先于当前的检索结果，层级及优先级都相同时保留先检索到的路由项
+func (s *Server) higherTrieMatch(item *routeTrieItem, rank int, match *routeTrieMatch) bool {
+    if match.item == nil || rank > match.rank {
+        return true
+    }
+    if rank < match.rank {
+        return false
+    }
+    newRouter := item.handler.router
+    oldRouter := match.item.handler.router
+    return s.compareRouterPriority(newRouter, oldRouter) && !s.compareRouterPriority(oldRouter, newRouter)
+}
+
+// 判断路由对象是否匹配指定的HTTP Method，按照ALL注册的路由匹配所有的HTTP Method
+func (s *Server) matchRouterMethod(router *Router, method string) bool {
+    return strings.EqualFold(router.Method, gDEFAULT_METHOD) || strings.EqualFold(router.Method, method)
+}
@@ -0,0 +1,198 @@
+// Copyright 2018 gf Author(https://gitee.com/johng/gf). All Rights Reserved.
+//
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT was not distributed with this file,
+// You can obtain one at https://gitee.com/johng/gf.
+
+// go test *.go -bench=".*"
+
+package ghttp
+
+import (
+    "fmt"
+    "net/http"
+    "net/http/httptest"
+    "reflect"
+    "testing"
+)
+
+var testTriePatterns = []string {
+    "/",
+    "/user/:id",
+    "POST:/user/:id",
+    "/user/list",
+    "/user/:id/profile",
+    "/user/list/*any",
+    "/file/*path",
+    "/:lang/docs",
+    "/list/{page}.html",
+    "/api/:",
+    "/names/:name/:name",
+}
+
+var testTriePaths = []string {
+    "/",
+    "/user",
+    "/user/",
+    "/user/1",
+    "/user/list",
+    "/user/1/profile",
+    "/user/list/profile",
+    "/user/list/a/b",
+    "/file",
+    "/file/a/b.txt",
+    "/en/docs",
+    "/list/2.html",
+    "/api/v1",
+    "/names/john/smith",
+    "/none/none",
+}
+
+// 按照路由规则注册测试的服务路由，返回内容为路由规则及路由参数
+func testTrieServer(name string, mode int, patterns []string) *Server {
+    s := testServer(name)
+    s.SetRouterMode(mode)
+    for _, pattern := range patterns {
+        pattern := pattern
+        s.BindHandler(pattern, func(r *Request) {
+            r.Response.Write(pattern, " ", r.GetRouterMap())
+        })
+    }
+    return s
+}
+
+// 比较两个Server对于给定路径的匹配结果(路由项及路由参数)
+func testCompareRouter(t *testing.T, tree, trie *Server, paths []string) {
+    for _, method := range []string{"GET", "POST"} {
+        for _, path := range paths {
+            item1 := tree.searchServeHandler(method, path, "localhost")
+            item2 := trie.searchServeHandler(method, path, "localhost")
+            if (item1 == nil) != (item2 == nil) {
+                t.Errorf("%s %s: tree matched %v, trie matched %v", method, path, item1 != nil, item2 != nil)
+                continue
+            }
+            if item1 == nil {
+                continue
+            }
+            if item1.handler.router.Uri != item2.handler.router.Uri || item1.handler.router.Method != item2.handler.router.Method {
+                t.Errorf("%s %s: tree matched %s:%s, trie matched %s:%s", method, path,
+                    item1.handler.router.Method, item1.handler.router.Uri, item2.handler.router.Method, item2.handler.router.Uri)
+            }
+            if !reflect.DeepEqual(item1.values, item2.values) {
+                t.Errorf("%s %s: tree values %v, trie values %v", method, path, item1.values, item2.values)
+            }
+        }
+    }
+}
+
+// 前缀树检索与哈希表+链表检索的匹配结果(路由项及路由参数)一致
+func TestServer_RouterTrie(t *testing.T) {
+    tree := testTrieServer("TestServer_RouterTrie_Tree", ROUTER_MODE_TREE, testTriePatterns)
+    trie := testTrieServer("TestServer_RouterTrie_Trie", ROUTER_MODE_TRIE, testTriePatterns)
+    // 所有的路由规则都可以使用前缀树表示，保证比较的是前缀树的检索结果
+    if trie.serveTrie[gDEFAULT_DOMAIN].regex {
+        t.Fatal("unexpected fallback to the tree search")
+    }
+    testCompareRouter(t, tree, trie, testTriePaths)
+
+    w := httptest.NewRecorder()
+    trie.handleRequest(w, httptest.NewRequest("GET", "/file/a/b.txt", nil))
+    if body := w.Body.String(); body != `/file/*path {"path":"a/b.txt"}` {
+        t.Errorf(`unexpected response "%s"`, body)
+    }
+    w = httptest.NewRecorder()
+    trie.handleRequest(w, httptest.NewRequest("PUT", "/user/list/profile", nil))
+    if body := w.Body.String(); body != `/user/list/*any {"any":"profile"}` {
+        t.Errorf(`unexpected response "%s"`, body)
+    }
+}
+
+// 注册了无法使用前缀树表示的路由规则(不在末尾层级的*规则)时，该域名使用哈希表+链表路由表检索
+func TestServer_RouterTrie_RegexFallback(t *testing.T) {
+    patterns := []string{ "/order/*any/edit", "/order/:id", "/user/:id" }
+    tree     := testTrieServer("TestServer_RouterTrie_RegexFallback_Tree", ROUTER_MODE_TREE, patterns)
+    trie     := testTrieServer("TestServer_RouterTrie_RegexFallback_Trie", ROUTER_MODE_TRIE, patterns)
+    if !trie.serveTrie[gDEFAULT_DOMAIN].regex {
+        t.Fatal("expected fallback to the tree search")
+    }
+    testCompareRouter(t, tree, trie, []string{ "/order/1/2/edit", "/order/1", "/user/1", "/none" })
+
+    w := httptest.NewRecorder()
+    trie.handleRequest(w, httptest.NewRequest("GET", "/order/1/2/edit", nil))
+    if body := w.Body.String(); body != `/order/*any/edit {"any":"1/2"}` {
+        t.Errorf(`unexpected response "%s"`, body)
+    }
+}
+
+// 前缀树检索方式下控制器同样可以获取路由参数
+func TestServer_RouterTrie_Controller(t *testing.T) {
+    s := testServer("TestServer_RouterTrie_Controller")
+    s.SetRouterMode(ROUTER_MODE_TRIE)
+    if err := s.BindControllerRest("/user/:id", &testUserController{}); err != nil {
+        t.Fatal(err)
+    }
+    w := httptest.NewRecorder()
+    s.handleRequest(w, httptest.NewRequest("PUT", "/user/10", nil))
+    if body := w.Body.String(); body != "put:10" {
+        t.Errorf(`unexpected response "%s"`, body)
+    }
+    w = httptest.NewRecorder()
+    s.handleRequest(w, httptest.NewRequest("TRACE", "/user/10", nil))
+    if w.Code != http.StatusMethodNotAllowed {
+        t.Errorf("expected status code %d, got %d", http.StatusMethodNotAllowed, w.Code)
+    }
+}
+
+// 注册1000个路由规则：每个资源包含精准匹配、命名匹配及模糊匹配的规则，并包含一个位于第一层级的命名匹配规则
+func benchmarkRouterServer(name string, mode int) *Server {
+    s := GetServer(name)
+    s.SetRouterMode(mode)
+    if len(s.routesMap) > 0 {
+        return s
+    }
+    handler := func(r *Request) {}
+    for i := 0; i < 250; i++ {
+        s.BindHandler(fmt.Sprintf("/api/res%d/list", i),        handler)
+        s.BindHandler(fmt.Sprintf("/api/res%d/:id", i),         handler)
+        s.BindHandler(fmt.Sprintf("/api/res%d/:id/*action", i), handler)
+        s.BindHandler(fmt.Sprintf("/:lang/page%d", i),          handler)
+    }
+    return s
+}
+
+func benchmarkRouterSearch(b *testing.B, s *Server, paths []string) {
+    b.ResetTimer()
+    for i := 0; i < b.N; i++ {
+        s.searchServeHandler("GET", paths[i % len(paths)], "localhost")
+    }
+}
+
+var benchmarkRouterPaths = []string {
+    "/api/res10/list",
+    "/api/res120/100",
+    "/api/res249/100/edit/name",
+    "/en/page200",
+}
+
+// 未匹配的请求(404)不会被缓存，每一次请求都会重新检索
+var benchmarkRouterMissPaths = []string {
+    "/api/res10/100/",
+    "/en/none",
+    "/none/none/none",
+}
+
+func BenchmarkRouter_Tree_1k(b *testing.B) {
+    benchmarkRouterSearch(b, benchmarkRouterServer("BenchmarkRouter_Tree", ROUTER_MODE_TREE), benchmarkRouterPaths)
+}
+
+func BenchmarkRouter_Trie_1k(b *testing.B) {
+    benchmarkRouterSearch(b, benchmarkRouterServer("BenchmarkRouter_Trie", ROUTER_MODE_TRIE), benchmarkRouterPaths)
+}
+
+func BenchmarkRouter_Tree_1k_Miss(b *testing.B) {
+    benchmarkRouterSearch(b, benchmarkRouterServer("BenchmarkRouter_Tree", ROUTER_MODE_TREE), benchmarkRouterMissPaths)
+}
+
+func BenchmarkRouter_Trie_1k_Miss(b *testing.B) {
+    benchmarkRouterSearch(b, benchmarkRouterServer("BenchmarkRouter_Trie", ROUTER_MODE_TRIE), benchmarkRouterMissPaths)
+}
@@ -17,6 +17,31 @@ import (
     "gitee.com/johng/gf/g/util/gstr"
 )
 
+// 控制器路由方法支持的定义方式
+const gCONTROLLER_METHOD_TYPES = `"func()", "func() interface{}" or "func() (interface{}, error)"`
+
+var (
+    interfaceType = reflect.TypeOf((*interface{})(nil)).Elem()
+    errorType     = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// 判断控制器方法的定义是否可以作为路由方法：
+// func()、func() interface{}(返回值自动输出)或者func() (interface{}, error)(返回值及错误信息自动输出)
+func isControllerMethod(t reflect.Type) bool {
+    if t.NumIn() > 0 {
+        return false
+    }
+    switch t.NumOut() {
+        case 0:
+            return true
+        case 1:
+            return t.Out(0) == interfaceType
+        case 2:
+            return t.Out(0) == interfaceType && t.Out(1) == errorType
+    }
+    return false
+}
+
 // 绑定控制器，控制器需要实现gmvc.Controller接口
 // 这种方式绑定的控制器每一次请求都会初始化一个新的控制器对象进行处理，对应不同的请求会话
 // 第三个参数methods用以指定需要注册的方法，支持多个方法名称，多个方法以英文“,”号分隔，区分大小写
@@ -43,9 +68,9 @@ func (s *Server)BindController(pattern string, c Controller, methods...string) e
         if mname == "Init" || mname == "Shut" || mname == "Exit"  {
             continue
         }
-        if _, ok := v.Method(i).Interface().(func()); !ok {
+        if !isControllerMethod(v.Method(i).Type()) {
             if methodMap != nil {
-                s := fmt.Sprintf(`invalid medthod definition "%s", while "func()" is required`, v.Method(i).Type().String())
+                s := fmt.Sprintf(`invalid medthod definition "%s", while %s is required`, v.Method(i).Type().String(), gCONTROLLER_METHOD_TYPES)
                 glog.Error(s)
                 return errors.New(s)
             }
@@ -95,8 +120,8 @@ func (s *Server)BindControllerMethod(pattern string, c Controller, method string
     if !fval.IsValid() {
         return errors.New("invalid method name:" + mname)
     }
-    if _, ok := fval.Interface().(func()); !ok {
-        s := fmt.Sprintf(`invalid medthod definition "%s", while "func()" is required`, fval.Type().String())
+    if !isControllerMethod(fval.Type()) {
+        s := fmt.Sprintf(`invalid medthod definition "%s", while %s is required`, fval.Type().String(), gCONTROLLER_METHOD_TYPES)
         glog.Error(s)
         return errors.New(s)
     }
@@ -117,9 +142,110 @@ func (s *Server)BindControllerMethod(pattern string, c Controller, method string
     return s.bindHandlerByMap(m)
 }
 
+// 通过方法映射显式绑定控制器，methodMap的键名为HTTP Method(不区分大小写，ALL表示所有的HTTP Method)，键值为控制器的方法名称(区分大小写)，
+// 例如：map[string]string{"GET" : "List", "POST" : "Create"}，路由方法的定义要求与BindController一致。
+// 显式绑定的路由优先于BindController/BindControllerRest等反射绑定的路由(与注册的先后顺序无关)，
+// 但同一路由规则上的多个显式绑定仍然视为重复注册
+func (s *Server)BindControllerMap(pattern string, c Controller, methodMap map[string]string) error {
+    m       := make(handlerMap)
+    v       := reflect.ValueOf(c)
+    t       := v.Type()
+    pkgPath := t.Elem().PkgPath()
+    pkgName := gfile.Basename(pkgPath)
+    ctlName := gstr.Replace(t.String(), fmt.Sprintf(`%s.`, pkgName), "")
+    if ctlName[0] == '*' {
+        ctlName = fmt.Sprintf(`(%s)`, ctlName)
+    }
+    for method, name := range methodMap {
+        key, err := s.explicitPattern(method, pattern)
+        if err != nil {
+            return err
+        }
+        mname := strings.TrimSpace(name)
+        fval  := v.MethodByName(mname)
+        if !fval.IsValid() {
+            s := fmt.Sprintf(`invalid method name "%s" of controller "%s" for pattern "%s"`, mname, t.String(), pattern)
+            glog.Error(s)
+            return errors.New(s)
+        }
+        if !isControllerMethod(fval.Type()) {
+            s := fmt.Sprintf(`invalid medthod definition "%s.%s %s", while %s is required`, t.String(), mname, fval.Type().String(), gCONTROLLER_METHOD_TYPES)
+            glog.Error(s)
+            return errors.New(s)
+        }
+        m[key] = &handlerItem {
+            name     : fmt.Sprintf(`%s.%s.%s`, pkgPath, ctlName, mname),
+            rtype    : gROUTE_REGISTER_CONTROLLER,
+            ctype    : v.Elem().Type(),
+            fname    : mname,
+            faddr    : nil,
+            explicit : true,
+        }
+    }
+    return s.bindHandlerByMap(m)
+}
+
+// 显式绑定控制器处理函数，每一次请求都会初始化一个新的控制器对象(类型与c一致)，执行Init后将控制器对象传递给handler处理，
+// 不依赖于控制器的方法名称，适用于不符合RESTful命名规则的接口。method为HTTP Method(不区分大小写，ALL表示所有的HTTP Method)，
+// 与BindControllerMap一样，显式绑定的路由优先于反射绑定的路由
+func (s *Server)BindControllerFunc(method, pattern string, c Controller, handler func(c Controller)) error {
+    if handler == nil {
+        return errors.New(fmt.Sprintf(`nil handler for pattern "%s"`, pattern))
+    }
+    key, err := s.explicitPattern(method, pattern)
+    if err != nil {
+        return err
+    }
+    v       := reflect.ValueOf(c)
+    t       := v.Type()
+    pkgPath := t.Elem().PkgPath()
+    pkgName := gfile.Basename(pkgPath)
+    ctlName := gstr.Replace(t.String(), fmt.Sprintf(`%s.`, pkgName), "")
+    if ctlName[0] == '*' {
+        ctlName = fmt.Sprintf(`(%s)`, ctlName)
+    }
+    return s.bindHandlerByMap(handlerMap {
+        key : &handlerItem {
+            name     : fmt.Sprintf(`%s.%s.%s(func)`, pkgPath, ctlName, strings.ToUpper(method)),
+            rtype    : gROUTE_REGISTER_CONTROLLER,
+            ctype    : v.Elem().Type(),
+            faddr    : nil,
+            cfunc    : handler,
+            explicit : true,
+        },
+    })
+}
+
+// 构造显式绑定的路由规则，ALL(或者空)表示所有的HTTP Method，此时不在pattern中增加HTTP Method前缀
+func (s *Server)explicitPattern(method, pattern string) (string, error) {
+    method = strings.ToUpper(strings.TrimSpace(method))
+    if method == "" || method == gDEFAULT_METHOD {
+        return pattern, nil
+    }
+    if _, ok := s.methodsMap[method]; !ok {
+        s := fmt.Sprintf(`invalid HTTP method "%s" for pattern "%s", while one of %s is required`, method, pattern, gHTTP_METHODS)
+        glog.Error(s)
+        return "", errors.New(s)
+    }
+    return method + ":" + pattern, nil
+}
+
 // 绑定控制器(RESTFul)，控制器需要实现gmvc.Controller接口
 // 方法会识别HTTP方法，并做REST绑定处理，例如：Post方法会绑定到HTTP POST的方法请求处理，Delete方法会绑定到HTTP DELETE的方法请求处理
-// 因此只会绑定HTTP Method对应的方法，其他方法不会自动注册绑定
+// 因此只会绑定HTTP Method对应的方法，其他方法不会自动注册绑定，方法定义必须为func()、func() interface{}或者func() (interface{}, error)，
+// 如果控制器没有任何与HTTP Method对应的方法，那么返回错误。
+// 控制器方法与HTTP Method的对应关系如下(方法名称不区分大小写匹配HTTP Method)：
+//     Get     -> GET     (获取资源)
+//     Post    -> POST    (创建资源)
+//     Put     -> PUT     (完整更新资源)
+//     Patch   -> PATCH   (部分更新资源)
+//     Delete  -> DELETE  (删除资源)
+//     Head    -> HEAD    (未定义时自动使用Get方法处理，不输出返回内容)
+//     Options -> OPTIONS (未定义时自动返回Allow头信息，列出该路由支持的HTTP Method，包括其他方式对同一路由注册的HTTP Method)
+//     Connect -> CONNECT
+//     Trace   -> TRACE
+// 例如：BindControllerRest("/user/:id", &User{})，User实现Get/Post/Put/Patch/Delete方法即可得到完整的RESTful资源，
+// 在方法中通过Request.Get("id")获取资源ID；未实现的HTTP Method请求返回405状态码。
 // 这种方式绑定的控制器每一次请求都会初始化一个新的控制器对象进行处理，对应不同的请求会话
 func (s *Server)BindControllerRest(pattern string, c Controller) error {
     // 遍历控制器，获取方法列表，并构造成uri
@@ -127,6 +253,13 @@ func (s *Server)BindControllerRest(pattern string, c Controller) error {
     v       := reflect.ValueOf(c)
     t       := v.Type()
     pkgPath := t.Elem().PkgPath()
+    pkgName := gfile.Basename(pkgPath)
+    ctlName := gstr.Replace(t.String(), fmt.Sprintf(`%s.`, pkgName), "")
+    if ctlName[0] == '*' {
+        ctlName = fmt.Sprintf(`(%s)`, ctlName)
+    }
+    // 控制器实现的HTTP Method(大写)及对应的路由注册项
+    methods := make(map[string]*handlerItem)
     // 如果存在与HttpMethod对应名字的方法，那么绑定这些方法
     for i := 0; i < v.NumMethod(); i++ {
         mname  := t.Method(i).Name
@@ -134,16 +267,11 @@ func (s *Server)BindControllerRest(pattern string, c Controller) error {
         if _, ok := s.methodsMap[method]; !ok {
             continue
         }
-        if _, ok := v.Method(i).Interface().(func()); !ok {
-            s := fmt.Sprintf(`invalid medthod definition "%s", while "func()" is required`, v.Method(i).Type().String())
+        if !isControllerMethod(v.Method(i).Type()) {
+            s := fmt.Sprintf(`invalid medthod definition "%s.%s %s", while %s is required`, t.String(), mname, v.Method(i).Type().String(), gCONTROLLER_METHOD_TYPES)
             glog.Error(s)
             return errors.New(s)
         }
-        pkgName := gfile.Basename(pkgPath)
-        ctlName := gstr.Replace(t.String(), fmt.Sprintf(`%s.`, pkgName), "")
-        if ctlName[0] == '*' {
-            ctlName = fmt.Sprintf(`(%s)`, ctlName)
-        }
         key   := mname + ":" + pattern
         m[key] = &handlerItem {
             name  : fmt.Sprintf(`%s.%s.%s`, pkgPath, ctlName, mname),
@@ -152,6 +280,41 @@ func (s *Server)BindControllerRest(pattern string, c Controller) error {
             fname : mname,
             faddr : nil,
         }
+        methods[method] = m[key]
+    }
+    // 没有任何与HTTP Method对应的方法时，通常是方法定义在了其他类型上(例如嵌入的结构体与接收者不一致)，
+    // 这种情况下路由不会生效，因此需要返回错误，避免静默失败
+    if len(m) == 0 {
+        s := fmt.Sprintf(`no HTTP method (%s) defined on controller "%s" for pattern "%s"`, gHTTP_METHODS, t.String(), pattern)
+        glog.Error(s)
+        return errors.New(s)
+    }
+    // 如果控制器定义了Get方法而没有定义Head方法，那么HEAD请求自动使用Get方法处理(返回时不输出内容，只输出头信息)，
+    // 如果需要自定义HEAD请求的处理，显式定义Head方法即可
+    if _, ok := methods["HEAD"]; !ok && methods["GET"] != nil {
+        item     := *methods["GET"]
+        item.name = fmt.Sprintf(`%s.%s.%s(auto)`, pkgPath, ctlName, "Head")
+        methods["HEAD"]      = &item
+        m["HEAD:" + pattern] = &item
+    }
+    // 如果控制器没有定义Options方法，那么自动生成OPTIONS请求的处理方法，返回该路由支持的HTTP Method列表(常用于CORS预检请求)
+    if _, ok := methods["OPTIONS"]; !ok {
+        m["OPTIONS:" + pattern] = &handlerItem {
+            name  : fmt.Sprintf(`%s.%s.%s(auto)`, pkgPath, ctlName, "Options"),
+            rtype : gROUTE_REGISTER_CONTROLLER,
+            ctype : nil,
+            fname : "",
+            faddr : s.allowMethodsHandler,
+            auto  : true,
+        }
     }
     return s.bindHandlerByMap(m)
 }
+
+// 自动生成的OPTIONS请求的处理方法，通过Allow头信息返回该路由支持的HTTP Method列表。
+// 列表在请求时按照当前的路由表检索，因此包含通过其他方式(例如分组路由)对同一路由注册的HTTP Method，
+// 不包含被过滤方法(BindMethodFilter)过滤的HTTP Method，自动生成的OPTIONS处理方法本身不计入列表。
+func (s *Server) allowMethodsHandler(r *Request) {
+    r.Response.Header().Set("Allow", strings.Join(s.allowedMethods(r), ","))
+    r.Response.Header().Set("Content-Length", "0")
+}
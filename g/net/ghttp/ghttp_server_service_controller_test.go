@@ -0,0 +1,390 @@
+// Copyright 2018 gf Author(https://gitee.com/johng/gf). All Rights Reserved.
+//
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT was not distributed with this file,
+// You can obtain one at https://gitee.com/johng/gf.
+
+// go test *.go -v
+
+package ghttp
+
+import (
+    "fmt"
+    "net/http"
+    "net/http/httptest"
+    "sort"
+    "strings"
+    "testing"
+    "gitee.com/johng/gf/g/os/gtime"
+)
+
+// 获取测试使用的Server，名称带上唯一的后缀，保证重复执行测试(例如-count=2)时不会重复注册路由
+func testServer(name string) *Server {
+    return GetServer(fmt.Sprintf("%s_%d", name, gtime.Nanosecond()))
+}
+
+// 只实现了Get方法的RESTful控制器
+type testGetOnlyController struct {
+    request *Request
+}
+
+func (c *testGetOnlyController) Init(r *Request) {
+    c.request = r
+}
+
+func (c *testGetOnlyController) Shut(r *Request) {
+
+}
+
+func (c *testGetOnlyController) Get() {
+    c.request.Response.Write("get")
+}
+
+// 只实现了Get方法的RESTful控制器收到POST请求时返回405，Allow头信息返回控制器支持的HTTP Method，
+// 并且执行405状态码的处理方法；未注册的路由仍然返回404
+func TestServer_BindControllerRest_MethodNotAllowed(t *testing.T) {
+    s := testServer("TestServer_BindControllerRest_MethodNotAllowed")
+    if err := s.BindControllerRest("/user", &testGetOnlyController{}); err != nil {
+        t.Fatal(err)
+    }
+    s.SetMethodNotAllowedHandler(func(r *Request) {
+        r.Response.Write("method not allowed")
+    })
+
+    w := httptest.NewRecorder()
+    s.handleRequest(w, httptest.NewRequest("GET", "/user", nil))
+    if w.Code != http.StatusOK || w.Body.String() != "get" {
+        t.Errorf("unexpected GET response %d: %s", w.Code, w.Body.String())
+    }
+
+    w = httptest.NewRecorder()
+    s.handleRequest(w, httptest.NewRequest("POST", "/user", nil))
+    if w.Code != http.StatusMethodNotAllowed {
+        t.Errorf("expected status code %d, got %d", http.StatusMethodNotAllowed, w.Code)
+    }
+    if allow := w.Header().Get("Allow"); allow != "GET,HEAD" {
+        t.Errorf(`expected Allow header "GET,HEAD", got "%s"`, allow)
+    }
+    if w.Body.String() != "method not allowed" {
+        t.Errorf(`expected body from the 405 handler, got "%s"`, w.Body.String())
+    }
+
+    w = httptest.NewRecorder()
+    s.handleRequest(w, httptest.NewRequest("POST", "/none", nil))
+    if w.Code != http.StatusNotFound {
+        t.Errorf("expected status code %d, got %d", http.StatusNotFound, w.Code)
+    }
+    if allow := w.Header().Get("Allow"); allow != "" {
+        t.Errorf(`expected no Allow header, got "%s"`, allow)
+    }
+}
+
+// 路由的所有HTTP Method均被过滤时，已注册的HTTP Method(包括自动生成的OPTIONS)的请求都返回405，执行405状态码的处理方法，
+// 并且不返回空的Allow头信息；未注册的路由仍然返回404
+func TestServer_BindControllerRest_AllMethodsFiltered(t *testing.T) {
+    s := testServer("TestServer_BindControllerRest_AllMethodsFiltered")
+    if err := s.BindControllerRest("/user/:id", &testUserController{}); err != nil {
+        t.Fatal(err)
+    }
+    s.BindMethodFilter("/user/*", func(r *Request, method string) bool {
+        return false
+    })
+    s.SetMethodNotAllowedHandler(func(r *Request) {
+        r.Response.Write("method not allowed")
+    })
+
+    for _, method := range []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"} {
+        w := httptest.NewRecorder()
+        s.handleRequest(w, httptest.NewRequest(method, "/user/10", nil))
+        if w.Code != http.StatusMethodNotAllowed {
+            t.Errorf("%s: expected status code %d, got %d", method, http.StatusMethodNotAllowed, w.Code)
+        }
+        if allow, ok := w.Header()["Allow"]; ok {
+            t.Errorf(`%s: unexpected Allow header %q`, method, allow)
+        }
+        if method != "HEAD" && w.Body.String() != "method not allowed" {
+            t.Errorf(`%s: expected body from the 405 handler, got "%s"`, method, w.Body.String())
+        }
+    }
+
+    w := httptest.NewRecorder()
+    s.handleRequest(w, httptest.NewRequest("GET", "/none", nil))
+    if w.Code != http.StatusNotFound {
+        t.Errorf("expected status code %d, got %d", http.StatusNotFound, w.Code)
+    }
+}
+
+// 实现了Get/Post/Put/Patch/Delete方法的RESTful资源控制器
+type testUserController struct {
+    request *Request
+}
+
+func (c *testUserController) Init(r *Request) {
+    c.request = r
+}
+
+func (c *testUserController) Shut(r *Request) {
+
+}
+
+func (c *testUserController) Get() {
+    c.request.Response.Write("get:" + c.request.Get("id"))
+}
+
+func (c *testUserController) Post() {
+    c.request.Response.Write("post:" + c.request.Get("id"))
+}
+
+func (c *testUserController) Put() {
+    c.request.Response.Write("put:" + c.request.Get("id"))
+}
+
+func (c *testUserController) Patch() {
+    c.request.Response.Write("patch:" + c.request.Get("id"))
+}
+
+func (c *testUserController) Delete() {
+    c.request.Response.Write("delete:" + c.request.Get("id"))
+}
+
+// 同一个控制器的Get/Post/Put/Patch/Delete方法分别处理对应HTTP Method的请求，HEAD及OPTIONS请求自动处理，
+// 其他HTTP Method返回405
+func TestServer_BindControllerRest_AllVerbs(t *testing.T) {
+    s := testServer("TestServer_BindControllerRest_AllVerbs")
+    if err := s.BindControllerRest("/user/:id", &testUserController{}); err != nil {
+        t.Fatal(err)
+    }
+    for _, method := range []string{"GET", "POST", "PUT", "PATCH", "DELETE"} {
+        w := httptest.NewRecorder()
+        s.handleRequest(w, httptest.NewRequest(method, "/user/10", nil))
+        expect := strings.ToLower(method) + ":10"
+        if w.Code != http.StatusOK || w.Body.String() != expect {
+            t.Errorf(`unexpected %s response %d: "%s", expected "%s"`, method, w.Code, w.Body.String(), expect)
+        }
+    }
+
+    w := httptest.NewRecorder()
+    s.handleRequest(w, httptest.NewRequest("HEAD", "/user/10", nil))
+    if w.Code != http.StatusOK || w.Body.Len() != 0 {
+        t.Errorf(`unexpected HEAD response %d: "%s"`, w.Code, w.Body.String())
+    }
+
+    w = httptest.NewRecorder()
+    s.handleRequest(w, httptest.NewRequest("OPTIONS", "/user/10", nil))
+    if allow := w.Header().Get("Allow"); allow != "DELETE,GET,HEAD,PATCH,POST,PUT" {
+        t.Errorf(`unexpected OPTIONS Allow header "%s"`, allow)
+    }
+
+    w = httptest.NewRecorder()
+    s.handleRequest(w, httptest.NewRequest("TRACE", "/user/10", nil))
+    if w.Code != http.StatusMethodNotAllowed {
+        t.Errorf("expected status code %d for TRACE, got %d", http.StatusMethodNotAllowed, w.Code)
+    }
+}
+
+// 自动生成的OPTIONS处理方法在请求时检索路由表，包含分组路由对同一路由注册的HTTP Method，
+// 并且不在Allow头信息中列出OPTIONS本身；显式注册了OPTIONS处理方法的路由才会列出OPTIONS
+func TestServer_BindControllerRest_OptionsWithGroup(t *testing.T) {
+    s := testServer("TestServer_BindControllerRest_OptionsWithGroup")
+    if err := s.BindControllerRest("/api/user/:id", &testGetOnlyController{}); err != nil {
+        t.Fatal(err)
+    }
+    group := s.Group("/api")
+    group.BindHandler("PUT:/user/:id", func(r *Request) {
+        r.Response.Write("put:" + r.Get("id"))
+    })
+    group.BindHandler("GET:/ping", func(r *Request) {
+        r.Response.Write("pong")
+    })
+    group.BindHandler("OPTIONS:/ping", func(r *Request) {
+        r.Response.Header().Set("Allow", "GET,OPTIONS")
+    })
+
+    w := httptest.NewRecorder()
+    s.handleRequest(w, httptest.NewRequest("OPTIONS", "/api/user/10", nil))
+    if w.Code != http.StatusOK {
+        t.Errorf("unexpected OPTIONS status code %d", w.Code)
+    }
+    if allow := w.Header().Get("Allow"); allow != "GET,HEAD,PUT" {
+        t.Errorf(`unexpected OPTIONS Allow header "%s"`, allow)
+    }
+
+    w = httptest.NewRecorder()
+    s.handleRequest(w, httptest.NewRequest("PUT", "/api/user/10", nil))
+    if w.Code != http.StatusOK || w.Body.String() != "put:10" {
+        t.Errorf(`unexpected PUT response %d: "%s"`, w.Code, w.Body.String())
+    }
+
+    w = httptest.NewRecorder()
+    s.handleRequest(w, httptest.NewRequest("DELETE", "/api/user/10", nil))
+    if w.Code != http.StatusMethodNotAllowed {
+        t.Errorf("expected status code %d, got %d", http.StatusMethodNotAllowed, w.Code)
+    }
+    if allow := w.Header().Get("Allow"); allow != "GET,HEAD,PUT" {
+        t.Errorf(`unexpected 405 Allow header "%s"`, allow)
+    }
+
+    w = httptest.NewRecorder()
+    s.handleRequest(w, httptest.NewRequest("POST", "/api/ping", nil))
+    if allow := w.Header().Get("Allow"); allow != "GET,OPTIONS" {
+        t.Errorf(`unexpected 405 Allow header "%s" for the route implementing OPTIONS`, allow)
+    }
+}
+
+// 遍历一次路由表检索的HTTP Method列表与按照每个HTTP Method分别检索的结果一致
+func TestServer_SearchAllowedMethods(t *testing.T) {
+    s := testServer("TestServer_SearchAllowedMethods")
+    for _, pattern := range append(testTriePatterns, "PUT:/user/:id", "DELETE:/file/*path", "/order/*any/edit") {
+        s.BindHandler(pattern, func(r *Request) {})
+    }
+    if err := s.BindControllerRest("/rest/:id", &testGetOnlyController{}); err != nil {
+        t.Fatal(err)
+    }
+    for _, path := range append(testTriePaths, "/order/1/2/edit", "/rest/1") {
+        expect := make([]string, 0)
+        for _, method := range strings.Split(gHTTP_METHODS, ",") {
+            if item := s.searchServeHandler(method, path, "localhost"); item != nil && !item.handler.auto {
+                expect = append(expect, method)
+            }
+        }
+        sort.Strings(expect)
+        if methods := s.searchAllowedMethods(path, "localhost"); strings.Join(methods, ",") != strings.Join(expect, ",") {
+            t.Errorf(`%s: unexpected allowed methods %v, expected %v`, path, methods, expect)
+        }
+    }
+}
+
+// 事件回调中产生的panic返回500状态码，并且返回内容会输出到客户端
+func TestServer_HookPanic(t *testing.T) {
+    s := testServer("TestServer_HookPanic")
+    s.BindHandler("/", func(r *Request) {
+        r.Response.Write("index")
+    })
+    s.BindHookHandler("/", HOOK_BEFORE_SERVE, func(r *Request) {
+        panic("hook panic")
+    })
+    w := httptest.NewRecorder()
+    s.handleRequest(w, httptest.NewRequest("GET", "/", nil))
+    if w.Code != http.StatusInternalServerError || w.Body.String() != http.StatusText(http.StatusInternalServerError) {
+        t.Errorf(`unexpected response %d: "%s"`, w.Code, w.Body.String())
+    }
+}
+
+// 分组路由注册的PUT被过滤方法按照请求的凭证过滤时，OPTIONS及405响应的Allow头信息不包含PUT，PUT请求返回405且不执行路由方法；
+// 请求携带凭证时正常列出并执行PUT
+func TestServer_BindMethodFilter_GroupPut(t *testing.T) {
+    s := testServer("TestServer_BindMethodFilter_GroupPut")
+    if err := s.BindControllerRest("/api/user/:id", &testGetOnlyController{}); err != nil {
+        t.Fatal(err)
+    }
+    group := s.Group("/api")
+    group.BindHandler("PUT:/user/:id", func(r *Request) {
+        r.Response.Write("put:" + r.Get("id"))
+    })
+    group.BindMethodFilter("/*", func(r *Request, method string) bool {
+        return method != "PUT" || r.Header.Get("X-Token") == "admin"
+    })
+
+    for token, expect := range map[string]string { "" : "GET,HEAD", "admin" : "GET,HEAD,PUT" } {
+        w := httptest.NewRecorder()
+        r := httptest.NewRequest("OPTIONS", "/api/user/10", nil)
+        r.Header.Set("X-Token", token)
+        s.handleRequest(w, r)
+        if allow := w.Header().Get("Allow"); allow != expect {
+            t.Errorf(`token "%s": unexpected OPTIONS Allow header "%s", expected "%s"`, token, allow, expect)
+        }
+        w = httptest.NewRecorder()
+        r = httptest.NewRequest("DELETE", "/api/user/10", nil)
+        r.Header.Set("X-Token", token)
+        s.handleRequest(w, r)
+        if allow := w.Header().Get("Allow"); w.Code != http.StatusMethodNotAllowed || allow != expect {
+            t.Errorf(`token "%s": unexpected 405 response %d with Allow header "%s"`, token, w.Code, allow)
+        }
+    }
+
+    w := httptest.NewRecorder()
+    s.handleRequest(w, httptest.NewRequest("PUT", "/api/user/10", nil))
+    if w.Code != http.StatusMethodNotAllowed || strings.Contains(w.Body.String(), "put:") {
+        t.Errorf(`unexpected filtered PUT response %d: "%s"`, w.Code, w.Body.String())
+    }
+    if allow := w.Header().Get("Allow"); allow != "GET,HEAD" {
+        t.Errorf(`unexpected Allow header "%s" for the filtered PUT`, allow)
+    }
+    w = httptest.NewRecorder()
+    r := httptest.NewRequest("PUT", "/api/user/10", nil)
+    r.Header.Set("X-Token", "admin")
+    s.handleRequest(w, r)
+    if w.Code != http.StatusOK || w.Body.String() != "put:10" {
+        t.Errorf(`unexpected PUT response %d: "%s"`, w.Code, w.Body.String())
+    }
+}
+
+// 路由唯一的HTTP Method被过滤时返回405，并且不返回空的Allow头信息
+func TestServer_BindMethodFilter_DeniedOnly(t *testing.T) {
+    s := testServer("TestServer_BindMethodFilter_DeniedOnly")
+    s.BindHandler("POST:/api/order", func(r *Request) {
+        r.Response.Write("order")
+    })
+    s.BindMethodFilter("/api/*", func(r *Request, method string) bool {
+        return r.Header.Get("X-Token") == "admin"
+    })
+
+    w := httptest.NewRecorder()
+    s.handleRequest(w, httptest.NewRequest("POST", "/api/order", nil))
+    if w.Code != http.StatusMethodNotAllowed || strings.Contains(w.Body.String(), "order") {
+        t.Errorf(`unexpected filtered POST response %d: "%s"`, w.Code, w.Body.String())
+    }
+    if allow, ok := w.Header()["Allow"]; ok {
+        t.Errorf(`unexpected Allow header %q for the filtered POST`, allow)
+    }
+    w = httptest.NewRecorder()
+    r := httptest.NewRequest("POST", "/api/order", nil)
+    r.Header.Set("X-Token", "admin")
+    s.handleRequest(w, r)
+    if w.Code != http.StatusOK || w.Body.String() != "order" {
+        t.Errorf(`unexpected POST response %d: "%s"`, w.Code, w.Body.String())
+    }
+}
+
+// 分组中间件按照注册顺序在外层先执行，对注册前后的路由均生效；注册后修改传入的中间件列表不影响已注册的中间件
+func TestServer_RouterGroupUse(t *testing.T) {
+    s     := testServer("TestServer_RouterGroupUse")
+    group := s.Group("/api")
+    group.BindHandler("/before", func(r *Request) {
+        r.Response.Write("before")
+    })
+    mark := func(name string) Middleware {
+        return func(handler HandlerFunc) HandlerFunc {
+            return func(r *Request) {
+                r.Response.Write(name + ">")
+                handler(r)
+            }
+        }
+    }
+    middleware := []Middleware{ mark("m1") }
+    if err := group.Use(middleware...); err != nil {
+        t.Fatal(err)
+    }
+    middleware[0] = mark("changed")
+    if err := group.Use(mark("m2")); err != nil {
+        t.Fatal(err)
+    }
+    group.BindHandler("/after", func(r *Request) {
+        r.Response.Write("after")
+    })
+    s.BindHandler("/other", func(r *Request) {
+        r.Response.Write("other")
+    })
+
+    for path, expect := range map[string]string {
+        "/api/before" : "m1>m2>before",
+        "/api/after"  : "m1>m2>after",
+        "/other"      : "other",
+    } {
+        w := httptest.NewRecorder()
+        s.handleRequest(w, httptest.NewRequest("GET", path, nil))
+        if w.Body.String() != expect {
+            t.Errorf(`%s: unexpected response "%s", expected "%s"`, path, w.Body.String(), expect)
+        }
+    }
+}
@@ -8,9 +8,13 @@
 package ghttp
 
 import (
+    "crypto/rand"
+    "encoding/hex"
+    "fmt"
     "sync"
     "strconv"
     "strings"
+    "gitee.com/johng/gf/g/os/glog"
     "gitee.com/johng/gf/g/os/gtime"
     "gitee.com/johng/gf/g/util/grand"
     "gitee.com/johng/gf/g/util/gconv"
@@ -18,47 +22,114 @@ import (
     "time"
 )
 
-// 单个session对象
+// 单个session对象，Session数据在第一次访问时从存储中加载(懒加载)，在请求结束输出之前保存到存储中；
+// 只有在写入Session数据(或者获取SessionId)时才会创建Session并通过Cookie下发SessionId，只读访问不会创建Session。
 type Session struct {
-    mu     sync.RWMutex             // 并发安全互斥锁
-    id     string                   // SessionId
-    data   *gmap.StringInterfaceMap // Session数据
-    server *Server                  // 所属Server
+    mu      sync.RWMutex             // 并发安全互斥锁
+    id      string                   // SessionId(Session尚未创建时为空)
+    data    *gmap.StringInterfaceMap // Session数据(尚未加载时为nil)
+    server  *Server                  // 所属Server
+    request *Request                 // 所属HTTP请求对象
 }
 
-// 生成一个唯一的sessionid字符串，长度16
+// 生成一个唯一的sessionid字符串，使用安全随机数生成，长度32
 func makeSessionId() string {
-    return strings.ToUpper(strconv.FormatInt(gtime.Nanosecond(), 32) + grand.RandStr(3))
+    b := make([]byte, 16)
+    if _, err := rand.Read(b); err != nil {
+        return strings.ToUpper(strconv.FormatInt(gtime.Nanosecond(), 32) + grand.RandStr(19))
+    }
+    return strings.ToUpper(hex.EncodeToString(b))
 }
 
 // 获取或者生成一个session对象
 func GetSession(r *Request) *Session {
-    s   := r.Server
-    sid := r.Cookie.SessionId()
-    if r := s.sessions.Get(sid); r != nil {
-        return r.(*Session)
+    if r.Session != nil {
+        return r.Session
+    }
+    return &Session {
+        server  : r.Server,
+        request : r,
+    }
+}
+
+// 加载Session数据：请求中带有SessionId并且存储中存在该Session时加载其数据，
+// 不存在的SessionId(例如已过期或者伪造的SessionId)不会被使用，写入数据时将会生成新的SessionId
+func (s *Session) init() {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    if s.data != nil {
+        return
     }
-    ses := &Session {
-        id     : sid,
-        data   : gmap.NewStringInterfaceMap(),
-        server : s,
+    s.data = gmap.NewStringInterfaceMap()
+    if id := s.request.Cookie.Get(s.server.GetSessionIdName()); id != "" {
+        if data, err := s.server.sessionStorage.Get(id); err != nil {
+            glog.Error(fmt.Sprintf(`ghttp: get session "%s" failed: %s`, id, err.Error()))
+        } else if data != nil {
+            s.id = id
+            s.data.BatchSet(data)
+        }
     }
-    s.sessions.Set(sid, ses, s.GetSessionMaxAge())
-    return ses
 }
 
-// 获取sessionid
+// 加载Session数据，Session不存在时创建Session并通过Cookie下发SessionId
+func (s *Session) start() {
+    s.init()
+    s.mu.Lock()
+    if s.id == "" {
+        s.id = makeSessionId()
+        s.request.Cookie.SetSessionId(s.id)
+    }
+    s.mu.Unlock()
+}
+
+// 获取sessionid，Session不存在时会创建Session
 func (s *Session) Id() string {
+    s.start()
+    s.mu.RLock()
+    defer s.mu.RUnlock()
     return s.id
 }
 
+// 重新生成SessionId(保留Session数据)并返回新的SessionId，原有的SessionId失效，
+// 用于登录等权限变化的场景，防止会话固定攻击(session fixation)
+func (s *Session) RegenerateId() string {
+    s.start()
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    if err := s.server.sessionStorage.Remove(s.id); err != nil {
+        glog.Error(fmt.Sprintf(`ghttp: remove session "%s" failed: %s`, s.id, err.Error()))
+    }
+    s.id = makeSessionId()
+    s.request.Cookie.SetSessionId(s.id)
+    return s.id
+}
+
+// 销毁Session(删除存储中的数据并使客户端的SessionId失效)，用于注销登录等场景，
+// 销毁后再次写入数据时会创建新的Session
+func (s *Session) Destroy() {
+    s.init()
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    if s.id == "" {
+        return
+    }
+    if err := s.server.sessionStorage.Remove(s.id); err != nil {
+        glog.Error(fmt.Sprintf(`ghttp: remove session "%s" failed: %s`, s.id, err.Error()))
+    }
+    s.id = ""
+    s.data.Clear()
+    s.request.Cookie.Remove(s.server.GetSessionIdName(), s.request.Cookie.domain, s.request.Cookie.path)
+}
+
 // 获取当前session所有数据
 func (s *Session) Data () map[string]interface{} {
+    s.init()
     return s.data.Clone()
 }
 
 // 设置session
 func (s *Session) Set (key string, value interface{}) {
+    s.start()
     s.data.Set(key, value)
 }
 
@@ -69,16 +140,18 @@ func (s *Session) Sets (m map[string]interface{}) {
 
 // 批量设置
 func (s *Session) BatchSet (m map[string]interface{}) {
+    s.start()
     s.data.BatchSet(m)
 }
 
 // 判断键名是否存在
 func (s *Session) Contains (key string) bool {
+    s.init()
     return s.data.Contains(key)
 }
 
 // 获取session
-func (s *Session) Get (key string) interface{}  { return s.data.Get(key)          }
+func (s *Session) Get (key string) interface{}  { s.init(); return s.data.Get(key) }
 func (s *Session) GetString (key string) string { return gconv.String(s.Get(key)) }
 func (s *Session) GetBool(key string) bool      { return gconv.Bool(s.Get(key))   }
 
@@ -113,15 +186,26 @@ func (s *Session) GetStruct(key string, objPointer interface{}, attrMapping...ma
 
 // 删除session
 func (s *Session) Remove (key string) {
+    s.init()
     s.data.Remove(key)
 }
 
 // 清空session
 func (s *Session) Clear () {
+    s.init()
     s.data.Clear()
 }
 
-// 更新过期时间(如果用在守护进程中长期使用，需要手动调用进行更新，防止超时被清除)
+// 保存Session数据到存储中并更新过期时间，请求结束输出之前会自动调用；
+// 如果在请求结束之后(例如守护进程中)继续使用Session，需要手动调用进行保存。
+// Session没有被访问或者不存在时不执行任何操作。
 func (s *Session) UpdateExpire() {
-    s.server.sessions.Set(s.id, s, s.server.GetSessionMaxAge()*1000)
+    s.mu.RLock()
+    defer s.mu.RUnlock()
+    if s.id == "" || s.data == nil {
+        return
+    }
+    if err := s.server.sessionStorage.Set(s.id, s.data.Clone(), s.server.GetSessionMaxAge()); err != nil {
+        glog.Error(fmt.Sprintf(`ghttp: save session "%s" failed: %s`, s.id, err.Error()))
+    }
 }
\ No newline at end of file
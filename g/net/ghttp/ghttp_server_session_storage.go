@@ -0,0 +1,55 @@
+// Copyright 2018 gf Author(https://gitee.com/johng/gf). All Rights Reserved.
+//
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT was not distributed with this file,
+// You can obtain one at https://gitee.com/johng/gf.
+// Session存储接口及默认的内存存储实现.
+
+package ghttp
+
+import (
+    "gitee.com/johng/gf/g/os/gcache"
+)
+
+// Session存储接口，可以通过Server.SetSessionStorage设置自定义的存储(例如Redis、文件)，默认使用内存存储。
+// Session数据在请求中第一次访问时通过Get加载，在请求结束输出之前通过Set保存，存储的实现需要保证并发安全。
+type SessionStorage interface {
+    // 获取指定SessionId的Session数据，Session不存在(或者已过期)时返回nil
+    Get(id string) (map[string]interface{}, error)
+    // 保存指定SessionId的Session数据，maxAge为Session的有效期(秒)
+    Set(id string, data map[string]interface{}, maxAge int) error
+    // 删除指定SessionId的Session数据
+    Remove(id string) error
+}
+
+// 默认的Session内存存储
+type sessionStorageMemory struct {
+    cache *gcache.Cache
+}
+
+// 创建一个Session内存存储对象，Session数据保存在当前进程的内存中，进程重启后失效
+func NewSessionStorageMemory() SessionStorage {
+    return &sessionStorageMemory {
+        cache : gcache.New(),
+    }
+}
+
+// 获取Session数据，保存时传入的已经是数据的副本，因此这里不再复制
+func (m *sessionStorageMemory) Get(id string) (map[string]interface{}, error) {
+    if v := m.cache.Get(id); v != nil {
+        return v.(map[string]interface{}), nil
+    }
+    return nil, nil
+}
+
+// 保存Session数据，gcache的过期时间单位为毫秒
+func (m *sessionStorageMemory) Set(id string, data map[string]interface{}, maxAge int) error {
+    m.cache.Set(id, data, maxAge*1000)
+    return nil
+}
+
+// 删除Session数据
+func (m *sessionStorageMemory) Remove(id string) error {
+    m.cache.Remove(id)
+    return nil
+}
@@ -0,0 +1,124 @@
+// Copyright 2018 gf Author(https://gitee.com/johng/gf). All Rights Reserved.
+//
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT was not distributed with this file,
+// You can obtain one at https://gitee.com/johng/gf.
+// 静态目录映射(URI前缀 => 本地目录).
+
+package ghttp
+
+import (
+    "errors"
+    "fmt"
+    "path"
+    "sort"
+    "strings"
+    "gitee.com/johng/gf/g/os/gfile"
+    "gitee.com/johng/gf/g/os/glog"
+    "gitee.com/johng/gf/g/os/gfsnotify"
+    "gitee.com/johng/gf/g/container/gmap"
+)
+
+// 静态目录映射项
+type staticPathItem struct {
+    prefix  string                // URI前缀(以"/"开头，不以"/"结尾，根前缀为空)
+    path    string                // 本地目录绝对路径
+    listDir bool                  // 访问目录(且目录下没有默认访问文件)时是否显示目录列表
+    cache   *gmap.StringStringMap // 清理后的相对路径与检索到的文件绝对路径的缓存
+    watch   *gfsnotify.Callback   // 开发环境下监控静态目录的回调对象，重复设置同一前缀时移除
+}
+
+// 设置静态目录映射，将URI前缀prefix下的请求映射到本地目录path中的文件，例如：SetStaticPath("/assets", "./dist")，
+// 请求/assets/js/app.js返回目录dist/js/app.js文件内容；path可以为绝对路径，也可以为相对于当前工作目录的路径。
+// 同一URI前缀重复设置时覆盖之前的设置，多个前缀同时匹配时优先使用较长的前缀。
+// 可选参数listDir表示访问目录(且目录下没有IndexFiles默认访问文件)时是否显示目录列表，默认不显示(返回403)。
+// 静态文件模糊匹配的路由规则之前检索，但精确匹配(规则中不包含变量及模糊匹配)的路由仍然优先于静态文件；
+// 静态文件服务支持If-Modified-Since/If-None-Match(ETag)缓存校验以及Range分段请求。
+// 开发环境下(可以检索到main包源码目录)会监控静态目录的文件变化，文件变化时自动清空检索缓存，重复设置同一前缀时移除之前的监控。
+func (s *Server) SetStaticPath(prefix string, path string, listDir...bool) error {
+    realPath := gfile.RealPath(path)
+    if realPath == "" || !gfile.IsDir(realPath) {
+        return errors.New(fmt.Sprintf(`invalid static path "%s": directory does not exist`, path))
+    }
+    item := &staticPathItem {
+        prefix  : joinGroupPrefix("", prefix),
+        path    : strings.TrimRight(realPath, gfile.Separator),
+        listDir : len(listDir) > 0 && listDir[0],
+        cache   : gmap.NewStringStringMap(),
+    }
+    // 开发环境下监控静态目录，文件变化时清空检索缓存
+    if p := gfile.MainPkgPath(); p != "" {
+        callback, err := gfsnotify.Add(item.path, func(event *gfsnotify.Event) {
+            item.cache.Clear()
+        }, true)
+        if err != nil {
+            glog.Warning("ghttp.SetStaticPath watch failed:", err.Error())
+        }
+        item.watch = callback
+    }
+    s.staticMu.Lock()
+    var replaced *staticPathItem
+    for i, v := range s.staticPaths {
+        if v.prefix == item.prefix {
+            s.staticPaths[i] = item
+            replaced         = v
+            break
+        }
+    }
+    if replaced == nil {
+        s.staticPaths = append(s.staticPaths, item)
+    }
+    sort.SliceStable(s.staticPaths, func(i, j int) bool {
+        return len(s.staticPaths[i].prefix) > len(s.staticPaths[j].prefix)
+    })
+    s.staticMu.Unlock()
+    if replaced != nil && replaced.watch != nil {
+        if err := gfsnotify.RemoveCallback(replaced.watch); err != nil {
+            glog.Warning("ghttp.SetStaticPath remove watch failed:", err.Error())
+        }
+    }
+    glog.Debug("ghttp.SetStaticPath:", item.prefix + "/", "=>", item.path)
+    return nil
+}
+
+// 根据请求URI检索静态目录映射中的文件，返回文件(或者目录)绝对路径及对应的映射项，找不到时返回空字符串
+func (s *Server) searchStaticPath(uri string) (string, *staticPathItem) {
+    s.staticMu.RLock()
+    defer s.staticMu.RUnlock()
+    for _, item := range s.staticPaths {
+        if item.prefix != "" && uri != item.prefix && !strings.HasPrefix(uri, item.prefix + "/") {
+            continue
+        }
+        // path.Clean可以保证检索路径不会超出静态目录(例如：/assets/../../etc/passwd)，
+        // 缓存同样使用清理后的相对路径作为键名，写法不同但指向同一文件的URI共用一个缓存项
+        relative := path.Clean("/" + strings.TrimPrefix(uri, item.prefix))
+        if filePath := item.cache.Get(relative); filePath != "" {
+            // 非开发环境下没有文件监控，需要校验缓存的文件是否仍然存在
+            if gfile.Exists(filePath) {
+                return filePath, item
+            }
+            item.cache.Remove(relative)
+        }
+        filePath := gfile.RealPath(item.path + gfile.Separator + strings.TrimLeft(relative, "/"))
+        if filePath == "" || (filePath != item.path && !strings.HasPrefix(filePath, item.path + gfile.Separator)) {
+            continue
+        }
+        // 目录优先检索默认访问文件
+        if gfile.IsDir(filePath) {
+            for _, file := range s.config.IndexFiles {
+                if index := filePath + gfile.Separator + file; gfile.IsFile(index) {
+                    filePath = index
+                    break
+                }
+            }
+        }
+        item.cache.Set(relative, filePath)
+        return filePath, item
+    }
+    return "", nil
+}
+
+// 判断路由项是否为精确匹配的路由(规则中不包含变量及模糊匹配)
+func isExactRouter(router *Router) bool {
+    return router != nil && !strings.ContainsAny(router.Uri, ":*{")
+}
@@ -0,0 +1,66 @@
+// Copyright 2018 gf Author(https://gitee.com/johng/gf). All Rights Reserved.
+//
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT was not distributed with this file,
+// You can obtain one at https://gitee.com/johng/gf.
+
+// go test *.go -v
+
+package ghttp
+
+import (
+    "os"
+    "path/filepath"
+    "testing"
+    "gitee.com/johng/gf/g/os/gfsnotify"
+)
+
+// 写法不同但指向同一文件的URI共用一个检索缓存项
+func TestServer_StaticPathCacheKey(t *testing.T) {
+    dir := t.TempDir()
+    if err := os.WriteFile(filepath.Join(dir, "app.js"), []byte("app"), 0644); err != nil {
+        t.Fatal(err)
+    }
+    s := testServer("TestServer_StaticPathCacheKey")
+    if err := s.SetStaticPath("/assets", dir); err != nil {
+        t.Fatal(err)
+    }
+    for _, uri := range []string{ "/assets/app.js", "/assets//app.js", "/assets/./app.js", "/assets/js/../app.js" } {
+        if filePath, _ := s.searchStaticPath(uri); filepath.Base(filePath) != "app.js" {
+            t.Errorf(`%s: unexpected file path "%s"`, uri, filePath)
+        }
+    }
+    if size := s.staticPaths[0].cache.Size(); size != 1 {
+        t.Errorf("unexpected cache size %d, expected 1", size)
+    }
+}
+
+// 重复设置同一前缀时移除之前设置的目录监控(测试环境下不一定能检索到main包源码目录，这里手动为映射项添加监控)
+func TestServer_StaticPathRewatch(t *testing.T) {
+    s := testServer("TestServer_StaticPathRewatch")
+    if err := s.SetStaticPath("/assets", t.TempDir()); err != nil {
+        t.Fatal(err)
+    }
+    old := s.staticPaths[0]
+    if old.watch == nil {
+        callback, err := gfsnotify.Add(old.path, func(event *gfsnotify.Event) {
+            old.cache.Clear()
+        }, true)
+        if err != nil {
+            t.Fatal(err)
+        }
+        old.watch = callback
+    }
+    if err := s.SetStaticPath("/assets", t.TempDir()); err != nil {
+        t.Fatal(err)
+    }
+    if len(s.staticPaths) != 1 || s.staticPaths[0] == old {
+        t.Fatal("unexpected static paths after replacing the prefix")
+    }
+    if err := gfsnotify.RemoveCallbackById(old.watch.Id); err == nil {
+        t.Error("the watch of the replaced static path was not removed")
+    }
+    if watch := s.staticPaths[0].watch; watch != nil {
+        gfsnotify.RemoveCallback(watch)
+    }
+}
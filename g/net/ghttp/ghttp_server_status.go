@@ -9,6 +9,7 @@ package ghttp
 
 import (
     "fmt"
+    "net/http"
 )
 
 // 查询状态码回调函数
@@ -47,4 +48,45 @@ func (s *Server)BindStatusHandlerByMap(handlerMap map[int]HandlerFunc) {
     for k, v := range handlerMap {
         s.BindStatusHandler(k, v)
     }
+}
+
+// 绑定路由不存在(404)时的处理方法，同BindStatusHandler(http.StatusNotFound, handler)
+func (s *Server)SetNotFoundHandler(handler HandlerFunc) {
+    s.BindStatusHandler(http.StatusNotFound, handler)
+}
+
+// 绑定路由存在但不支持请求的HTTP Method(405)时的处理方法，同BindStatusHandler(http.StatusMethodNotAllowed, handler)
+func (s *Server)SetMethodNotAllowedHandler(handler HandlerFunc) {
+    s.BindStatusHandler(http.StatusMethodNotAllowed, handler)
+}
+
+// 绑定路由方法(包括中间件)产生panic时的处理方法，可以根据错误信息err输出自定义的错误页面，
+// 处理方法没有设置状态码时返回500状态码；未设置处理方法时返回500状态码(可以通过BindStatusHandler自定义500页面)。
+// panic在每个请求内部被恢复并记录到错误日志中，不会影响其他请求及Server的运行。
+func (s *Server)SetPanicHandler(handler PanicHandler) {
+    s.panicHandler = handler
+}
+
+// 处理路由方法产生的panic：清空已输出到缓冲区的内容，记录错误日志并输出错误页面
+func (s *Server)handlePanic(r *Request, err interface{}) {
+    r.Response.ClearBuffer()
+    s.handleErrorLog(err, r)
+    if s.panicHandler == nil {
+        r.Response.WriteStatus(http.StatusInternalServerError)
+        return
+    }
+    defer func() {
+        // 处理方法本身产生panic时返回默认的500状态码
+        if e := recover(); e != nil && e != gEXCEPTION_EXIT {
+            s.handleErrorLog(e, r)
+            r.Response.ClearBuffer()
+            r.Response.WriteStatus(http.StatusInternalServerError)
+            return
+        }
+        // 如果处理方法内部没有设置状态码，那么这里设置500状态码
+        if r.Response.Status == http.StatusOK {
+            r.Response.WriteHeader(http.StatusInternalServerError)
+        }
+    }()
+    s.panicHandler(r, err)
 }
\ No newline at end of file
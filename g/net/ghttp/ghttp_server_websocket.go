@@ -7,10 +7,17 @@
 
 package ghttp
 
-import "gitee.com/johng/gf/third/github.com/gorilla/websocket"
+import (
+    "gitee.com/johng/gf/g/container/gtype"
+    "gitee.com/johng/gf/third/github.com/gorilla/websocket"
+    "time"
+)
 
+// Web Socket连接对象，读写消息使用ReadMessage/WriteMessage方法(同一时刻只允许一个goroutine读取以及一个goroutine写入)
 type WebSocket struct {
     *websocket.Conn
+    closed    *gtype.Bool   // 是否已关闭
+    closeChan chan struct{} // 关闭事件，用于结束心跳检测
 }
 
 const (
@@ -33,4 +40,49 @@ const (
     // PongMessage denotes a pong control message. The optional message payload
     // is UTF-8 encoded text.
     WS_MSG_PONG   = websocket.PongMessage
-)
\ No newline at end of file
+)
+
+// 创建Web Socket连接对象
+func newWebSocket(conn *websocket.Conn) *WebSocket {
+    return &WebSocket {
+        Conn      : conn,
+        closed    : gtype.NewBool(),
+        closeChan : make(chan struct{}),
+    }
+}
+
+// 开启心跳检测：每隔interval向客户端发送一次ping消息，客户端超过2个interval没有任何消息(包括pong消息)时，
+// ReadMessage将返回超时错误，调用方应当在ReadMessage返回错误时调用Close关闭连接；连接关闭后心跳检测自动结束。
+// 注意：开启心跳检测后会覆盖连接的读取超时时间及pong消息处理方法。
+func (ws *WebSocket) KeepAlive(interval time.Duration) {
+    timeout := 2*interval
+    ws.SetReadDeadline(time.Now().Add(timeout))
+    ws.SetPongHandler(func(string) error {
+        return ws.SetReadDeadline(time.Now().Add(timeout))
+    })
+    go func() {
+        ticker := time.NewTicker(interval)
+        defer ticker.Stop()
+        for {
+            select {
+                case <- ws.closeChan:
+                    return
+                case <- ticker.C:
+                    // WriteControl可以与WriteMessage并发调用
+                    if err := ws.WriteControl(WS_MSG_PING, nil, time.Now().Add(interval)); err != nil {
+                        return
+                    }
+            }
+        }
+    }()
+}
+
+// 关闭连接：向客户端发送正常关闭的close消息(客户端已断开时忽略发送错误)后关闭底层连接，并结束心跳检测，重复调用是安全的
+func (ws *WebSocket) Close() error {
+    if ws.closed.Set(true) {
+        return nil
+    }
+    close(ws.closeChan)
+    ws.WriteControl(WS_MSG_CLOSE, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(time.Second))
+    return ws.Conn.Close()
+}
@@ -240,8 +240,23 @@ func Chmod(path string, mode os.FileMode) error {
 }
 
 // 打开目录，并返回其下一级文件列表(绝对路径)，按照文件名称大小写进行排序，支持目录递归遍历。
+// pattern参数支持多个文件名称模式匹配，使用','符号分隔多个模式，例如："*.go,*.ini"。
 func ScanDir(path string, pattern string, recursive ... bool) ([]string, error) {
-    list, err := doScanDir(path, pattern, recursive...)
+    return ScanDirFunc(path, pattern, len(recursive) > 0 && recursive[0], nil)
+}
+
+// 同ScanDir，exclude参数为需要排除的文件名称模式，使用','符号分隔多个模式，例如：".git,*.tmp"，
+// 被排除的目录不会再递归遍历。
+func ScanDirExclude(path string, pattern string, exclude string, recursive ... bool) ([]string, error) {
+    return ScanDirFunc(path, pattern, len(recursive) > 0 && recursive[0], func(path string, info os.FileInfo) bool {
+        return !matchPatterns(exclude, info.Name())
+    })
+}
+
+// 同ScanDir，filter为自定义的过滤方法，返回false表示排除该文件/目录(被排除的目录不会再递归遍历)，
+// 通过filter的文件/目录再按照pattern进行匹配，filter为nil时不过滤。
+func ScanDirFunc(path string, pattern string, recursive bool, filter func(path string, info os.FileInfo) bool) ([]string, error) {
+    list, err := doScanDir(path, pattern, recursive, filter)
     if err != nil {
         return nil, err
     }
@@ -253,7 +268,7 @@ func ScanDir(path string, pattern string, recursive ... bool) ([]string, error)
 
 // 内部检索目录方法，支持递归，返回没有排序的文件绝对路径列表结果。
 // pattern参数支持多个文件名称模式匹配，使用','符号分隔多个模式。
-func doScanDir(path string, pattern string, recursive ... bool) ([]string, error) {
+func doScanDir(path string, pattern string, recursive bool, filter func(path string, info os.FileInfo) bool) ([]string, error) {
     var list []string
     // 打开目录
     dfile, err := os.Open(path)
@@ -266,25 +281,46 @@ func doScanDir(path string, pattern string, recursive ... bool) ([]string, error
     if err != nil {
         return nil, err
     }
-    // 是否递归遍历
     for _, name := range names {
         path := fmt.Sprintf("%s%s%s", path, Separator, name)
-        if IsDir(path) && len(recursive) > 0 && recursive[0] {
-            array, _ := doScanDir(path, pattern, true)
+        info, err := os.Stat(path)
+        if err != nil {
+            // 无法获取信息的文件(例如失效的符号链接)使用链接本身的信息
+            if info, err = os.Lstat(path); err != nil {
+                continue
+            }
+        }
+        if filter != nil && !filter(path, info) {
+            continue
+        }
+        // 是否递归遍历
+        if info.IsDir() && recursive {
+            array, _ := doScanDir(path, pattern, true, filter)
             if len(array) > 0 {
                 list = append(list, array...)
             }
         }
         // 满足pattern才加入结果列表
-        for _, p := range strings.Split(pattern, ",") {
-            if match, err := filepath.Match(strings.TrimSpace(p), name); err == nil && match {
-                list = append(list, path)
-            }
+        if matchPatterns(pattern, name) {
+            list = append(list, path)
         }
     }
     return list, nil
 }
 
+// 判断文件名称是否满足给定的模式(多个模式使用','符号分隔)中的任意一个
+func matchPatterns(pattern string, name string) bool {
+    for _, p := range strings.Split(pattern, ",") {
+        if p = strings.TrimSpace(p); p == "" {
+            continue
+        }
+        if match, err := filepath.Match(p, name); err == nil && match {
+            return true
+        }
+    }
+    return false
+}
+
 // 将所给定的路径转换为绝对路径
 // 并判断文件路径是否存在，如果文件不存在，那么返回空字符串
 func RealPath(path string) string {
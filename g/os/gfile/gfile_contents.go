@@ -7,16 +7,27 @@
 package gfile
 
 import (
+    "bufio"
+    "errors"
+    "fmt"
     "io"
     "io/ioutil"
     "os"
+    "time"
 )
 
 const (
     // 方法中涉及到读取的时候的缓冲大小
-    gREAD_BUFFER      = 1024
+    gREAD_BUFFER       = 1024
+    // 按行读取文件内容时的缓冲大小
+    gREAD_LINES_BUFFER = 64*1024
     // 方法中涉及到文件指针池的默认缓存时间(秒)
-    gFILE_POOL_EXPIRE = 60
+    gFILE_POOL_EXPIRE  = 60
+)
+
+var (
+    // 按行读取文件内容时，回调方法返回该错误表示停止读取，ReadLines/ReadLinesBytes将返回nil
+    ErrStopReadLines = errors.New("stop reading lines")
 )
 
 // (文本)读取文件内容
@@ -56,6 +67,63 @@ func putContents(path string, data []byte, flag int, perm int) error {
     return nil
 }
 
+// 原子写入文件内容：先写入到同一目录下的临时文件，再通过重命名替换目标文件，
+// 读取方(或者文件监听)不会读取到只写入了一部分的文件内容。目标文件已存在时保留其权限。
+func putContentsAtomic(path string, data []byte) error {
+    dir := Dir(path)
+    if !Exists(dir) {
+        if err := Mkdir(dir); err != nil {
+            return err
+        }
+    }
+    perm := os.FileMode(0)
+    if info, err := os.Stat(path); err == nil {
+        perm = info.Mode().Perm()
+    }
+    // 临时文件名称以"."开头，避免被按照扩展名匹配的监听/扫描逻辑处理
+    var f   *os.File
+    var err error
+    tmp := ""
+    for i := 0; i < 10000; i++ {
+        tmp    = fmt.Sprintf("%s%s.%s.%d.tmp", dir, Separator, Basename(path), time.Now().UnixNano() + int64(i))
+        f, err = os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_EXCL, gDEFAULT_PERM)
+        if !os.IsExist(err) {
+            break
+        }
+    }
+    if err != nil {
+        return err
+    }
+    if n, err := f.Write(data); err != nil || n < len(data) {
+        f.Close()
+        os.Remove(tmp)
+        if err == nil {
+            err = io.ErrShortWrite
+        }
+        return err
+    }
+    if err := f.Sync(); err != nil {
+        f.Close()
+        os.Remove(tmp)
+        return err
+    }
+    if err := f.Close(); err != nil {
+        os.Remove(tmp)
+        return err
+    }
+    if perm != 0 {
+        if err := os.Chmod(tmp, perm); err != nil {
+            os.Remove(tmp)
+            return err
+        }
+    }
+    if err := os.Rename(tmp, path); err != nil {
+        os.Remove(tmp)
+        return err
+    }
+    return nil
+}
+
 // Truncate
 func Truncate(path string, size int) error {
     return os.Truncate(path, int64(size))
@@ -66,6 +134,11 @@ func PutContents(path string, content string) error {
     return putContents(path, []byte(content), os.O_WRONLY|os.O_CREATE|os.O_TRUNC, gDEFAULT_PERM)
 }
 
+// (文本)原子写入文件内容，写入过程中目标文件要么是原有内容要么是完整的新内容
+func PutContentsAtomic(path string, content string) error {
+    return putContentsAtomic(path, []byte(content))
+}
+
 // (文本)追加内容到文件末尾
 func PutContentsAppend(path string, content string) error {
     return putContents(path, []byte(content), os.O_WRONLY|os.O_CREATE|os.O_APPEND, gDEFAULT_PERM)
@@ -76,6 +149,11 @@ func PutBinContents(path string, content []byte) error {
     return putContents(path, content, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, gDEFAULT_PERM)
 }
 
+// (二进制)原子写入文件内容，写入过程中目标文件要么是原有内容要么是完整的新内容
+func PutBinContentsAtomic(path string, content []byte) error {
+    return putContentsAtomic(path, content)
+}
+
 // (二进制)追加内容到文件末尾
 func PutBinContentsAppend(path string, content []byte) error {
     return putContents(path, content, os.O_WRONLY|os.O_CREATE|os.O_APPEND, gDEFAULT_PERM)
@@ -148,4 +226,57 @@ func GetBinContentsByTwoOffsetsByPath(path string, start int64, end int64) []byt
         panic(err)
     }
     return nil
-}
\ No newline at end of file
+}
+
+// 按行流式读取文件内容(不会将整个文件读取到内存中)，每一行内容(不包含行尾的"\n"或者"\r\n")调用一次callback，
+// callback返回ErrStopReadLines时停止读取并返回nil，返回其他错误时停止读取并返回该错误。
+// 需要跟踪文件的追加写入(类似tail -f)时使用gfsnotify.Tail。
+func ReadLines(path string, callback func(line string) error) error {
+    return ReadLinesBytes(path, func(line []byte) error {
+        return callback(string(line))
+    })
+}
+
+// 同ReadLines，回调参数为[]byte类型，避免字符串的内存分配。
+// 注意：line使用了复用的缓冲区，其内容只在callback执行期间有效，需要保留时应当复制一份。
+func ReadLinesBytes(path string, callback func(line []byte) error) error {
+    f, err := os.Open(path)
+    if err != nil {
+        return err
+    }
+    defer f.Close()
+    reader := bufio.NewReaderSize(f, gREAD_LINES_BUFFER)
+    buffer := make([]byte, 0, gREAD_LINES_BUFFER)
+    for {
+        // 超过读取缓冲区大小的长行会被分多次返回，这里合并到复用的缓冲区中
+        slice, isPrefix, err := reader.ReadLine()
+        if err != nil {
+            if err != io.EOF {
+                return err
+            }
+            // 文件最后一行没有换行符并且长度为读取缓冲区大小的整数倍时，最后一次读取只返回io.EOF，需要回调已合并的内容
+            if len(buffer) > 0 {
+                if err = callback(buffer); err != nil && err != ErrStopReadLines {
+                    return err
+                }
+            }
+            return nil
+        }
+        line := slice
+        if isPrefix || len(buffer) > 0 {
+            buffer = append(buffer, slice...)
+            if isPrefix {
+                continue
+            }
+            line = buffer
+        }
+        err = callback(line)
+        buffer = buffer[:0]
+        if err != nil {
+            if err == ErrStopReadLines {
+                return nil
+            }
+            return err
+        }
+    }
+}
@@ -0,0 +1,201 @@
+// Copyright 2018 gf Author(https://gitee.com/johng/gf). All Rights Reserved.
+//
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT was not distributed with this file,
+// You can obtain one at https://gitee.com/johng/gf.
+
+package gfile
+
+import (
+    "errors"
+    "fmt"
+    "io"
+    "os"
+    "path/filepath"
+    "strings"
+)
+
+// 文件/目录复制的可选参数
+type CopyOptions struct {
+    PreserveTime bool // 是否保留文件/目录的修改时间(默认为复制时的时间)
+    FollowLink   bool // 符号链接的处理方式：true表示复制链接指向的文件/目录内容，false表示在目标位置重新创建相同的符号链接(默认)
+}
+
+// 复制文件，目标文件已存在时覆盖，文件权限与源文件保持一致，目标文件所在目录不存在时自动创建。
+// 目标路径与源文件为同一个文件(包括指向源文件的符号链接)时返回错误。错误信息中包含复制失败的文件路径。
+func CopyFile(src string, dst string, options...CopyOptions) error {
+    option := CopyOptions{}
+    if len(options) > 0 {
+        option = options[0]
+    }
+    if _, err := os.Lstat(src); err != nil {
+        return copyError(src, dst, err)
+    }
+    if err := Mkdir(Dir(dst)); err != nil {
+        return copyError(src, dst, err)
+    }
+    return copyFile(src, dst, option)
+}
+
+// 递归复制目录，包括目录下的所有文件及子目录，目标目录不存在时自动创建，文件/目录的权限与源文件/目录保持一致。
+// 复制失败时返回错误，错误信息中包含复制失败的文件路径(已经复制的文件不会删除)。
+func CopyDir(src string, dst string, options...CopyOptions) error {
+    option := CopyOptions{}
+    if len(options) > 0 {
+        option = options[0]
+    }
+    srcReal, err := resolvePath(src)
+    if err != nil {
+        return copyError(src, dst, err)
+    }
+    dstReal, err := resolvePath(dst)
+    if err != nil {
+        return copyError(src, dst, err)
+    }
+    // 不允许复制到源目录自身或者其子目录中(包括通过符号链接指向的子目录)，否则会无限递归
+    if rel, err := filepath.Rel(srcReal, dstReal); err == nil && rel != ".." && !strings.HasPrefix(rel, ".." + Separator) {
+        return copyError(src, dst, errors.New("destination is inside the source directory"))
+    }
+    info, err := os.Stat(src)
+    if err != nil {
+        return copyError(src, dst, err)
+    }
+    if !info.IsDir() {
+        return copyError(src, dst, errors.New("source is not a directory"))
+    }
+    return copyDir(src, dst, info, option)
+}
+
+// 获取路径解析符号链接后的绝对路径，路径不存在时解析其最近的已存在的上级目录，再拼接上不存在的部分
+func resolvePath(path string) (string, error) {
+    abs, err := filepath.Abs(path)
+    if err != nil {
+        return "", err
+    }
+    rest := ""
+    for {
+        if real, err := filepath.EvalSymlinks(abs); err == nil {
+            return filepath.Join(real, rest), nil
+        }
+        parent := filepath.Dir(abs)
+        if parent == abs {
+            return filepath.Join(abs, rest), nil
+        }
+        rest = filepath.Join(filepath.Base(abs), rest)
+        abs  = parent
+    }
+}
+
+// 递归复制目录，info为源目录的文件信息
+func copyDir(src string, dst string, info os.FileInfo, option CopyOptions) error {
+    // 创建时保证当前用户可写，复制完成后再设置为源目录的权限
+    if err := os.MkdirAll(dst, info.Mode().Perm() | 0700); err != nil {
+        return copyError(src, dst, err)
+    }
+    names, err := DirNames(src)
+    if err != nil {
+        return copyError(src, dst, err)
+    }
+    for _, name := range names {
+        srcPath := src + Separator + name
+        dstPath := dst + Separator + name
+        subInfo, err := os.Lstat(srcPath)
+        if err != nil {
+            return copyError(srcPath, dstPath, err)
+        }
+        // 需要跟随符号链接时，按照链接指向的文件/目录处理
+        if subInfo.Mode() & os.ModeSymlink != 0 && option.FollowLink {
+            if subInfo, err = os.Stat(srcPath); err != nil {
+                return copyError(srcPath, dstPath, err)
+            }
+        }
+        if subInfo.IsDir() {
+            err = copyDir(srcPath, dstPath, subInfo, option)
+        } else {
+            err = copyFile(srcPath, dstPath, option)
+        }
+        if err != nil {
+            return err
+        }
+    }
+    // 目录的权限及修改时间需要在复制完目录内容后设置(复制内容会修改目录的修改时间，并且只读目录无法写入内容)
+    if err := os.Chmod(dst, info.Mode().Perm()); err != nil {
+        return copyError(src, dst, err)
+    }
+    if option.PreserveTime {
+        if err := os.Chtimes(dst, info.ModTime(), info.ModTime()); err != nil {
+            return copyError(src, dst, err)
+        }
+    }
+    return nil
+}
+
+// 复制单个文件(或者符号链接)，目标文件所在目录需要已存在
+func copyFile(src string, dst string, option CopyOptions) error {
+    info, err := os.Lstat(src)
+    if err != nil {
+        return copyError(src, dst, err)
+    }
+    // 重新创建符号链接
+    if info.Mode() & os.ModeSymlink != 0 {
+        if !option.FollowLink {
+            link, err := os.Readlink(src)
+            if err != nil {
+                return copyError(src, dst, err)
+            }
+            if dstInfo, err := os.Lstat(dst); err == nil {
+                if os.SameFile(info, dstInfo) {
+                    return copyError(src, dst, errors.New("source and destination are the same file"))
+                }
+                if err := os.Remove(dst); err != nil {
+                    return copyError(src, dst, err)
+                }
+            }
+            if err := os.Symlink(link, dst); err != nil {
+                return copyError(src, dst, err)
+            }
+            return nil
+        }
+        if info, err = os.Stat(src); err != nil {
+            return copyError(src, dst, err)
+        }
+    }
+    if info.IsDir() {
+        return copyError(src, dst, errors.New("source is a directory"))
+    }
+    // 打开目标文件时会清空其内容，目标路径(跟随符号链接)与源文件为同一个文件时不能复制
+    if dstInfo, err := os.Stat(dst); err == nil && os.SameFile(info, dstInfo) {
+        return copyError(src, dst, errors.New("source and destination are the same file"))
+    }
+    srcFile, err := os.Open(src)
+    if err != nil {
+        return copyError(src, dst, err)
+    }
+    defer srcFile.Close()
+    dstFile, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode().Perm())
+    if err != nil {
+        return copyError(src, dst, err)
+    }
+    if _, err := io.Copy(dstFile, srcFile); err != nil {
+        dstFile.Close()
+        return copyError(src, dst, err)
+    }
+    if err := dstFile.Close(); err != nil {
+        return copyError(src, dst, err)
+    }
+    // 创建文件时的权限会受到umask影响，并且已存在的文件不会修改权限，因此需要重新设置
+    if err := os.Chmod(dst, info.Mode().Perm()); err != nil {
+        return copyError(src, dst, err)
+    }
+    if option.PreserveTime {
+        if err := os.Chtimes(dst, info.ModTime(), info.ModTime()); err != nil {
+            return copyError(src, dst, err)
+        }
+    }
+    return nil
+}
+
+// 生成复制失败的错误信息
+func copyError(src string, dst string, err error) error {
+    return errors.New(fmt.Sprintf(`copy "%s" to "%s" failed: %s`, src, dst, err.Error()))
+}
@@ -0,0 +1,48 @@
+// Copyright 2018 gf Author(https://gitee.com/johng/gf). All Rights Reserved.
+//
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT was not distributed with this file,
+// You can obtain one at https://gitee.com/johng/gf.
+
+package gfile
+
+import (
+    "fmt"
+    "os"
+)
+
+// 文件/目录不存在时Stat返回的错误类型，可以通过gfile.IsNotExist或者errors.Is(err, os.ErrNotExist)判断(os.IsNotExist无法识别该类型)
+type NotExistError struct {
+    Path string // 不存在的文件/目录路径
+}
+
+func (e *NotExistError) Error() string {
+    return fmt.Sprintf(`file "%s" does not exist`, e.Path)
+}
+
+// 兼容errors.Is(err, os.ErrNotExist)判断
+func (e *NotExistError) Unwrap() error {
+    return os.ErrNotExist
+}
+
+// 获取文件/目录的信息快照(只执行一次系统调用)，可以同时获取Size()、ModTime()、IsDir()、Mode()等信息，
+// 常用于判断文件是否真正发生了变化(例如只修改了权限时文件大小及修改时间不变)。
+// 文件/目录不存在时返回*NotExistError类型的错误。
+func Stat(path string) (os.FileInfo, error) {
+    info, err := os.Stat(path)
+    if err != nil {
+        if os.IsNotExist(err) {
+            return nil, &NotExistError{Path : path}
+        }
+        return nil, err
+    }
+    return info, nil
+}
+
+// 判断错误是否为文件/目录不存在的错误(包括*NotExistError类型的错误)
+func IsNotExist(err error) bool {
+    if _, ok := err.(*NotExistError); ok {
+        return true
+    }
+    return os.IsNotExist(err)
+}
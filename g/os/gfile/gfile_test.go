@@ -0,0 +1,375 @@
+// Copyright 2018 gf Author(https://gitee.com/johng/gf). All Rights Reserved.
+//
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT was not distributed with this file,
+// You can obtain one at https://gitee.com/johng/gf.
+
+// go test *.go -v
+
+package gfile
+
+import (
+    "errors"
+    "os"
+    "strings"
+    "sync"
+    "testing"
+    "time"
+)
+
+// 原子写入：创建不存在的目录，保留已有文件的权限，不遗留临时文件，并发读取时只会读取到完整的内容
+func TestPutContentsAtomic(t *testing.T) {
+    dir  := t.TempDir()
+    path := dir + Separator + "sub" + Separator + "config.json"
+    if err := PutContentsAtomic(path, "v1"); err != nil {
+        t.Fatal(err)
+    }
+    if content := GetContents(path); content != "v1" {
+        t.Errorf(`unexpected content "%s"`, content)
+    }
+    if err := os.Chmod(path, 0600); err != nil {
+        t.Fatal(err)
+    }
+    if err := PutBinContentsAtomic(path, []byte("v2")); err != nil {
+        t.Fatal(err)
+    }
+    if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+        t.Errorf("the permission of the replaced file was not preserved: %v, %v", info, err)
+    }
+
+    // 并发写入及读取
+    contents := []string{ strings.Repeat("a", 100000), strings.Repeat("b", 200000) }
+    wg       := sync.WaitGroup{}
+    done     := make(chan struct{})
+    for _, content := range contents {
+        wg.Add(1)
+        go func(content string) {
+            defer wg.Done()
+            for i := 0; i < 50; i++ {
+                if err := PutContentsAtomic(path, content); err != nil {
+                    t.Error(err)
+                    return
+                }
+            }
+        }(content)
+    }
+    go func() {
+        wg.Wait()
+        close(done)
+    }()
+    for reading := true; reading; {
+        select {
+            case <-done:
+                reading = false
+            default:
+                if content := GetContents(path); content != contents[0] && content != contents[1] && content != "v2" {
+                    t.Fatalf("read a partial content of %d bytes", len(content))
+                }
+        }
+    }
+    names, err := DirNames(Dir(path))
+    if err != nil {
+        t.Fatal(err)
+    }
+    if len(names) != 1 || names[0] != "config.json" {
+        t.Errorf("unexpected files %v left in the directory", names)
+    }
+}
+
+// 在目录dir下按照"相对路径 => 内容"创建文件
+func testCreateFiles(t *testing.T, dir string, files map[string]string) {
+    for path, content := range files {
+        if err := PutContents(dir + Separator + path, content); err != nil {
+            t.Fatal(err)
+        }
+    }
+}
+
+// 复制文件：覆盖已有的目标文件，自动创建目标目录，保持权限，可选保留修改时间
+func TestCopyFile(t *testing.T) {
+    dir := t.TempDir()
+    src := dir + Separator + "src.txt"
+    dst := dir + Separator + "a" + Separator + "b" + Separator + "dst.txt"
+    testCreateFiles(t, dir, map[string]string{ "src.txt" : "content", "a/b/dst.txt" : "old content that is longer" })
+    mtime := time.Now().Add(-time.Hour).Truncate(time.Second)
+    if err := os.Chmod(src, 0640); err != nil {
+        t.Fatal(err)
+    }
+    if err := os.Chtimes(src, mtime, mtime); err != nil {
+        t.Fatal(err)
+    }
+    if err := CopyFile(src, dst, CopyOptions{ PreserveTime : true }); err != nil {
+        t.Fatal(err)
+    }
+    info, err := os.Stat(dst)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if GetContents(dst) != "content" || info.Mode().Perm() != 0640 || !info.ModTime().Equal(mtime) {
+        t.Errorf(`unexpected copied file "%s" with mode %v and mtime %v`, GetContents(dst), info.Mode(), info.ModTime())
+    }
+    if err := CopyFile(dir + Separator + "none", dst); err == nil || !strings.Contains(err.Error(), "none") {
+        t.Errorf("unexpected error for a missing source file: %v", err)
+    }
+    if err := CopyFile(dir + Separator + "a", dst); err == nil {
+        t.Error("expected error for copying a directory with CopyFile")
+    }
+    // 复制到源文件自身或者指向源文件的符号链接时返回错误，并且不会清空源文件
+    link := dir + Separator + "src-link.txt"
+    if err := os.Symlink(src, link); err != nil {
+        t.Fatal(err)
+    }
+    for _, c := range []struct {
+        src, dst string
+        option   CopyOptions
+    } {
+        { src,  src,  CopyOptions{} },
+        { src,  link, CopyOptions{} },
+        { link, src,  CopyOptions{ FollowLink : true } },
+        { link, link, CopyOptions{} },
+    } {
+        if err := CopyFile(c.src, c.dst, c.option); err == nil || !strings.Contains(err.Error(), "same file") {
+            t.Errorf(`unexpected error for copying "%s" to "%s": %v`, c.src, c.dst, err)
+        }
+        if content := GetContents(src); content != "content" {
+            t.Fatalf(`the source file was changed to "%s" by copying "%s" to "%s"`, content, c.src, c.dst)
+        }
+    }
+    if target, err := os.Readlink(link); err != nil || target != src {
+        t.Errorf(`the symbolic link was changed: "%s", %v`, target, err)
+    }
+}
+
+// 递归复制目录：包括子目录及符号链接(重新创建或者跟随)，不允许复制到源目录自身或者其子目录中
+func TestCopyDir(t *testing.T) {
+    dir := t.TempDir()
+    src := dir + Separator + "src"
+    testCreateFiles(t, src, map[string]string{ "a.txt" : "a", "sub/b.txt" : "b", "sub/deep/c.txt" : "c" })
+    if err := os.Symlink("a.txt", src + Separator + "link.txt"); err != nil {
+        t.Fatal(err)
+    }
+    if err := os.Chmod(src + Separator + "sub", 0750); err != nil {
+        t.Fatal(err)
+    }
+
+    dst := dir + Separator + "dst"
+    if err := CopyDir(src, dst); err != nil {
+        t.Fatal(err)
+    }
+    for path, content := range map[string]string{ "a.txt" : "a", "sub/b.txt" : "b", "sub/deep/c.txt" : "c", "link.txt" : "a" } {
+        if c := GetContents(dst + Separator + path); c != content {
+            t.Errorf(`unexpected content "%s" of copied "%s"`, c, path)
+        }
+    }
+    if link, err := os.Readlink(dst + Separator + "link.txt"); err != nil || link != "a.txt" {
+        t.Errorf(`the symbolic link was not recreated: "%s", %v`, link, err)
+    }
+    if info, err := os.Stat(dst + Separator + "sub"); err != nil || info.Mode().Perm() != 0750 {
+        t.Errorf("the permission of the copied directory was not preserved: %v, %v", info, err)
+    }
+    follow := dir + Separator + "follow"
+    if err := CopyDir(src, follow, CopyOptions{ FollowLink : true }); err != nil {
+        t.Fatal(err)
+    }
+    if info, err := os.Lstat(follow + Separator + "link.txt"); err != nil || !info.Mode().IsRegular() {
+        t.Errorf("the symbolic link was not followed: %v, %v", info, err)
+    }
+
+    // 复制到源目录自身或者其子目录中(包括通过符号链接访问源目录)
+    link := dir + Separator + "src-link"
+    if err := os.Symlink(src, link); err != nil {
+        t.Fatal(err)
+    }
+    for _, c := range [][2]string {
+        { src,  src },
+        { src,  src + Separator + "copy" },
+        { src,  src + Separator + "sub" + Separator + "new" + Separator + "copy" },
+        { link, src + Separator + "copy" },
+        { src,  link + Separator + "copy" },
+    } {
+        if err := CopyDir(c[0], c[1]); err == nil || !strings.Contains(err.Error(), "inside the source directory") {
+            t.Errorf(`unexpected error for copying "%s" to "%s": %v`, c[0], c[1], err)
+        }
+    }
+    if Exists(src + Separator + "copy") {
+        t.Error("the refused copy created the destination directory")
+    }
+    // 名称以源目录名称开头的同级目录不是子目录
+    if err := CopyDir(src, src + "-copy"); err != nil {
+        t.Error(err)
+    }
+}
+
+
+// ScanDirFunc/ScanDirExclude：被过滤的目录不再递归遍历，通过过滤的文件/目录再按照pattern匹配，结果按照路径排序
+func TestScanDirFunc(t *testing.T) {
+    dir := t.TempDir()
+    testCreateFiles(t, dir, map[string]string{
+        "a.go"           : "",
+        "b.txt"          : "",
+        "sub/c.go"       : "",
+        "sub/d.tmp"      : "",
+        ".git/e.go"      : "",
+        "vendor/x/f.go"  : "",
+    })
+    relative := func(paths []string) string {
+        for i, path := range paths {
+            paths[i] = strings.TrimPrefix(path, dir + Separator)
+        }
+        return strings.Join(paths, ",")
+    }
+    visited := make(map[string]bool)
+    paths, err := ScanDirFunc(dir, "*.go", true, func(path string, info os.FileInfo) bool {
+        visited[strings.TrimPrefix(path, dir + Separator)] = true
+        return info.Name() != "vendor"
+    })
+    if err != nil {
+        t.Fatal(err)
+    }
+    if s := relative(paths); s != ".git/e.go,a.go,sub/c.go" {
+        t.Errorf(`unexpected paths "%s"`, s)
+    }
+    if visited["vendor/x"] || visited["vendor/x/f.go"] {
+        t.Errorf("the filtered directory was traversed: %v", visited)
+    }
+    if paths, err = ScanDirExclude(dir, "*", ".git,vendor,*.tmp", true); err != nil {
+        t.Fatal(err)
+    }
+    if s := relative(paths); s != "a.go,b.txt,sub,sub/c.go" {
+        t.Errorf(`unexpected paths "%s" with exclude patterns`, s)
+    }
+    if paths, err = ScanDirFunc(dir, "*.go,*.txt", false, nil); err != nil {
+        t.Fatal(err)
+    }
+    if s := relative(paths); s != "a.go,b.txt" {
+        t.Errorf(`unexpected paths "%s" without recursion`, s)
+    }
+    if _, err := ScanDirFunc(dir + Separator + "none", "*", true, nil); err == nil {
+        t.Error("expected error for a missing directory")
+    }
+}
+
+// 按行读取：超过读取缓冲区大小的长行(包括长度为缓冲区大小整数倍的行)、"\r\n"换行、没有换行符的最后一行及空行
+func TestReadLines(t *testing.T) {
+    size := gREAD_LINES_BUFFER
+    for _, lengths := range [][]int {
+        { 0, 1, 0 },
+        { 10, size - 1, size, size + 1, 3 * size, 10 },
+        { 10, size },
+        { 10, size - 1 },
+        { 10, 2 * size },
+        { size + 5 },
+    } {
+        for _, newline := range []string{ "\n", "\r\n" } {
+            for _, trailing := range []bool{ true, false } {
+                lines := make([]string, len(lengths))
+                for i, n := range lengths {
+                    lines[i] = strings.Repeat(string(rune('a' + i)), n)
+                }
+                content := strings.Join(lines, newline)
+                if trailing {
+                    content += newline
+                }
+                path := t.TempDir() + Separator + "lines.txt"
+                if err := PutContents(path, content); err != nil {
+                    t.Fatal(err)
+                }
+                read := make([]string, 0)
+                if err := ReadLines(path, func(line string) error {
+                    read = append(read, line)
+                    return nil
+                }); err != nil {
+                    t.Fatal(err)
+                }
+                // 以空行结尾并且没有换行符时，最后的空行不会被读取
+                expect := lines
+                if !trailing && lengths[len(lengths) - 1] == 0 {
+                    expect = lines[:len(lines) - 1]
+                }
+                if strings.Join(read, ",") != strings.Join(expect, ",") {
+                    t.Errorf("lengths %v, newline %q, trailing %v: unexpected %d lines", lengths, newline, trailing, len(read))
+                }
+            }
+        }
+    }
+}
+
+// 回调返回ErrStopReadLines时停止读取并返回nil，返回其他错误时停止读取并返回该错误；ReadLinesBytes复用缓冲区
+func TestReadLines_Stop(t *testing.T) {
+    path := t.TempDir() + Separator + "lines.txt"
+    if err := PutContents(path, "a\nb\nc\n" + strings.Repeat("d", gREAD_LINES_BUFFER)); err != nil {
+        t.Fatal(err)
+    }
+    count := 0
+    if err := ReadLines(path, func(line string) error {
+        if count++; line == "b" {
+            return ErrStopReadLines
+        }
+        return nil
+    }); err != nil || count != 2 {
+        t.Errorf("unexpected result %v after reading %d lines", err, count)
+    }
+    stop := os.ErrInvalid
+    if err := ReadLinesBytes(path, func(line []byte) error {
+        if len(line) == gREAD_LINES_BUFFER {
+            return stop
+        }
+        return nil
+    }); err != stop {
+        t.Errorf("unexpected error %v, expected %v", err, stop)
+    }
+    lines := make([]string, 0)
+    if err := ReadLinesBytes(path, func(line []byte) error {
+        lines = append(lines, string(line))
+        return nil
+    }); err != nil || len(lines) != 4 || lines[0] != "a" || len(lines[3]) != gREAD_LINES_BUFFER {
+        t.Errorf("unexpected lines of lengths %d, %v", len(lines), err)
+    }
+    if err := ReadLines(path + ".none", func(line string) error { return nil }); err == nil {
+        t.Error("expected error for a missing file")
+    }
+}
+
+// Stat一次获取文件信息快照，文件不存在时返回*NotExistError，可以通过IsNotExist及errors.Is判断
+func TestStat(t *testing.T) {
+    dir  := t.TempDir()
+    path := dir + Separator + "stat.txt"
+    if err := PutContents(path, "12345"); err != nil {
+        t.Fatal(err)
+    }
+    info, err := Stat(path)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if info.Size() != 5 || info.IsDir() || info.Name() != "stat.txt" {
+        t.Errorf("unexpected file info %v", info)
+    }
+    // 只修改权限时文件大小及修改时间不变
+    if err := os.Chmod(path, 0600); err != nil {
+        t.Fatal(err)
+    }
+    if changed, err := Stat(path); err != nil || changed.Size() != info.Size() || !changed.ModTime().Equal(info.ModTime()) || changed.Mode().Perm() != 0600 {
+        t.Errorf("unexpected file info %v, %v after chmod", changed, err)
+    }
+    if info, err := Stat(dir); err != nil || !info.IsDir() {
+        t.Errorf("unexpected directory info %v, %v", info, err)
+    }
+
+    _, err = Stat(dir + Separator + "none")
+    if err == nil {
+        t.Fatal("expected error for a missing file")
+    }
+    if e, ok := err.(*NotExistError); !ok || e.Path != dir + Separator + "none" {
+        t.Errorf("unexpected error type %T: %v", err, err)
+    }
+    if !IsNotExist(err) || !errors.Is(err, os.ErrNotExist) {
+        t.Errorf("the error %v was not recognized as a not-exist error", err)
+    }
+    if _, err := os.Stat(dir + Separator + "none"); !IsNotExist(err) {
+        t.Error("IsNotExist did not recognize the error of os.Stat")
+    }
+    if IsNotExist(nil) || IsNotExist(os.ErrPermission) {
+        t.Error("IsNotExist recognized an unrelated error")
+    }
+}
+
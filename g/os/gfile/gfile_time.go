@@ -10,7 +10,7 @@ import (
     "os"
 )
 
-// 文件修改时间(时间戳，秒)
+// 文件修改时间(时间戳，秒)，文件不存在时返回0，只执行一次系统调用
 func MTime(path string) int64 {
     s, e := os.Stat(path)
     if e != nil {
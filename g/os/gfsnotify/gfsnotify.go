@@ -9,44 +9,82 @@ package gfsnotify
 
 import (
     "container/list"
+    "context"
     "errors"
     "fmt"
     "gitee.com/johng/gf/g/container/glist"
     "gitee.com/johng/gf/g/container/gmap"
-    "gitee.com/johng/gf/g/container/gqueue"
     "gitee.com/johng/gf/g/container/gtype"
     "gitee.com/johng/gf/g/encoding/ghash"
-    "gitee.com/johng/gf/g/os/gcache"
     "gitee.com/johng/gf/g/os/gcmd"
     "gitee.com/johng/gf/g/os/genv"
     "gitee.com/johng/gf/g/util/gconv"
     "gitee.com/johng/gf/third/github.com/fsnotify/fsnotify"
+    "sort"
+    "strings"
+    "sync"
+    "time"
 )
 
 // 监听管理对象
 type Watcher struct {
-    watcher       *fsnotify.Watcher        // 底层fsnotify对象
-    events        *gqueue.Queue            // 过滤后的事件通知，不会出现重复事件
-    closeChan     chan struct{}            // 关闭事件
-    callbacks     *gmap.StringInterfaceMap // 监听的回调函数
-    cache         *gcache.Cache            // 缓存对象，用于事件重复过滤
+    id             int                            // 监听对象ID(自增)，便于调试时区分不同的监听对象
+    watcher        backend                        // 底层监听对象(fsnotify或者轮询)
+    events         *eventQueue                    // 过滤后的事件通知，不会出现重复事件
+    closeChan      chan struct{}                  // 关闭事件
+    closed         *gtype.Bool                    // 是否已关闭，保证关闭操作只会执行一次
+    errorHandler   *gtype.Interface               // 底层监听错误的自定义处理方法(func(error))
+    logger         *gtype.Interface               // 日志对象(Logger)
+    debug          *gtype.Bool                    // 是否输出调试日志
+    callbacks      *gmap.StringInterfaceMap       // 监听的回调函数
+    loopWg         sync.WaitGroup                 // 监听循环及事件循环的退出等待
+    debounceMu     sync.Mutex                     // 事件合并互斥锁
+    debounces      map[debounceKey]*debounceItem  // 等待合并回调的事件(按照回调对象及事件路径区分)
+    renameMu       sync.Mutex                     // 等待关联的重命名事件互斥锁
+    renameEvent    *pendingRename                 // 等待关联的重命名事件
+    movedEvent     *Event                         // 最近一次关联成功的移动事件(仅在事件循环中使用)，用于过滤旧路径重复的重命名事件
+    stats          *watcherStats                  // 运行统计计数器
+    serialDispatch bool                           // 是否开启同一路径的串行回调
+    serialMu       sync.Mutex                     // 串行回调队列互斥锁
+    serials        map[string]*serialQueue        // 串行回调队列(按照事件路径区分)
+    workers        []chan serialTask              // 回调工作协程的待执行队列(按照事件路径哈希分配，见WithWorkers)
+    linkParents    map[string]int                 // 只监听符号链接本身时需要监听的链接所在目录及其引用计数(在回调注册的锁中使用)
+    clock          clock                          // 时间源
+    recursive      *gtype.Bool                    // 添加目录监听时未指定是否递归的默认值(默认递归)
 }
 
 // 注册的监听回调方法
 type Callback struct {
-    Id     int                 // 唯一ID
-    Func   func(event *Event)  // 回调方法
-    Path   string              // 监听的文件/目录
-    elem   *list.Element       // 指向监听链表中的元素项位置
-    parent *Callback           // 父级callback，有这个属性表示该callback为被自动管理的callback
-    subs   *glist.List         // 子级回调对象指针列表
+    Id         int                 // 唯一ID
+    Func       func(event *Event)  // 回调方法
+    Path       string              // 监听的文件/目录
+    watcher    *Watcher            // 所属的监听对象
+    elem       *list.Element       // 指向监听链表中的元素项位置
+    parent     *Callback           // 父级callback，有这个属性表示该callback为被自动管理的callback
+    parentElem *list.Element       // 指向父级callback子级列表中的元素项位置
+    subs       *glist.List         // 子级回调对象指针列表
+    option     WatchOption         // 监听配置项(子级callback继承父级的配置)
+    recursive  bool                // 是否为目录的递归监听
+    waiting    *gtype.Bool         // 是否正在等待路径被创建(仅用于等待创建的callback，其他callback为nil)
+    waiter     bool                // 是否为等待创建的callback自动管理的上级目录监听
+    linkParent string              // 监听符号链接本身时，监听的符号链接所在目录
+    file       bool                // 注册监听时是否为文件(非目录)，文件的回调只接收该文件本身的事件
+    multi      bool                // 是否为AddPaths返回的批量回调对象(本身不注册监听，subs为每一个路径的主callback)
+    group      *Callback           // 通过AddPaths添加时所属的批量回调对象(parentElem指向其子级列表中的元素项位置)
 }
 
 // 监听事件对象
 type Event struct {
     event   fsnotify.Event   // 底层事件对象
+    time    int64            // 事件产生的时间(毫秒)
     Path    string           // 文件绝对路径
+    // 移动前的文件绝对路径，仅在MOVE事件中有效(此时Path为移动后的文件绝对路径)。
+    // Linux(inotify)下重命名会产生旧路径的RENAME事件以及紧随其后新路径的CREATE事件，两者在事件循环中按照时间窗口关联为MOVE事件；
+    // macOS(kqueue)下通常只有旧路径的RENAME事件，新路径只有在其父级目录被监听时才会产生CREATE事件，因此可能无法关联；
+    // Windows下重命名的新旧路径事件是成对产生的，可以正常关联。
+    OldPath string
     Op      Op               // 触发监听的文件操作
+    Initial bool             // 是否为添加监听时产生的初始事件(Op为CREATE，见WithInitialEvent)
     Watcher *Watcher         // 事件对应的监听对象
 }
 
@@ -58,58 +96,158 @@ const (
     CREATE Op = 1 << iota
     WRITE
     REMOVE
+    // 重命名后原路径仍然存在(例如编辑器或者部署工具的原子替换)，真实的重命名会被关联为MOVE或者REMOVE事件
     RENAME
     CHMOD
+    // 在监听的路径内移动(重命名)，Path为移动后的路径，OldPath为移动前的路径。
+    // 旧路径的重命名事件会在RENAME_CORRELATE_INTERVAL时间窗口内等待新路径的新建事件，两者合并为一个MOVE事件回调；
+    // 时间窗口内没有关联的新建事件时(例如移出了监听的路径)，以旧路径的REMOVE事件回调。
+    // 对于只注册了旧路径(没有注册新路径)的回调，移动同样以旧路径的REMOVE事件回调。
+    MOVE
 )
 
 const (
-    REPEAT_EVENT_FILTER_INTERVAL = 1 // (毫秒)重复事件过滤间隔
-    DEFAULT_WATCHER_COUNT        = 4 // 默认创建的监控对象数量(使用哈希取模)
+    REPEAT_EVENT_FILTER_INTERVAL = 1   // (毫秒)重复事件过滤间隔
+    RENAME_CORRELATE_INTERVAL    = 100 // (毫秒)重命名事件与随后的新建事件关联为移动事件(MOVE)的时间窗口，真实的重命名事件最长会被延迟该时间回调
+    DEFAULT_WATCHER_COUNT        = 4   // 默认创建的监控对象数量(使用哈希取模)
 )
 
 var (
-    // 全局监听对象，方便应用端调用
-    watchers     []*Watcher
-    // 全局默认的监听watcher数量
-    watcherCount int
-    // 默认的watchers是否初始化，使用时才创建
-    watcherInited  = gtype.NewBool()
+    // 全局监听对象，方便应用端调用，使用时才创建，关闭后再次使用时重新创建
+    watchers       []*Watcher
+    // 全局监听对象互斥锁
+    watcherMu      sync.Mutex
+    // 全局监听对象最近一次初始化失败的错误信息
+    watcherError   error
+    // 全局监听对象的底层监听错误处理方法，初始化成功后同步设置到每一个watcher
+    watcherHandler = gtype.NewInterface()
+    // 全局监听对象添加目录监听时未指定是否递归的默认值，初始化成功后同步设置到每一个watcher
+    watcherRecurse = gtype.NewBool(true)
+    // 监听对象ID自增序列
+    watcherIdSeq   = gtype.NewInt()
     // 回调方法ID与对象指针的映射哈希表，用于根据ID快速查找回调对象
     callbackIdMap  = gmap.NewIntInterfaceMap()
 )
 
-// 初始化创建watcher对象，用于包默认管理监听
-func initWatcher() {
-    if !watcherInited.Set(true) {
-        // 默认的创建的inotify数量
-        watcherCount = gconv.Int(genv.Get("GF_INOTIFY_COUNT"))
-        if watcherCount == 0 {
-            watcherCount = gconv.Int(gcmd.Option.Get("gf.inotify-count"))
-        }
-        if watcherCount == 0 {
-            watcherCount = DEFAULT_WATCHER_COUNT
-        }
-        watchers = make([]*Watcher, watcherCount)
-        for i := 0; i < watcherCount; i++ {
-            if w, err := New(); err == nil {
-                watchers[i] = w
-            } else {
-                panic(err)
+// 获取全局监听对象列表，如果尚未初始化则创建watcher对象，用于包默认管理监听。
+// 如果初始化失败(例如inotify句柄数量达到系统限制)，会记录失败的错误信息，并在下一次调用时重新尝试初始化。
+func initWatcher() ([]*Watcher, error) {
+    watcherMu.Lock()
+    defer watcherMu.Unlock()
+    if watchers != nil {
+        return watchers, nil
+    }
+    // 默认的创建的inotify数量
+    count := gconv.Int(genv.Get("GF_INOTIFY_COUNT"))
+    if count == 0 {
+        count = gconv.Int(gcmd.Option.Get("gf.inotify-count"))
+    }
+    if count == 0 {
+        count = DEFAULT_WATCHER_COUNT
+    }
+    array := make([]*Watcher, count)
+    for i := 0; i < count; i++ {
+        if w, err := New(); err == nil {
+            if handler, ok := watcherHandler.Val().(func(err error)); ok {
+                w.SetErrorHandler(handler)
             }
+            w.SetDefaultRecursive(watcherRecurse.Val())
+            array[i] = w
+        } else {
+            // 释放已经创建的watcher，避免占用系统的inotify句柄
+            for j := 0; j < i; j++ {
+                array[j].Close()
+            }
+            watcherError = errors.New(fmt.Sprintf(`global watcher creating failed: %s`, err.Error()))
+            return nil, watcherError
+        }
+    }
+    watchers     = array
+    watcherError = nil
+    return watchers, nil
+}
+
+// 关闭全局监听对象，所有通过包方法添加的监听都将被移除(等待事件循环退出后返回)，再次使用包方法时会重新创建全局监听对象
+func Close() error {
+    watcherMu.Lock()
+    defer watcherMu.Unlock()
+    return closeWatchers()
+}
+
+// 关闭并重新创建全局监听对象，所有通过包方法添加的监听都将被移除
+func Reset() error {
+    watcherMu.Lock()
+    err := closeWatchers()
+    watcherMu.Unlock()
+    if err != nil {
+        return err
+    }
+    _, err = initWatcher()
+    return err
+}
+
+// 关闭全局监听对象，需要在全局监听对象的锁中调用
+func closeWatchers() error {
+    errs := make([]string, 0)
+    for _, w := range watchers {
+        if err := w.Close(); err != nil {
+            errs = append(errs, err.Error())
         }
     }
+    watchers = nil
+    if len(errs) > 0 {
+        return errors.New(strings.Join(errs, "; "))
+    }
+    return nil
+}
+
+// 获取全局监听对象最近一次初始化失败的错误信息，初始化成功或者尚未初始化时返回nil
+func InitError() error {
+    watcherMu.Lock()
+    defer watcherMu.Unlock()
+    return watcherError
 }
 
-// 创建监听管理对象，主要注意的是创建监听对象会占用系统的inotify句柄数量，受到 fs.inotify.max_user_instances 的限制
-func New() (*Watcher, error) {
-    if watch, err := fsnotify.NewWatcher(); err == nil {
+// 创建监听管理对象，主要注意的是创建监听对象会占用系统的inotify句柄数量，受到 fs.inotify.max_user_instances 的限制。
+// options为非必需参数，用于设置监听管理对象的配置项，例如：New(WithCapacity(10000))。
+func New(options...WatcherOption) (*Watcher, error) {
+    option := WatcherOption{ clock : realClock{} }
+    for _, v := range options {
+        option = option.merge(v)
+    }
+    var watch backend
+    var err   error
+    if option.PollInterval > 0 {
+        watch = newPollWatcher(option.PollInterval)
+    } else {
+        watch, err = newFsnotifyBackend()
+    }
+    if err == nil {
         w := &Watcher {
-            cache         : gcache.New(),
-            watcher       : watch,
-            events        : gqueue.New(),
-            closeChan     : make(chan struct{}),
-            callbacks     : gmap.NewStringInterfaceMap(),
+            id             : watcherIdSeq.Add(1),
+            watcher        : watch,
+            closeChan      : make(chan struct{}),
+            closed         : gtype.NewBool(),
+            errorHandler   : gtype.NewInterface(),
+            logger         : gtype.NewInterface(),
+            debug          : gtype.NewBool(),
+            callbacks      : gmap.NewStringInterfaceMap(),
+            debounces      : make(map[debounceKey]*debounceItem),
+            stats          : newWatcherStats(),
+            serialDispatch : option.SerialDispatch,
+            serials        : make(map[string]*serialQueue),
+            linkParents    : make(map[string]int),
+            clock          : option.clock,
+            recursive      : gtype.NewBool(true),
         }
+        w.SetLogger(option.Logger)
+        w.events = newEventQueue(option.Capacity, option.DropOldest, func(event *Event) {
+            w.stats.dropped.Add(1)
+            if option.OnOverflow != nil {
+                option.OnOverflow(event)
+            }
+        })
+        w.startWorkers(option.Workers)
         w.startWatchLoop()
         w.startEventLoop()
         return w, nil
@@ -118,18 +256,110 @@ func New() (*Watcher, error) {
     }
 }
 
-// 添加对指定文件/目录的监听，并给定回调函数；如果给定的是一个目录，默认递归监控。
-func Add(path string, callbackFunc func(event *Event), recursive...bool) (callback *Callback, err error) {
-    return getWatcherByPath(path).Add(path, callbackFunc, recursive...)
+// 创建监听管理对象，并将其生命周期绑定到给定的ctx，当ctx被取消时自动关闭该监听管理对象。
+// 在ctx取消之前或者之后显式调用Close都是安全的。
+func NewWithContext(ctx context.Context, options...WatcherOption) (*Watcher, error) {
+    w, err := New(options...)
+    if err != nil {
+        return nil, err
+    }
+    go func() {
+        select {
+            case <- ctx.Done():
+                w.Close()
+            case <- w.closeChan:
+        }
+    }()
+    return w, nil
+}
+
+// 添加对指定文件/目录的监听，并给定回调函数；如果给定的是一个目录，默认递归监控(可以通过SetDefaultRecursive修改默认值)。
+// options参数支持bool(是否递归监听)及WatchOption(监听配置项)类型。
+func Add(path string, callbackFunc func(event *Event), options...interface{}) (callback *Callback, err error) {
+    w, err := getWatcherByPath(path)
+    if err != nil {
+        return nil, err
+    }
+    return w.Add(path, callbackFunc, options...)
+}
+
+// 添加监听，如果该路径已经存在相同回调函数的注册，那么不再重复注册，直接返回已存在的回调对象
+func AddUnique(path string, callbackFunc func(event *Event), options...interface{}) (callback *Callback, err error) {
+    w, err := getWatcherByPath(path)
+    if err != nil {
+        return nil, err
+    }
+    return w.AddUnique(path, callbackFunc, options...)
+}
+
+// 使用同一个回调函数批量添加多个文件/目录的监听，返回的回调对象管理所有添加成功的路径，详见Watcher.AddPaths
+func AddPaths(paths []string, callbackFunc func(event *Event), options...interface{}) (callback *Callback, err error) {
+    if len(paths) == 0 {
+        return nil, errors.New("no path given")
+    }
+    w, err := getWatcherByPath(paths[0])
+    if err != nil {
+        return nil, err
+    }
+    // 每一个路径使用各自对应的全局监听对象，与Add/Remove保持一致
+    return w.addPaths(paths, callbackFunc, options, getWatcherByPath)
+}
+
+// 添加一次性的监听，回调函数只会执行一次，随后自动移除该回调
+func AddOnce(path string, callbackFunc func(event *Event), options...interface{}) (callback *Callback, err error) {
+    w, err := getWatcherByPath(path)
+    if err != nil {
+        return nil, err
+    }
+    return w.AddOnce(path, callbackFunc, options...)
+}
+
+// 添加批量回调的监听，时间窗口内监听路径下所有的事件会汇总(同一路径只保留最后一次事件)后回调一次
+func AddBatch(path string, callbackFunc func(events []*Event), window time.Duration, options...interface{}) (callback *Callback, err error) {
+    w, err := getWatcherByPath(path)
+    if err != nil {
+        return nil, err
+    }
+    return w.AddBatch(path, callbackFunc, window, options...)
+}
+
+// 订阅指定文件/目录的监听事件，事件将会写入返回的通道中，调用cancel方法取消订阅并关闭通道
+func Subscribe(path string, options...interface{}) (events <-chan *Event, cancel func(), err error) {
+    w, err := getWatcherByPath(path)
+    if err != nil {
+        return nil, nil, err
+    }
+    return w.Subscribe(path, options...)
 }
 
 // 递归移除对指定文件/目录的所有监听回调
 func Remove(path string) error {
-    return getWatcherByPath(path).Remove(path)
+    w, err := getWatcherByPath(path)
+    if err != nil {
+        return err
+    }
+    return w.Remove(path)
+}
+
+// 只移除对指定文件/目录本身的所有监听回调，不会移除该目录下单独添加的其他文件/目录的监听
+func RemoveSingle(path string) error {
+    w, err := getWatcherByPath(path)
+    if err != nil {
+        return err
+    }
+    return w.RemoveSingle(path)
+}
+
+// 根据Add返回的回调对象移除指定的监听回调，同一路径下的其他回调不受影响
+func RemoveCallback(callback *Callback) error {
+    if callback == nil || callback.watcher == nil {
+        return errors.New("invalid callback")
+    }
+    return callback.watcher.RemoveCallback(callback)
 }
 
 // 根据指定的回调函数ID，移出指定的inotify回调函数
-func RemoveCallback(callbackId int) error {
+func RemoveCallbackById(callbackId int) error {
     callback := (*Callback)(nil)
     if r := callbackIdMap.Get(callbackId); r != nil {
         callback = r.(*Callback)
@@ -137,11 +367,60 @@ func RemoveCallback(callbackId int) error {
     if callback == nil {
         return errors.New(fmt.Sprintf(`callback for id %d not found`, callbackId))
     }
-    return getWatcherByPath(callback.Path).RemoveCallback(callbackId)
+    return RemoveCallback(callback)
+}
+
+// 设置全局监听对象的底层监听错误处理方法，未设置时默认使用日志对象输出错误信息
+func SetErrorHandler(handler func(err error)) {
+    watcherMu.Lock()
+    defer watcherMu.Unlock()
+    watcherHandler.Set(handler)
+    for _, w := range watchers {
+        w.SetErrorHandler(handler)
+    }
+}
+
+// 设置全局监听对象(包方法Add/AddUnique/AddOnce/AddBatch/Subscribe等)添加目录监听时，未指定是否递归的默认值，默认为递归监听。
+// 该设置是进程全局的，对已经添加的监听不生效，也不影响显式指定了是否递归(bool参数)的调用以及通过New创建的监听对象。
+func SetDefaultRecursive(recursive bool) {
+    watcherMu.Lock()
+    defer watcherMu.Unlock()
+    watcherRecurse.Set(recursive)
+    for _, w := range watchers {
+        w.SetDefaultRecursive(recursive)
+    }
+}
+
+// 阻塞等待，直到全局监听对象被关闭，全局监听对象初始化失败时立即返回
+func Wait() {
+    array, err := initWatcher()
+    if err != nil {
+        return
+    }
+    for _, w := range array {
+        w.Wait()
+    }
+}
+
+// 获取全局监听对象当前已注册监听的文件/目录路径列表(快照，按照路径排序)
+func Paths() []string {
+    paths      := make([]string, 0)
+    array, err := initWatcher()
+    if err != nil {
+        return paths
+    }
+    for _, w := range array {
+        paths = append(paths, w.callbacks.Keys()...)
+    }
+    sort.Strings(paths)
+    return paths
 }
 
 // 根据path计算对应的watcher对象
-func getWatcherByPath(path string) *Watcher {
-    initWatcher()
-    return watchers[ghash.BKDRHash([]byte(path)) % uint32(watcherCount)]
+func getWatcherByPath(path string) (*Watcher, error) {
+    array, err := initWatcher()
+    if err != nil {
+        return nil, err
+    }
+    return array[ghash.BKDRHash([]byte(path)) % uint32(len(array))], nil
 }
@@ -0,0 +1,43 @@
+// Copyright 2018 gf Author(https://gitee.com/johng/gf). All Rights Reserved.
+//
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT was not distributed with this file,
+// You can obtain one at https://gitee.com/johng/gf.
+
+package gfsnotify
+
+import (
+    "gitee.com/johng/gf/third/github.com/fsnotify/fsnotify"
+)
+
+// 底层监听接口，默认使用fsnotify(inotify/kqueue/ReadDirectoryChangesW)，也可以使用轮询方式(见WithPolling)。
+// 监听循环只通过该接口读取底层事件，因此不同的底层实现产生的事件对于回调是完全一致的。
+type backend interface {
+    Add(path string) error            // 添加对文件/目录的监听，目录只监听其本身及直属的文件/目录
+    Remove(path string) error         // 移除对文件/目录的监听，不在监听中时返回包含"non-existent"的错误
+    Close() error                     // 关闭底层监听，关闭后事件通道及错误通道将被关闭
+    eventChan() <-chan fsnotify.Event // 底层事件通道
+    errorChan() <-chan error          // 底层错误通道
+}
+
+// 基于fsnotify的底层监听
+type fsnotifyBackend struct {
+    *fsnotify.Watcher
+}
+
+// 创建基于fsnotify的底层监听
+func newFsnotifyBackend() (backend, error) {
+    watcher, err := fsnotify.NewWatcher()
+    if err != nil {
+        return nil, err
+    }
+    return &fsnotifyBackend{ watcher }, nil
+}
+
+func (b *fsnotifyBackend) eventChan() <-chan fsnotify.Event {
+    return b.Events
+}
+
+func (b *fsnotifyBackend) errorChan() <-chan error {
+    return b.Errors
+}
@@ -0,0 +1,89 @@
+// Copyright 2018 gf Author(https://gitee.com/johng/gf). All Rights Reserved.
+//
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT was not distributed with this file,
+// You can obtain one at https://gitee.com/johng/gf.
+
+package gfsnotify
+
+import (
+    "errors"
+    "fmt"
+    "sync"
+    "time"
+)
+
+const (
+    DEFAULT_BATCH_WINDOW = 100*time.Millisecond // 默认的批量回调时间窗口
+)
+
+// 添加批量回调的监听，与WithDebounce针对单个路径的事件合并不同，这里将监听路径下(包括递归添加的子级路径)所有路径的事件
+// 在时间窗口内进行汇总：窗口从第一个事件开始计时，窗口结束后只回调一次，参数为窗口期内去重后的事件列表
+// (同一路径只保留最后一次的事件，按照路径首次回调的先后顺序排列)，适用于例如编辑器"全部保存"后统一触发构建的场景。
+// 监听的根路径被删除时会立即回调(包含该删除事件)，不再等待窗口结束。
+// window小于等于0时使用默认的时间窗口(DEFAULT_BATCH_WINDOW)，options参数同Add方法。
+// 批量回调之间串行执行，回调方法中产生的panic同样会交由错误处理方法处理。
+func (w *Watcher) AddBatch(path string, callbackFunc func(events []*Event), window time.Duration, options...interface{}) (callback *Callback, err error) {
+    if window <= 0 {
+        window = DEFAULT_BATCH_WINDOW
+    }
+    var (
+        mu      sync.Mutex
+        callMu  sync.Mutex
+        timer   clockTimer
+        paths   = make([]string, 0)
+        pending = make(map[string]*Event)
+        ready   = make(chan struct{})
+    )
+    // 回调窗口期内汇总的事件，并清空汇总结果
+    flush := func() {
+        mu.Lock()
+        if timer != nil {
+            timer.Stop()
+            timer = nil
+        }
+        events := make([]*Event, 0, len(paths))
+        for _, v := range paths {
+            events = append(events, pending[v])
+        }
+        paths   = make([]string, 0)
+        pending = make(map[string]*Event)
+        mu.Unlock()
+        // 监听对象关闭后不再回调
+        if len(events) == 0 || w.closed.Val() {
+            return
+        }
+        callMu.Lock()
+        defer callMu.Unlock()
+        defer func() {
+            if r := recover(); r != nil {
+                w.handleError(errors.New(fmt.Sprintf(`batch callback panic on "%s" with %d events: %v`, callback.Path, len(events), r)))
+            }
+        }()
+        w.stats.invoked.Add(1)
+        callbackFunc(events)
+    }
+    callback, err = w.Add(path, func(event *Event) {
+        // 等待Add方法返回，保证能够获取到回调对象
+        <- ready
+        mu.Lock()
+        // 回调默认异步执行，到达的先后顺序不一定与事件的先后顺序一致，因此按照事件时间保留最后一次的事件
+        if old, ok := pending[event.Path]; !ok {
+            paths = append(paths, event.Path)
+            pending[event.Path] = event
+        } else if event.time >= old.time {
+            pending[event.Path] = event
+        }
+        if event.IsRemove() && event.Path == callback.Path {
+            mu.Unlock()
+            flush()
+            return
+        }
+        if timer == nil {
+            timer = w.clock.AfterFunc(window, flush)
+        }
+        mu.Unlock()
+    }, options...)
+    close(ready)
+    return
+}
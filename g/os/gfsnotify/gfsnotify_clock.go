@@ -0,0 +1,45 @@
+// Copyright 2018 gf Author(https://gitee.com/johng/gf). All Rights Reserved.
+//
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT was not distributed with this file,
+// You can obtain one at https://gitee.com/johng/gf.
+
+package gfsnotify
+
+import (
+    "time"
+)
+
+// 时间源，事件时间、重复事件过滤、重命名关联、事件合并(Debounce)以及批量回调(AddBatch)的时间窗口均通过时间源获取，
+// 默认使用系统时间，测试时可以通过withClock替换为可控的时间源，从而不依赖time.Sleep进行确定性的测试。
+type clock interface {
+    Now() time.Time                                 // 当前时间
+    AfterFunc(d time.Duration, f func()) clockTimer // 在d时间之后异步执行f，同time.AfterFunc
+}
+
+// 时间源的定时器，同*time.Timer
+type clockTimer interface {
+    Stop() bool
+    Reset(d time.Duration) bool
+}
+
+// 系统时间源
+type realClock struct {}
+
+func (realClock) Now() time.Time {
+    return time.Now()
+}
+
+func (realClock) AfterFunc(d time.Duration, f func()) clockTimer {
+    return time.AfterFunc(d, f)
+}
+
+// 配置项：时间源(仅用于测试)
+func withClock(c clock) WatcherOption {
+    return WatcherOption{ clock : c }
+}
+
+// 获取时间源的当前时间(毫秒)
+func (w *Watcher) millisecond() int64 {
+    return w.clock.Now().UnixNano() / int64(time.Millisecond)
+}
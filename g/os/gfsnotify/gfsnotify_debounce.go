@@ -0,0 +1,67 @@
+// Copyright 2018 gf Author(https://gitee.com/johng/gf). All Rights Reserved.
+//
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT was not distributed with this file,
+// You can obtain one at https://gitee.com/johng/gf.
+
+package gfsnotify
+
+// 事件合并的检索键名，同一回调对象下的同一路径事件会被合并
+type debounceKey struct {
+    callback *Callback
+    path     string
+}
+
+// 等待合并回调的事件项
+type debounceItem struct {
+    timer    clockTimer  // 合并窗口定时器
+    event    *Event      // 窗口期内最后一次的事件
+}
+
+// 将事件加入合并窗口，窗口期内同一路径的后续事件会覆盖之前的事件，并重新计时；
+// 当窗口期结束后，使用最后一次事件执行回调。
+// 删除事件不会被合并，而是立即执行回调(同时丢弃窗口期内等待的事件)。
+func (w *Watcher) debounceEvent(callback *Callback, event *Event) {
+    key := debounceKey{callback, event.Path}
+    w.debounceMu.Lock()
+    defer w.debounceMu.Unlock()
+    if item, ok := w.debounces[key]; ok {
+        if event.IsRemove() {
+            item.timer.Stop()
+            delete(w.debounces, key)
+            w.dispatch(callback, event)
+            return
+        }
+        item.event = event
+        item.timer.Reset(callback.option.Debounce)
+        return
+    }
+    if event.IsRemove() {
+        w.dispatch(callback, event)
+        return
+    }
+    item := &debounceItem{ event : event }
+    item.timer = w.clock.AfterFunc(callback.option.Debounce, func() {
+        w.debounceMu.Lock()
+        // 如果已被取消(例如监听对象已关闭)或者已被替换，那么不再执行回调
+        if w.debounces[key] != item {
+            w.debounceMu.Unlock()
+            return
+        }
+        delete(w.debounces, key)
+        event := item.event
+        w.debounceMu.Unlock()
+        w.dispatch(callback, event)
+    })
+    w.debounces[key] = item
+}
+
+// 取消所有等待合并回调的事件
+func (w *Watcher) cancelDebounces() {
+    w.debounceMu.Lock()
+    for key, item := range w.debounces {
+        item.timer.Stop()
+        delete(w.debounces, key)
+    }
+    w.debounceMu.Unlock()
+}
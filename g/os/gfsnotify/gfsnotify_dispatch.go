@@ -0,0 +1,67 @@
+// Copyright 2018 gf Author(https://gitee.com/johng/gf). All Rights Reserved.
+//
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT was not distributed with this file,
+// You can obtain one at https://gitee.com/johng/gf.
+
+package gfsnotify
+
+import (
+    "container/list"
+)
+
+// 回调任务项(用于串行回调队列及回调工作协程)
+type serialTask struct {
+    callback *Callback
+    event    *Event
+}
+
+// 同一路径的串行回调队列
+type serialQueue struct {
+    tasks    *list.List // 等待执行的回调任务
+    running  bool       // 是否有goroutine正在执行该队列的回调任务
+}
+
+// 分发事件到回调方法。
+// 默认每一次回调都使用单独的goroutine异步执行；开启串行分发时，同一路径的回调按照事件的先后顺序依次执行，
+// 不同路径的回调仍然并发执行；开启工作协程时，回调由路径对应的工作协程执行。
+func (w *Watcher) dispatch(callback *Callback, event *Event) {
+    if len(w.workers) > 0 {
+        w.dispatchToWorker(callback, event)
+        return
+    }
+    if !w.serialDispatch {
+        go w.callFunc(callback, event)
+        return
+    }
+    w.serialMu.Lock()
+    defer w.serialMu.Unlock()
+    queue, ok := w.serials[event.Path]
+    if !ok {
+        queue = &serialQueue{ tasks : list.New() }
+        w.serials[event.Path] = queue
+    }
+    queue.tasks.PushBack(serialTask{callback, event})
+    if !queue.running {
+        queue.running = true
+        go w.runSerialQueue(event.Path, queue)
+    }
+}
+
+// 依次执行指定路径的串行回调队列，队列为空时退出并删除该队列
+func (w *Watcher) runSerialQueue(path string, queue *serialQueue) {
+    for {
+        w.serialMu.Lock()
+        e := queue.tasks.Front()
+        if e == nil {
+            queue.running = false
+            delete(w.serials, path)
+            w.serialMu.Unlock()
+            return
+        }
+        queue.tasks.Remove(e)
+        w.serialMu.Unlock()
+        task := e.Value.(serialTask)
+        w.callFunc(task.callback, task.event)
+    }
+}
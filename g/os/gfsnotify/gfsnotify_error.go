@@ -0,0 +1,49 @@
+// Copyright 2018 gf Author(https://gitee.com/johng/gf). All Rights Reserved.
+//
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT was not distributed with this file,
+// You can obtain one at https://gitee.com/johng/gf.
+
+package gfsnotify
+
+import (
+    "errors"
+    "fmt"
+    "strings"
+    "syscall"
+)
+
+// 底层监听数量达到系统限制的错误(Linux下为 fs.inotify.max_user_watches)
+var ErrWatchLimitReached = errors.New("watch limit reached")
+
+// 监听管理对象已被关闭的错误(例如Tail在监听管理对象关闭时返回)
+var ErrWatcherClosed = errors.New("watcher closed")
+
+// 底层监听数量达到系统限制时交由错误处理方法处理的错误对象，
+// 可以通过类型断言获取详细信息，例如：if e, ok := err.(*WatchLimitError); ok { ... }
+type WatchLimitError struct {
+    Path  string // 添加监听失败的文件/目录
+    Count int    // 当前已注册监听的文件/目录数量
+    Err   error  // 底层错误
+}
+
+func (e *WatchLimitError) Error() string {
+    path := ""
+    if e.Path != "" {
+        path = fmt.Sprintf(` while watching "%s"`, e.Path)
+    }
+    return fmt.Sprintf(
+        `%s%s with %d paths registered, consider increasing fs.inotify.max_user_watches: %v`,
+        ErrWatchLimitReached.Error(), path, e.Count, e.Err,
+    )
+}
+
+// 便于使用errors.Is(err, ErrWatchLimitReached)进行判断
+func (e *WatchLimitError) Unwrap() error {
+    return ErrWatchLimitReached
+}
+
+// 判断是否为底层监听数量达到系统限制的错误(ENOSPC)
+func isWatchLimitError(err error) bool {
+    return err == syscall.ENOSPC || strings.Contains(err.Error(), "no space left on device")
+}
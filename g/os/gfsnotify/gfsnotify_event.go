@@ -6,8 +6,47 @@
 
 package gfsnotify
 
+import (
+    "fmt"
+    "strings"
+)
+
+// 操作名称，按照位顺序排列
+var opNames = []struct {
+    op   Op
+    name string
+}{
+    {CREATE, "CREATE"},
+    {WRITE,  "WRITE"},
+    {REMOVE, "REMOVE"},
+    {RENAME, "RENAME"},
+    {CHMOD,  "CHMOD"},
+    {MOVE,   "MOVE"},
+}
+
+// 操作的字符串表示，组合操作使用'|'连接，例如：CREATE|WRITE；
+// 零值返回空字符串，未知的操作位使用十六进制表示，例如：WRITE|0x40。
+func (op Op) String() string {
+    names := make([]string, 0)
+    for _, v := range opNames {
+        if op & v.op == v.op {
+            names = append(names, v.name)
+            op &^= v.op
+        }
+    }
+    if op != 0 {
+        names = append(names, fmt.Sprintf("0x%x", uint32(op)))
+    }
+    return strings.Join(names, "|")
+}
+
+// 事件的字符串表示，包含文件路径、操作及所属的监听对象ID，例如："/tmp/test.txt": WRITE (watcher 1)
 func (e *Event) String() string {
-    return e.event.String()
+    id := 0
+    if e.Watcher != nil {
+        id = e.Watcher.id
+    }
+    return fmt.Sprintf(`"%s": %s (watcher %d)`, e.Path, e.Op.String(), id)
 }
 
 // 文件/目录创建
@@ -34,3 +73,8 @@ func (e *Event) IsRename() bool {
 func (e *Event) IsChmod() bool {
     return  e.Op & CHMOD == CHMOD
 }
+
+// 文件/目录在监听的路径内移动(重命名)
+func (e *Event) IsMove() bool {
+    return  e.Op & MOVE == MOVE
+}
@@ -32,6 +32,17 @@ func fileRealPath(path string) string {
     return p
 }
 
+// 将所给定的路径转换为绝对路径，路径存在时返回真实路径，不存在时(例如已被删除)返回其绝对路径
+func fileAbsPath(path string) string {
+    if t := fileRealPath(path); t != "" {
+        return t
+    }
+    if t, err := filepath.Abs(path); err == nil {
+        return t
+    }
+    return path
+}
+
 // 判断所给路径文件/文件夹是否存在
 func fileExists(path string) bool {
     if _, err := os.Stat(path); !os.IsNotExist(err) {
@@ -93,4 +104,69 @@ func fileDoScanDir(path string, pattern string, recursive ... bool) ([]string, e
         }
     }
     return list, nil
-}
\ No newline at end of file
+}
+
+// 递归检索目录，返回排序后的文件绝对路径列表，filter用于自定义过滤：
+// 返回false的文件/目录不会加入结果列表，并且返回false的目录不会继续递归检索。
+// follow表示是否进入指向目录的符号链接继续检索，进入时会跳过指向其上级目录的符号链接，避免循环链接造成无限递归。
+func fileScanDirFunc(path string, follow bool, filter func(path string, isDir bool) bool) ([]string, error) {
+    ancestors := make(map[string]bool)
+    if real, err := filepath.EvalSymlinks(path); err == nil {
+        ancestors[real] = true
+    }
+    list, err := fileDoScanDirFunc(path, follow, ancestors, filter)
+    if err != nil {
+        return nil, err
+    }
+    if len(list) > 0 {
+        sort.Strings(list)
+    }
+    return list, nil
+}
+
+// 内部递归检索目录方法，返回没有排序的文件绝对路径列表结果，ancestors为当前检索目录及其上级目录的真实路径集合。
+func fileDoScanDirFunc(path string, follow bool, ancestors map[string]bool, filter func(path string, isDir bool) bool) ([]string, error) {
+    var list []string
+    dfile, err := os.Open(path)
+    if err != nil {
+        return nil, err
+    }
+    defer dfile.Close()
+    names, err := dfile.Readdirnames(-1)
+    if err != nil {
+        return nil, err
+    }
+    for _, name := range names {
+        path  := fmt.Sprintf("%s%s%s", path, string(filepath.Separator), name)
+        isDir := fileIsDir(path)
+        real  := ""
+        if isDir {
+            real, err = filepath.EvalSymlinks(path)
+            if err != nil {
+                continue
+            }
+            if fileIsLink(path) {
+                // 不进入符号链接时，指向目录的符号链接作为普通文件(链接本身)处理；
+                // 进入符号链接时，跳过指向上级目录的循环链接
+                if !follow {
+                    isDir = false
+                } else if ancestors[real] {
+                    continue
+                }
+            }
+        }
+        if !filter(path, isDir) {
+            continue
+        }
+        list = append(list, path)
+        if isDir {
+            ancestors[real] = true
+            array, _ := fileDoScanDirFunc(path, follow, ancestors, filter)
+            delete(ancestors, real)
+            if len(array) > 0 {
+                list = append(list, array...)
+            }
+        }
+    }
+    return list, nil
+}
@@ -0,0 +1,111 @@
+// Copyright 2018 gf Author(https://gitee.com/johng/gf). All Rights Reserved.
+//
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT was not distributed with this file,
+// You can obtain one at https://gitee.com/johng/gf.
+
+package gfsnotify
+
+import (
+    "errors"
+    "fmt"
+    "path/filepath"
+    "strings"
+)
+
+// 按照glob规则添加监听，只监听满足规则的文件，例如：AddGlob("/data/config/**/*.yaml", callback)。
+// 规则中不包含通配符的前缀部分作为监听的根目录(例如/data/config)，其余部分按照层级匹配相对于根目录的路径：
+// "**"匹配零个或者多个层级，其他层级按照filepath.Match的规则匹配名称。
+// 与递归监听整个目录后再过滤不同，只有其下可能存在满足规则的文件的目录才会被监听，
+// 回调也只会收到满足规则的文件的事件(包括添加监听之后新建的满足规则的文件)；
+// 新建的目录满足规则时会自动添加监听，使得其下新建的文件同样能够被监听到。
+// options参数同Add方法(始终为递归监听)，返回的回调对象管理根目录及其下自动添加的所有监听，通过RemoveCallback移除。
+func (w *Watcher) AddGlob(pattern string, callbackFunc func(event *Event), options...interface{}) (callback *Callback, err error) {
+    root, glob, err := parseGlob(pattern)
+    if err != nil {
+        return nil, err
+    }
+    // 复制调用方传入的options后再追加，避免修改调用方切片的底层数组
+    opts := append(append([]interface{}{}, options...), true, WatchOption{ glob : glob })
+    return w.Add(root, callbackFunc, opts...)
+}
+
+// 按照glob规则添加监听，只监听满足规则的文件，详见Watcher.AddGlob
+func AddGlob(pattern string, callbackFunc func(event *Event), options...interface{}) (callback *Callback, err error) {
+    root, _, err := parseGlob(pattern)
+    if err != nil {
+        return nil, err
+    }
+    w, err := getWatcherByPath(root)
+    if err != nil {
+        return nil, err
+    }
+    return w.AddGlob(pattern, callbackFunc, options...)
+}
+
+// 解析glob规则，返回不包含通配符的根目录，以及按照层级拆分的相对于根目录的规则；规则不包含通配符时根目录为文件所在的目录
+func parseGlob(pattern string) (root string, glob []string, err error) {
+    segments := strings.Split(filepath.ToSlash(pattern), "/")
+    index    := 0
+    for index < len(segments) - 1 && !strings.ContainsAny(segments[index], `*?[\`) {
+        index++
+    }
+    for _, v := range segments[index:] {
+        if v == "" {
+            continue
+        }
+        if v != "**" {
+            if _, err := filepath.Match(v, ""); err != nil {
+                return "", nil, errors.New(fmt.Sprintf(`invalid glob pattern "%s": %v`, pattern, err))
+            }
+        }
+        glob = append(glob, v)
+    }
+    if len(glob) == 0 {
+        return "", nil, errors.New(fmt.Sprintf(`invalid glob pattern "%s"`, pattern))
+    }
+    // 规则以"/"开头时根目录为"/"，以通配符开头时根目录为当前工作目录
+    root = strings.Join(segments[:index], "/")
+    if root == "" {
+        if index > 0 {
+            root = "/"
+        } else {
+            root = "."
+        }
+    }
+    return filepath.FromSlash(root), glob, nil
+}
+
+// 获取path相对于root按照层级拆分的名称列表，path为root本身时返回空列表
+func relativeSegments(root, path string) []string {
+    relative := strings.Trim(strings.TrimPrefix(path, root), string(filepath.Separator))
+    if relative == "" {
+        return nil
+    }
+    return strings.Split(relative, string(filepath.Separator))
+}
+
+// 判断按照层级拆分的相对路径是否满足glob规则；prefix为true时判断该路径下的子级路径是否可能满足glob规则(用于目录)
+func matchGlob(glob []string, segments []string, prefix bool) bool {
+    if len(segments) == 0 {
+        if prefix {
+            return len(glob) > 0
+        }
+        for _, v := range glob {
+            if v != "**" {
+                return false
+            }
+        }
+        return true
+    }
+    if len(glob) == 0 {
+        return false
+    }
+    if glob[0] == "**" {
+        return matchGlob(glob[1:], segments, prefix) || matchGlob(glob, segments[1:], prefix)
+    }
+    if match, err := filepath.Match(glob[0], segments[0]); err != nil || !match {
+        return false
+    }
+    return matchGlob(glob[1:], segments[1:], prefix)
+}
@@ -0,0 +1,59 @@
+// Copyright 2018 gf Author(https://gitee.com/johng/gf). All Rights Reserved.
+//
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT was not distributed with this file,
+// You can obtain one at https://gitee.com/johng/gf.
+
+package gfsnotify
+
+import (
+    "gitee.com/johng/gf/g/os/glog"
+)
+
+// 监听管理对象的日志接口，*glog.Logger实现了该接口
+type Logger interface {
+    Error(v ...interface{})
+    Debug(v ...interface{})
+}
+
+// 默认的日志对象，使用glog.Category("gfsnotify")分类日志对象输出日志，
+// 可以通过glog.Category("gfsnotify")单独设置监听模块的日志等级及输出
+type defaultLogger struct {}
+
+func (l defaultLogger) Error(v ...interface{}) {
+    glog.Category("gfsnotify").Error(v...)
+}
+
+func (l defaultLogger) Debug(v ...interface{}) {
+    glog.Category("gfsnotify").Debug(v...)
+}
+
+// 日志对象的存储包装，保证并发安全容器中存储的数据类型一致
+type loggerHolder struct {
+    logger Logger
+}
+
+// 设置监听管理对象的日志对象，传递nil表示恢复使用默认的glog.Category("gfsnotify")输出日志
+func (w *Watcher) SetLogger(logger Logger) {
+    if logger == nil {
+        logger = defaultLogger{}
+    }
+    w.logger.Set(loggerHolder{logger})
+}
+
+// 设置是否输出监听循环及事件循环的调试日志(通过日志对象的Debug方法输出)
+func (w *Watcher) SetDebug(debug bool) {
+    w.debug.Set(debug)
+}
+
+// 获取监听管理对象的日志对象
+func (w *Watcher) getLogger() Logger {
+    return w.logger.Val().(loggerHolder).logger
+}
+
+// 输出调试日志，未开启调试时不输出
+func (w *Watcher) debugLog(v ...interface{}) {
+    if w.debug.Val() {
+        w.getLogger().Debug(v...)
+    }
+}
@@ -0,0 +1,84 @@
+// Copyright 2018 gf Author(https://gitee.com/johng/gf). All Rights Reserved.
+//
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT was not distributed with this file,
+// You can obtain one at https://gitee.com/johng/gf.
+
+package gfsnotify
+
+import (
+    "errors"
+    "fmt"
+    "os"
+    "sync"
+    "time"
+)
+
+const (
+    DEFAULT_MODIFIED_DEBOUNCE = 100*time.Millisecond // WatchModified默认的事件合并时间窗口
+)
+
+// 监听文件内容的修改，只有文件内容真正发生变化时才会回调，适用于配置文件热加载等场景：
+// 1. 忽略只修改了权限的CHMOD事件，并合并时间窗口内的多次事件(默认为DEFAULT_MODIFIED_DEBOUNCE，可通过WithDebounce修改)；
+// 2. 回调前比较文件的大小及修改时间，与上一次回调(或者添加监听)时相同的事件不会回调；
+// 3. 通过监听文件所在的目录并按照路径过滤事件，编辑器"写入临时文件后重命名覆盖"等保存方式替换了文件之后仍然能够继续监听。
+// 文件内容发生变化时回调WRITE事件，文件被删除时回调REMOVE事件，删除后重新创建时回调WRITE事件，事件的Path始终为监听的文件路径，
+// 回调方法串行执行。返回的回调对象为文件所在目录的监听回调，可以通过RemoveCallback移除。
+// 修改时间的精度取决于文件系统，精度较低(例如秒级)的文件系统下，同一精度内大小不变的修改可能无法识别。
+func (w *Watcher) WatchModified(path string, callbackFunc func(event *Event), options...WatchOption) (callback *Callback, err error) {
+    realPath := fileRealPath(path)
+    if realPath == "" {
+        return nil, errors.New(fmt.Sprintf(`"%s" does not exist`, path))
+    }
+    path = realPath
+    info, err := os.Stat(path)
+    if err != nil {
+        return nil, err
+    }
+    if info.IsDir() {
+        return nil, errors.New(fmt.Sprintf(`"%s" is a directory, while a file is required`, path))
+    }
+    option := WatchOption{ IgnoreChmod : true, Debounce : DEFAULT_MODIFIED_DEBOUNCE }
+    for _, v := range options {
+        option = option.merge(v)
+    }
+    var (
+        mu   sync.Mutex
+        last = newPollStat(info)
+        gone = false
+    )
+    return w.Add(fileDir(path), func(event *Event) {
+        if event.Path != path {
+            return
+        }
+        mu.Lock()
+        defer mu.Unlock()
+        modified := *event
+        modified.OldPath = ""
+        // 事件合并后按照文件当前的状态判断，而不是按照事件的Op判断
+        if info, err := os.Stat(path); err == nil && !info.IsDir() {
+            stat := newPollStat(info)
+            if !gone && stat == last {
+                return
+            }
+            last, gone  = stat, false
+            modified.Op = WRITE
+        } else {
+            if gone {
+                return
+            }
+            last, gone  = pollStat{}, true
+            modified.Op = REMOVE
+        }
+        callbackFunc(&modified)
+    }, false, option)
+}
+
+// 监听文件内容的修改，只有文件内容真正发生变化时才会回调，详见Watcher.WatchModified
+func WatchModified(path string, callbackFunc func(event *Event), options...WatchOption) (callback *Callback, err error) {
+    w, err := getWatcherByPath(path)
+    if err != nil {
+        return nil, err
+    }
+    return w.WatchModified(path, callbackFunc, options...)
+}
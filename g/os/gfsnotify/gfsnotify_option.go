@@ -0,0 +1,319 @@
+// Copyright 2018 gf Author(https://gitee.com/johng/gf). All Rights Reserved.
+//
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT was not distributed with this file,
+// You can obtain one at https://gitee.com/johng/gf.
+
+package gfsnotify
+
+import (
+    "errors"
+    "fmt"
+    "path/filepath"
+    "strings"
+    "time"
+)
+
+// 添加监听时的可选配置项，零值表示使用默认配置
+type WatchOption struct {
+    Debounce      time.Duration // 事件合并的时间窗口，窗口期内同一路径的多个事件只会回调一次(使用最后一次事件的Op)
+    Pattern       string        // 文件名称匹配模式，多个模式使用','分隔，例如："*.go,*.mod"，仅对文件生效，目录始终会被递归监听
+    Exclude       string        // 排除的文件/目录名称匹配模式，多个模式使用','分隔，例如："node_modules,.git"，被排除的目录不会被递归监听
+    Ops           Op            // 关注的文件操作集合(按位组合)，例如：WRITE|CREATE，只有与该集合有交集的事件才会回调，零值表示关注所有操作
+    // 忽略只修改了权限的CHMOD事件(在合并及回调之前过滤)。编辑器及部署工具修改文件内容时通常会同时产生CHMOD和WRITE事件，
+    // 忽略CHMOD事件不会影响WRITE事件的回调，并且开启Debounce时被忽略的CHMOD事件也不会覆盖窗口期内最后一次事件的Op
+    IgnoreChmod   bool
+    // 当监听的路径不存在时，不返回错误，而是监听其最近的已存在的上级目录，
+    // 当路径被创建后自动转换为对该路径的直接监听，并回调CREATE事件
+    WaitForCreate bool
+    // 回调原始的删除事件：默认情况下，删除事件发生后如果文件仍然存在(例如编辑器或者部署工具的原子替换)，
+    // 该事件会被认为是“假删除”并以RENAME事件回调；开启后将以REMOVE事件回调，对该路径的监听仍然保持不变
+    RawRemove     bool
+    InitialEvent  bool          // 添加监听成功后，对已存在的每一个监听路径回调一次初始事件(Op为CREATE，Event.Initial为true)，便于统一"加载+监听"的处理逻辑
+    Symlink       SymlinkMode   // 符号链接的监听方式，默认监听符号链接指向的目标(SYMLINK_FOLLOW)，详见SymlinkMode
+    // 不检查路径是否存在，只转换为绝对路径后直接添加底层监听，底层监听失败时返回错误(默认情况下底层监听的错误会被忽略)，
+    // 用于监听FIFO、设备文件以及部分/proc下的文件等无法正常获取文件信息的特殊文件
+    RawPath       bool
+    maxDepth      int           // 递归监听的最大深度+1，零值表示不限制，只能通过WithMaxDepth设置
+    glob          []string      // 相对于根目录按照层级拆分的glob规则，只能通过AddGlob设置
+}
+
+// 创建监听管理对象时的可选配置项，零值表示使用默认配置
+type WatcherOption struct {
+    // 待处理事件队列的最大长度，零值表示不限制。
+    // 队列满时默认阻塞监听循环：事件不会丢失，积压的事件由底层(内核)队列缓冲，超出内核队列限制时会产生溢出错误并交由错误处理方法处理；
+    // 对于更关注实时性而允许丢失事件的场景，可以设置DropOldest。
+    Capacity       int
+    DropOldest     bool               // 队列满时丢弃最早的待处理事件，而不是阻塞监听循环
+    OnOverflow     func(event *Event) // 队列满时被丢弃事件的回调，在监听循环中同步执行，不宜执行耗时操作
+    // 同一路径的回调按照事件的先后顺序串行执行(不同路径之间仍然并发执行)，默认每一次回调都异步执行，不保证先后顺序
+    SerialDispatch bool
+    Logger         Logger             // 日志对象，用于输出错误信息及调试日志，默认使用glog包方法
+    // 大于0时使用轮询方式代替inotify等内核通知机制，见WithPolling
+    PollInterval   time.Duration
+    // 大于0时使用固定数量的工作协程执行回调，代替每一次回调创建一个goroutine，见WithWorkers
+    Workers        int
+    clock          clock              // 时间源，只能通过withClock设置(仅用于测试)
+}
+
+// 配置项：待处理事件队列的最大长度(队列满时阻塞)
+func WithCapacity(capacity int) WatcherOption {
+    return WatcherOption{ Capacity : capacity }
+}
+
+// 配置项：待处理事件队列满时丢弃最早的事件，并回调给定的方法(可为nil)
+func WithDropOldest(onOverflow func(event *Event)) WatcherOption {
+    return WatcherOption{ DropOldest : true, OnOverflow : onOverflow }
+}
+
+// 配置项：同一路径的回调串行执行
+func WithSerialDispatch() WatcherOption {
+    return WatcherOption{ SerialDispatch : true }
+}
+
+// 配置项：日志对象
+func WithLogger(logger Logger) WatcherOption {
+    return WatcherOption{ Logger : logger }
+}
+
+// 配置项：使用轮询方式监听文件变化，interval为轮询间隔。
+// 适用于inotify等内核通知机制无法工作的文件系统(例如NFS等网络文件系统、部分容器的overlay/bind挂载)，
+// 产生的事件与默认方式一致，通过相同的事件队列及回调处理，但是需要注意：
+// 1. 事件的延迟最长为一个轮询间隔，并且同一轮询间隔内的多次修改只会产生一次事件；
+// 2. 每一个轮询间隔都会对所有监听的文件执行Stat，对所有监听的目录读取目录列表，监听的文件数量较多时CPU及IO开销较大，
+//    需要根据监听的文件数量选择合适的轮询间隔(通常为秒级)；
+// 3. 无法识别重命名(产生旧路径的REMOVE事件以及新路径的CREATE事件)，也不会产生CHMOD事件。
+func WithPolling(interval time.Duration) WatcherOption {
+    return WatcherOption{ PollInterval : interval }
+}
+
+// 配置项：使用n个固定的工作协程执行回调，限制回调的并发数量，避免大量文件变化时创建过多的goroutine。
+// 事件按照路径哈希分配到工作协程，同一路径的回调始终由同一个工作协程按照事件的先后顺序串行执行(此时SerialDispatch不再生效)；
+// 工作协程的待执行队列满时，分发会阻塞直到有空闲位置(阻塞会传递到事件队列，见Capacity)，
+// 工作协程的繁忙程度可以通过Stats的BusyWorkers、PendingTasks及BlockedTasks获取。
+// 需要注意回调方法执行耗时操作时会阻塞同一工作协程上其他路径的回调。
+func WithWorkers(n int) WatcherOption {
+    return WatcherOption{ Workers : n }
+}
+
+// 合并配置项，后者的非零值属性会覆盖前者
+func (o WatcherOption) merge(other WatcherOption) WatcherOption {
+    if other.Capacity > 0 {
+        o.Capacity = other.Capacity
+    }
+    if other.DropOldest {
+        o.DropOldest = true
+    }
+    if other.OnOverflow != nil {
+        o.OnOverflow = other.OnOverflow
+    }
+    if other.SerialDispatch {
+        o.SerialDispatch = true
+    }
+    if other.Logger != nil {
+        o.Logger = other.Logger
+    }
+    if other.PollInterval > 0 {
+        o.PollInterval = other.PollInterval
+    }
+    if other.Workers > 0 {
+        o.Workers = other.Workers
+    }
+    if other.clock != nil {
+        o.clock = other.clock
+    }
+    return o
+}
+
+// 配置项：事件合并(防抖)的时间窗口
+func WithDebounce(interval time.Duration) WatchOption {
+    return WatchOption{ Debounce : interval }
+}
+
+// 配置项：文件名称匹配模式
+func WithPattern(pattern string) WatchOption {
+    return WatchOption{ Pattern : pattern }
+}
+
+// 配置项：排除的文件/目录名称匹配模式
+func WithExclude(exclude string) WatchOption {
+    return WatchOption{ Exclude : exclude }
+}
+
+// 配置项：关注的文件操作集合
+func WithOps(ops Op) WatchOption {
+    return WatchOption{ Ops : ops }
+}
+
+// 配置项：是否忽略只修改了权限的CHMOD事件
+func WithIgnoreChmod(ignore bool) WatchOption {
+    return WatchOption{ IgnoreChmod : ignore }
+}
+
+// 配置项：监听的路径不存在时等待其被创建
+func WithWaitForCreate() WatchOption {
+    return WatchOption{ WaitForCreate : true }
+}
+
+// 配置项：回调原始的删除事件
+func WithRawRemove(raw bool) WatchOption {
+    return WatchOption{ RawRemove : raw }
+}
+
+// 配置项：递归监听的最大深度，depth表示监听到根目录以下的第几层，0表示只监听给定的目录本身，
+// 超出深度的文件/目录不会被添加监听(包括递归添加以及新建时的自动添加)，小于0表示不限制
+func WithMaxDepth(depth int) WatchOption {
+    if depth < 0 {
+        return WatchOption{}
+    }
+    return WatchOption{ maxDepth : depth + 1 }
+}
+
+// 配置项：添加监听成功后回调初始事件
+func WithInitialEvent(initial bool) WatchOption {
+    return WatchOption{ InitialEvent : initial }
+}
+
+// 配置项：是否跟随符号链接，true表示监听符号链接指向的目标(默认)，false表示只监听符号链接本身
+func WithFollowSymlinks(follow bool) WatchOption {
+    if follow {
+        return WatchOption{ Symlink : SYMLINK_FOLLOW }
+    }
+    return WatchOption{ Symlink : SYMLINK_NOFOLLOW }
+}
+
+// 配置项：符号链接的监听方式
+func WithSymlinkMode(mode SymlinkMode) WatchOption {
+    return WatchOption{ Symlink : mode }
+}
+
+// 配置项：不检查路径是否存在，直接添加底层监听
+func WithRawPath() WatchOption {
+    return WatchOption{ RawPath : true }
+}
+
+// 合并配置项，后者的非零值属性会覆盖前者
+func (o WatchOption) merge(other WatchOption) WatchOption {
+    if other.Debounce > 0 {
+        o.Debounce = other.Debounce
+    }
+    if other.Pattern != "" {
+        o.Pattern = other.Pattern
+    }
+    if other.Exclude != "" {
+        o.Exclude = other.Exclude
+    }
+    if other.Ops != 0 {
+        o.Ops = other.Ops
+    }
+    if other.IgnoreChmod {
+        o.IgnoreChmod = true
+    }
+    if other.WaitForCreate {
+        o.WaitForCreate = true
+    }
+    if other.RawRemove {
+        o.RawRemove = true
+    }
+    if other.InitialEvent {
+        o.InitialEvent = true
+    }
+    if other.Symlink != SYMLINK_FOLLOW {
+        o.Symlink = other.Symlink
+    }
+    if other.RawPath {
+        o.RawPath = true
+    }
+    if other.maxDepth != 0 {
+        o.maxDepth = other.maxDepth
+    }
+    if other.glob != nil {
+        o.glob = other.glob
+    }
+    return o
+}
+
+// 判断给定的文件/目录是否满足配置项的过滤规则，root为注册监听时的根路径，根路径本身始终满足。
+// 对于根路径下的文件/目录，路径中的任意一级名称满足Exclude即被排除；文件的名称需要满足Pattern。
+// 通过AddGlob添加的监听只有满足glob规则的文件满足过滤规则，根路径及目录都不满足。
+func (o WatchOption) accept(root, path string, isDir bool) bool {
+    if o.glob != nil && (isDir || !matchGlob(o.glob, relativeSegments(root, path), false)) {
+        return false
+    }
+    if path == root || (o.Pattern == "" && o.Exclude == "") {
+        return true
+    }
+    if o.Exclude != "" {
+        relative := strings.TrimPrefix(path, root)
+        for _, name := range strings.Split(relative, string(filepath.Separator)) {
+            if name != "" && matchPatterns(o.Exclude, name) {
+                return false
+            }
+        }
+    }
+    if o.Pattern != "" && !isDir {
+        return matchPatterns(o.Pattern, filepath.Base(path))
+    }
+    return true
+}
+
+// 判断给定的文件/目录是否需要添加监听：满足配置项的过滤规则，并且没有超出递归监听的最大深度；
+// 通过AddGlob添加的监听，只有其下可能存在满足glob规则的文件的目录才需要添加监听
+func (o WatchOption) acceptWatch(root, path string, isDir bool) bool {
+    if o.maxDepth > 0 && path != root {
+        relative := strings.Trim(strings.TrimPrefix(path, root), string(filepath.Separator))
+        if depth := len(strings.Split(relative, string(filepath.Separator))); depth > o.maxDepth - 1 {
+            return false
+        }
+    }
+    if o.glob != nil && isDir {
+        if !matchGlob(o.glob, relativeSegments(root, path), true) {
+            return false
+        }
+        o.glob = nil
+    }
+    return o.accept(root, path, isDir)
+}
+
+// 判断给定的文件操作是否为配置项所关注的操作
+func (o WatchOption) acceptOp(op Op) bool {
+    if o.IgnoreChmod && op == CHMOD {
+        return false
+    }
+    return o.Ops == 0 || o.Ops & op != 0
+}
+
+// 判断名称是否满足给定的名称匹配模式，多个模式使用','分隔，空白的模式被忽略(与gfile.ScanDirExclude的规则一致)
+func matchPatterns(patterns string, name string) bool {
+    for _, p := range strings.Split(patterns, ",") {
+        if p = strings.TrimSpace(p); p == "" {
+            continue
+        }
+        if match, err := filepath.Match(p, name); err == nil && match {
+            return true
+        }
+    }
+    return false
+}
+
+// 解析Add方法的可选参数：
+// bool类型参数表示当path为目录时是否递归监听(未指定时使用defaultRecursive)；
+// WatchOption类型参数为监听配置项，多个配置项会按照先后顺序进行合并。
+func parseWatchOptions(options []interface{}, defaultRecursive bool) (recursive bool, option WatchOption, err error) {
+    recursive = defaultRecursive
+    for _, v := range options {
+        switch r := v.(type) {
+            case bool:
+                recursive = r
+            case WatchOption:
+                option = option.merge(r)
+            case *WatchOption:
+                if r != nil {
+                    option = option.merge(*r)
+                }
+            default:
+                return false, option, errors.New(fmt.Sprintf(`invalid watch option type: %T`, v))
+        }
+    }
+    return
+}
@@ -0,0 +1,238 @@
+// Copyright 2018 gf Author(https://gitee.com/johng/gf). All Rights Reserved.
+//
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT was not distributed with this file,
+// You can obtain one at https://gitee.com/johng/gf.
+
+package gfsnotify
+
+import (
+    "errors"
+    "fmt"
+    "gitee.com/johng/gf/g/container/gtype"
+    "gitee.com/johng/gf/third/github.com/fsnotify/fsnotify"
+    "io/ioutil"
+    "os"
+    "path/filepath"
+    "sort"
+    "sync"
+    "time"
+)
+
+// 基于轮询的底层监听，用于inotify等内核通知机制无法工作的文件系统(例如NFS等网络文件系统、部分容器的overlay/bind挂载)。
+// 每一个轮询周期对所有监听的文件执行一次Stat，对所有监听的目录读取一次目录列表，并与上一次的快照进行比较，产生以下事件：
+// 1. 目录下新增的文件/目录产生CREATE事件，消失的文件/目录产生REMOVE事件；
+// 2. 文件的修改时间或者大小发生变化时产生WRITE事件；
+// 3. 监听的文件/目录本身消失时产生REMOVE事件，并自动移除该监听(与inotify的行为一致)。
+// 轮询方式无法识别重命名，重命名会产生旧路径的REMOVE事件以及新路径的CREATE事件，也不会产生CHMOD事件。
+type pollWatcher struct {
+    mu        sync.Mutex
+    interval  time.Duration                // 轮询间隔
+    paths     map[string]*pollEntry        // 监听的文件/目录及其最近一次的快照
+    events    chan fsnotify.Event          // 底层事件通道
+    errors    chan error                   // 底层错误通道
+    closeChan chan struct{}                // 关闭事件
+    closed    *gtype.Bool                  // 是否已关闭
+    wg        sync.WaitGroup               // 轮询循环的退出等待
+}
+
+// 文件/目录的快照
+type pollEntry struct {
+    stat     pollStat            // 文件/目录本身的状态
+    children map[string]pollStat // 目录下直属文件/目录的状态(键名为文件名称)，文件为nil
+}
+
+// 文件/目录的状态，用于比较是否发生变化
+type pollStat struct {
+    modTime time.Time
+    size    int64
+    isDir   bool
+}
+
+// 创建基于轮询的底层监听，interval为轮询间隔
+func newPollWatcher(interval time.Duration) *pollWatcher {
+    p := &pollWatcher {
+        interval  : interval,
+        paths     : make(map[string]*pollEntry),
+        events    : make(chan fsnotify.Event),
+        errors    : make(chan error),
+        closeChan : make(chan struct{}),
+        closed    : gtype.NewBool(),
+    }
+    p.wg.Add(1)
+    go p.loop()
+    return p
+}
+
+// 添加监听，添加时立即生成快照，添加之后的变化将在下一个轮询周期产生事件
+func (p *pollWatcher) Add(path string) error {
+    entry, err := pollSnapshot(path)
+    if err != nil {
+        return err
+    }
+    p.mu.Lock()
+    defer p.mu.Unlock()
+    if p.closed.Val() {
+        return errors.New("poll watcher closed")
+    }
+    // 重复添加时保留原有的快照，避免丢失两次轮询之间的变化
+    if _, ok := p.paths[path]; !ok {
+        p.paths[path] = entry
+    }
+    return nil
+}
+
+// 移除监听
+func (p *pollWatcher) Remove(path string) error {
+    p.mu.Lock()
+    defer p.mu.Unlock()
+    if _, ok := p.paths[path]; !ok {
+        return errors.New(fmt.Sprintf(`can't remove non-existent poll watch for: %s`, path))
+    }
+    delete(p.paths, path)
+    return nil
+}
+
+// 关闭轮询，等待轮询循环退出后关闭事件通道及错误通道，重复调用是安全的
+func (p *pollWatcher) Close() error {
+    if p.closed.Set(true) {
+        return nil
+    }
+    close(p.closeChan)
+    p.wg.Wait()
+    p.mu.Lock()
+    p.paths = make(map[string]*pollEntry)
+    p.mu.Unlock()
+    close(p.events)
+    close(p.errors)
+    return nil
+}
+
+func (p *pollWatcher) eventChan() <-chan fsnotify.Event {
+    return p.events
+}
+
+func (p *pollWatcher) errorChan() <-chan error {
+    return p.errors
+}
+
+// 轮询循环
+func (p *pollWatcher) loop() {
+    defer p.wg.Done()
+    ticker := time.NewTicker(p.interval)
+    defer ticker.Stop()
+    for {
+        select {
+            case <- p.closeChan:
+                return
+            case <- ticker.C:
+                events, errs := p.poll()
+                // 在锁外写入通道，避免事件处理过程中调用Add/Remove造成死锁
+                for _, ev := range events {
+                    select {
+                        case p.events <- ev:
+                        case <- p.closeChan:
+                            return
+                    }
+                }
+                for _, err := range errs {
+                    select {
+                        case p.errors <- err:
+                        case <- p.closeChan:
+                            return
+                    }
+                }
+        }
+    }
+}
+
+// 执行一次轮询，比较所有监听路径的快照，返回产生的事件(同一轮询周期内相同的事件只保留一次)以及错误
+func (p *pollWatcher) poll() (events []fsnotify.Event, errs []error) {
+    p.mu.Lock()
+    defer p.mu.Unlock()
+    paths := make([]string, 0, len(p.paths))
+    for path, _ := range p.paths {
+        paths = append(paths, path)
+    }
+    sort.Strings(paths)
+    seen := make(map[fsnotify.Event]bool)
+    emit := func(path string, op fsnotify.Op) {
+        ev := fsnotify.Event{ Name : path, Op : op }
+        if !seen[ev] {
+            seen[ev] = true
+            events   = append(events, ev)
+        }
+    }
+    for _, path := range paths {
+        old        := p.paths[path]
+        entry, err := pollSnapshot(path)
+        if err != nil {
+            if os.IsNotExist(err) {
+                emit(path, fsnotify.Remove)
+                delete(p.paths, path)
+            } else {
+                errs = append(errs, err)
+            }
+            continue
+        }
+        p.paths[path] = entry
+        if !old.stat.isDir && !entry.stat.isDir && old.stat != entry.stat {
+            emit(path, fsnotify.Write)
+        }
+        if old.children == nil || entry.children == nil {
+            continue
+        }
+        names := make([]string, 0, len(entry.children))
+        for name, _ := range entry.children {
+            names = append(names, name)
+        }
+        for name, _ := range old.children {
+            if _, ok := entry.children[name]; !ok {
+                names = append(names, name)
+            }
+        }
+        sort.Strings(names)
+        for _, name := range names {
+            stat, ok     := entry.children[name]
+            oldStat, had := old.children[name]
+            childPath    := path + string(filepath.Separator) + name
+            switch {
+                case ok && !had:
+                    emit(childPath, fsnotify.Create)
+                case !ok && had:
+                    emit(childPath, fsnotify.Remove)
+                case !stat.isDir && stat != oldStat:
+                    emit(childPath, fsnotify.Write)
+            }
+        }
+    }
+    return
+}
+
+// 生成文件/目录的快照，目录同时记录其直属文件/目录的状态
+func pollSnapshot(path string) (*pollEntry, error) {
+    info, err := os.Stat(path)
+    if err != nil {
+        return nil, err
+    }
+    entry := &pollEntry{ stat : newPollStat(info) }
+    if info.IsDir() {
+        infos, err := ioutil.ReadDir(path)
+        if err != nil {
+            return nil, err
+        }
+        entry.children = make(map[string]pollStat, len(infos))
+        for _, v := range infos {
+            entry.children[v.Name()] = newPollStat(v)
+        }
+    }
+    return entry, nil
+}
+
+func newPollStat(info os.FileInfo) pollStat {
+    return pollStat {
+        modTime : info.ModTime(),
+        size    : info.Size(),
+        isDir   : info.IsDir(),
+    }
+}
@@ -0,0 +1,81 @@
+// Copyright 2018 gf Author(https://gitee.com/johng/gf). All Rights Reserved.
+//
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT was not distributed with this file,
+// You can obtain one at https://gitee.com/johng/gf.
+
+package gfsnotify
+
+import (
+    "gitee.com/johng/gf/g/container/gqueue"
+)
+
+// 事件队列，监听循环写入，事件循环读取。
+// 不限制大小时使用动态队列；限制大小时使用有界队列，队列满时阻塞写入或者丢弃最早的事件。
+type eventQueue struct {
+    queue      *gqueue.Queue      // 动态队列(不限制大小)
+    bounded    chan *Event        // 有界队列(限制大小)
+    dropOldest bool               // 有界队列满时是否丢弃最早的事件(否则阻塞写入)
+    onOverflow func(event *Event) // 有界队列满时被丢弃事件的回调
+}
+
+// 创建事件队列，capacity <= 0 表示不限制大小
+func newEventQueue(capacity int, dropOldest bool, onOverflow func(event *Event)) *eventQueue {
+    q := &eventQueue {
+        dropOldest : dropOldest,
+        onOverflow : onOverflow,
+    }
+    if capacity > 0 {
+        q.bounded = make(chan *Event, capacity)
+    } else {
+        q.queue   = gqueue.New()
+    }
+    return q
+}
+
+// 写入事件
+func (q *eventQueue) push(event *Event) {
+    if q.bounded == nil {
+        q.queue.Push(event)
+        return
+    }
+    if !q.dropOldest {
+        q.bounded <- event
+        return
+    }
+    for {
+        select {
+            case q.bounded <- event:
+                return
+            default:
+        }
+        // 队列已满，丢弃最早的事件后重新尝试写入(读取时队列可能已被事件循环消费，因此不能阻塞)
+        select {
+            case dropped := <- q.bounded:
+                if q.onOverflow != nil {
+                    q.onOverflow(dropped)
+                }
+            default:
+        }
+    }
+}
+
+// 读取事件，队列为空时阻塞等待，队列关闭后返回nil
+func (q *eventQueue) pop() *Event {
+    if q.bounded == nil {
+        if v := q.queue.Pop(); v != nil {
+            return v.(*Event)
+        }
+        return nil
+    }
+    return <- q.bounded
+}
+
+// 关闭队列，只能由写入方调用
+func (q *eventQueue) close() {
+    if q.bounded == nil {
+        q.queue.Close()
+    } else {
+        close(q.bounded)
+    }
+}
@@ -0,0 +1,146 @@
+// Copyright 2018 gf Author(https://gitee.com/johng/gf). All Rights Reserved.
+//
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT was not distributed with this file,
+// You can obtain one at https://gitee.com/johng/gf.
+
+package gfsnotify
+
+import (
+    "time"
+)
+
+// 等待关联的重命名事件
+type pendingRename struct {
+    event     *Event        // 旧路径的重命名事件
+    callbacks []interface{} // 旧路径的回调列表快照
+    timer     clockTimer    // 关联时间窗口定时器，超时后以旧路径的REMOVE事件回调
+}
+
+// 关联重命名事件(在事件循环中调用)，返回true表示该事件已被暂存或者丢弃，不需要继续处理：
+// 1. 真实的重命名事件(旧路径已不存在)暂存RENAME_CORRELATE_INTERVAL时间窗口，等待新路径的新建事件；
+// 2. 时间窗口内的下一个新建事件与之关联，修改为MOVE事件并设置OldPath，移除旧路径的监听，
+//    对于只注册了旧路径的回调，以旧路径的REMOVE事件回调；
+// 3. 超出时间窗口或者无法关联时(下一个新建事件超时、或者期间产生了其他路径的重命名事件)，以旧路径的REMOVE事件回调，
+//    此时如果旧路径已被重新创建，那么以RENAME事件回调(见removeRenamed)。
+// 同一次移动可能同时产生目录监听及文件自身监听的重命名事件，重复的事件会被丢弃。
+func (w *Watcher) correlateRename(event *Event, callbacks []interface{}) bool {
+    if event.IsRename() {
+        // 重命名之后原路径已被重新创建，不需要关联，但是底层监听仍然指向重命名后的文件
+        if fileExists(event.Path) {
+            w.rewatch(event.Path)
+            return false
+        }
+        if m := w.movedEvent; m != nil && m.OldPath == event.Path && event.time - m.time <= RENAME_CORRELATE_INTERVAL {
+            return true
+        }
+        w.renameMu.Lock()
+        last := w.renameEvent
+        if last != nil && last.event.Path == event.Path {
+            w.renameMu.Unlock()
+            return true
+        }
+        pending := &pendingRename {
+            event     : event,
+            callbacks : callbacks,
+        }
+        pending.timer = w.clock.AfterFunc(RENAME_CORRELATE_INTERVAL*time.Millisecond, func() {
+            w.renameMu.Lock()
+            if w.renameEvent != pending {
+                w.renameMu.Unlock()
+                return
+            }
+            w.renameEvent = nil
+            w.renameMu.Unlock()
+            w.removeRenamed(pending, nil)
+        })
+        w.renameEvent = pending
+        w.renameMu.Unlock()
+        if last != nil {
+            last.timer.Stop()
+            w.removeRenamed(last, nil)
+        }
+        return true
+    }
+    if !event.IsCreate() {
+        return false
+    }
+    w.renameMu.Lock()
+    pending := w.renameEvent
+    w.renameEvent = nil
+    w.renameMu.Unlock()
+    if pending == nil {
+        return false
+    }
+    pending.timer.Stop()
+    if event.time - pending.event.time > RENAME_CORRELATE_INTERVAL || event.Path == pending.event.Path {
+        w.removeRenamed(pending, nil)
+        return false
+    }
+    event.OldPath = pending.event.Path
+    event.Op      = MOVE
+    w.movedEvent  = event
+    w.removeRenamed(pending, callbacks)
+    return false
+}
+
+// 移除重命名的旧路径的监听，并对旧路径的回调以旧路径的REMOVE事件回调，
+// 与新路径的回调(exclude)属于同一次注册的回调已经收到了MOVE事件，不再重复回调。
+// 如果旧路径在此期间已被重新创建(例如编辑器先将文件重命名为备份文件，再写入新的文件)，
+// 那么与“假删除”的处理一致：重新添加对旧路径的监听，已注册的回调保持不变，并以RENAME事件回调。
+func (w *Watcher) removeRenamed(pending *pendingRename, exclude []interface{}) {
+    if w.closed.Val() {
+        return
+    }
+    path  := pending.event.Path
+    isDir := false
+    for _, v := range pending.callbacks {
+        if callback := v.(*Callback); callback.Path == path {
+            isDir = !callback.file
+            break
+        }
+    }
+    removed := *pending.event
+    if fileExists(path) {
+        w.rewatch(path)
+        removed.Op = RENAME
+        isDir      = fileIsDir(path)
+    } else {
+        w.Remove(path)
+        removed.Op = REMOVE
+    }
+    for _, v := range pending.callbacks {
+        if callback := v.(*Callback); !containsRoot(exclude, callback) {
+            w.handleCallback(callback, &removed, isDir)
+        }
+    }
+}
+
+// 重新添加对已注册回调的路径的底层监听：重命名后底层监听仍然指向重命名后的文件，需要先移除再重新添加
+func (w *Watcher) rewatch(path string) {
+    if w.callbacks.Contains(path) {
+        w.watcher.Remove(path)
+        w.watcher.Add(path)
+    }
+}
+
+// 取消等待关联的重命名事件
+func (w *Watcher) cancelRename() {
+    w.renameMu.Lock()
+    if w.renameEvent != nil {
+        w.renameEvent.timer.Stop()
+        w.renameEvent = nil
+    }
+    w.renameMu.Unlock()
+}
+
+// 判断回调列表中是否包含与给定的回调对象属于同一次注册的回调对象
+func containsRoot(callbacks []interface{}, callback *Callback) bool {
+    root := callback.root()
+    for _, v := range callbacks {
+        if v.(*Callback).root() == root {
+            return true
+        }
+    }
+    return false
+}
@@ -0,0 +1,68 @@
+// Copyright 2018 gf Author(https://gitee.com/johng/gf). All Rights Reserved.
+//
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT was not distributed with this file,
+// You can obtain one at https://gitee.com/johng/gf.
+
+package gfsnotify
+
+import (
+    "gitee.com/johng/gf/g/container/gtype"
+)
+
+// 监听管理对象的运行统计信息(快照)。
+// 如果Received持续增长而Dispatched停滞不前，表示事件循环被阻塞。
+type WatcherStats struct {
+    Received     int64 // 从底层fsnotify接收到的事件数量(包含被过滤的重复事件)
+    Dispatched   int64 // 事件循环处理(分发给回调)的事件数量
+    Invoked      int64 // 回调方法的执行次数
+    Dropped      int64 // 事件队列满或者订阅通道满时被丢弃的事件数量
+    Errors       int64 // 处理的错误数量(包含底层监听错误及回调方法的panic)
+    // 回调工作协程的统计信息，仅在开启WithWorkers时有效：
+    // BusyWorkers持续等于Workers并且PendingTasks持续增长表示工作协程已经饱和，BlockedTasks为由于待执行队列满而阻塞分发的次数
+    Workers      int   // 回调工作协程的数量
+    BusyWorkers  int64 // 正在执行回调的工作协程数量
+    PendingTasks int64 // 等待工作协程执行的回调数量
+    BlockedTasks int64 // 由于工作协程的待执行队列满而阻塞分发的次数
+}
+
+// 监听管理对象内部的统计计数器
+type watcherStats struct {
+    received   *gtype.Int64
+    dispatched *gtype.Int64
+    invoked    *gtype.Int64
+    dropped    *gtype.Int64
+    errors     *gtype.Int64
+    busy       *gtype.Int64
+    pending    *gtype.Int64
+    blocked    *gtype.Int64
+}
+
+// 创建统计计数器
+func newWatcherStats() *watcherStats {
+    return &watcherStats {
+        received   : gtype.NewInt64(),
+        dispatched : gtype.NewInt64(),
+        invoked    : gtype.NewInt64(),
+        dropped    : gtype.NewInt64(),
+        errors     : gtype.NewInt64(),
+        busy       : gtype.NewInt64(),
+        pending    : gtype.NewInt64(),
+        blocked    : gtype.NewInt64(),
+    }
+}
+
+// 获取监听管理对象的运行统计信息，并发安全
+func (w *Watcher) Stats() WatcherStats {
+    return WatcherStats {
+        Received     : w.stats.received.Val(),
+        Dispatched   : w.stats.dispatched.Val(),
+        Invoked      : w.stats.invoked.Val(),
+        Dropped      : w.stats.dropped.Val(),
+        Errors       : w.stats.errors.Val(),
+        Workers      : len(w.workers),
+        BusyWorkers  : w.stats.busy.Val(),
+        PendingTasks : w.stats.pending.Val(),
+        BlockedTasks : w.stats.blocked.Val(),
+    }
+}
@@ -0,0 +1,94 @@
+// Copyright 2018 gf Author(https://gitee.com/johng/gf). All Rights Reserved.
+//
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT was not distributed with this file,
+// You can obtain one at https://gitee.com/johng/gf.
+
+package gfsnotify
+
+import (
+    "sync"
+)
+
+const (
+    DEFAULT_SUBSCRIBE_BUFFER = 100 // 默认的订阅通道缓冲大小
+)
+
+// 订阅监听事件时的可选配置项
+type SubscribeOption struct {
+    Buffer int  // 订阅通道的缓冲大小，零值表示使用默认大小(DEFAULT_SUBSCRIBE_BUFFER)
+    // 订阅通道满时是否阻塞等待消费者读取，默认丢弃新的事件(计入Stats的Dropped统计)，
+    // 阻塞时不会影响事件循环，但是开启串行分发时会阻塞同一路径的后续回调
+    Block  bool
+}
+
+// 订阅指定文件/目录的监听事件，事件将会写入返回的通道中，作为回调方法的替代方式。
+// 调用返回的cancel方法会移除该订阅的监听并关闭通道，监听管理对象关闭时也会自动关闭通道，重复调用cancel是安全的。
+// options参数同Add方法，另外支持SubscribeOption类型的配置项。
+func (w *Watcher) Subscribe(path string, options...interface{}) (events <-chan *Event, cancel func(), err error) {
+    option     := SubscribeOption{}
+    addOptions := make([]interface{}, 0, len(options))
+    for _, v := range options {
+        switch r := v.(type) {
+            case SubscribeOption:
+                option = r
+            case *SubscribeOption:
+                if r != nil {
+                    option = *r
+                }
+            default:
+                addOptions = append(addOptions, v)
+        }
+    }
+    if option.Buffer <= 0 {
+        option.Buffer = DEFAULT_SUBSCRIBE_BUFFER
+    }
+    var (
+        mu     sync.RWMutex
+        once   sync.Once
+        closed bool
+        ch     = make(chan *Event, option.Buffer)
+        done   = make(chan struct{})
+    )
+    callback, err := w.Add(path, func(event *Event) {
+        mu.RLock()
+        defer mu.RUnlock()
+        if closed {
+            return
+        }
+        if option.Block {
+            select {
+                case ch <- event:
+                case <- done:
+            }
+        } else {
+            select {
+                case ch <- event:
+                default:
+                    w.stats.dropped.Add(1)
+            }
+        }
+    }, addOptions...)
+    if err != nil {
+        return nil, nil, err
+    }
+    cancel = func() {
+        once.Do(func() {
+            // 首先通知阻塞写入的回调退出，再关闭通道
+            close(done)
+            w.RemoveCallback(callback)
+            mu.Lock()
+            closed = true
+            close(ch)
+            mu.Unlock()
+        })
+    }
+    go func() {
+        select {
+            case <- w.closeChan:
+                cancel()
+            case <- done:
+        }
+    }()
+    return ch, cancel, nil
+}
@@ -0,0 +1,81 @@
+// Copyright 2018 gf Author(https://gitee.com/johng/gf). All Rights Reserved.
+//
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT was not distributed with this file,
+// You can obtain one at https://gitee.com/johng/gf.
+
+package gfsnotify
+
+import (
+    "os"
+)
+
+// 符号链接的监听方式
+type SymlinkMode int
+
+const (
+    // 监听符号链接指向的目标(默认)，递归监听时会进入指向目录的符号链接(自动跳过循环链接)；
+    // 符号链接被重新指向(例如 ln -sfn 原子部署)时不会产生事件，因为监听的仍然是原来的目标。
+    SYMLINK_FOLLOW   SymlinkMode = iota
+    // 只监听符号链接本身(通过监听其所在目录实现)，符号链接被创建、重新指向或者删除时产生事件，
+    // 目标文件的内容修改不会产生事件，递归监听时不会进入指向目录的符号链接。
+    SYMLINK_NOFOLLOW
+    // 同时监听符号链接本身及其指向的目标，符号链接被重新指向后会自动转换为监听新的目标。
+    SYMLINK_BOTH
+)
+
+// 添加底层监听，对于符号链接按照监听方式进行处理，返回符号链接所在的目录(非符号链接或者默认监听方式时返回空字符串)
+func (w *Watcher) addUnderlyingWatch(path string, mode SymlinkMode) (linkParent string, err error) {
+    if mode != SYMLINK_FOLLOW && fileIsLink(path) {
+        linkParent = fileDir(path)
+        w.callbacks.LockFunc(func(m map[string]interface{}) {
+            w.linkParents[linkParent]++
+            if w.linkParents[linkParent] == 1 {
+                err = w.watcher.Add(linkParent)
+            }
+        })
+        if mode == SYMLINK_NOFOLLOW {
+            return
+        }
+    }
+    if e := w.watcher.Add(path); e != nil {
+        err = e
+    }
+    return
+}
+
+// 减少符号链接所在目录的引用计数，当不再被引用并且该目录没有注册回调时，移除该目录的底层监听。
+// 需要在回调注册的锁中调用。
+func (w *Watcher) removeLinkParent(m map[string]interface{}, linkParent string) {
+    if w.linkParents[linkParent]--; w.linkParents[linkParent] > 0 {
+        return
+    }
+    delete(w.linkParents, linkParent)
+    if _, ok := m[linkParent]; !ok {
+        w.watcher.Remove(linkParent)
+    }
+}
+
+// 符号链接被重新指向(新建或者重命名覆盖)后，同时监听目标的回调需要转换为监听新的目标
+func (w *Watcher) refollowLink(event *Event, callbacks []interface{}) {
+    if !event.IsCreate() && !event.IsRename() && !event.IsMove() {
+        return
+    }
+    for _, v := range callbacks {
+        callback := v.(*Callback)
+        if callback.Path == event.Path && callback.linkParent != "" && callback.option.Symlink == SYMLINK_BOTH {
+            w.watcher.Remove(event.Path)
+            w.watcher.Add(event.Path)
+            return
+        }
+    }
+}
+
+// 判断所给路径是否为符号链接
+func fileIsLink(path string) bool {
+    s, err := os.Lstat(path)
+    if err != nil {
+        return false
+    }
+    return s.Mode() & os.ModeSymlink != 0
+}
@@ -0,0 +1,220 @@
+// Copyright 2018 gf Author(https://gitee.com/johng/gf). All Rights Reserved.
+//
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT was not distributed with this file,
+// You can obtain one at https://gitee.com/johng/gf.
+
+package gfsnotify
+
+import (
+    "bufio"
+    "context"
+    "errors"
+    "fmt"
+    "io"
+    "os"
+    "strings"
+    "time"
+)
+
+const (
+    // (毫秒)Tail处理文件变化后再次检查文件的延迟时间：间隔小于REPEAT_EVENT_FILTER_INTERVAL的连续写入只会产生一次事件，
+    // 再次检查以读取在处理事件期间写入的内容
+    TAIL_RECHECK_INTERVAL = 10
+)
+
+// 跟踪读取的文件状态
+type tailFile struct {
+    path    string                  // 跟踪的文件路径
+    file    *os.File                // 当前打开的文件
+    info    os.FileInfo             // 当前打开的文件信息，用于判断文件是否已被替换(轮转)
+    reader  *bufio.Reader           // 当前打开的文件的读取对象
+    offset  int64                   // 当前打开的文件已读取的位置
+    partial string                  // 已读取的尚未以换行符结束的内容
+    handler func(line string) error // 按行回调的处理方法
+}
+
+// 类似tail -f，读取文件已有的内容并跟踪文件的追加写入，按行回调handler(不包含行尾的"\n"及"\r")，方法会阻塞直到：
+// 1. ctx被取消(ctx为nil时不会被取消)，返回ctx.Err()；
+// 2. handler返回错误，返回该错误；
+// 3. 监听管理对象被关闭，返回ErrWatcherClosed；读取文件失败时返回对应的错误。
+// 通过监听文件所在的目录跟踪文件的变化，并处理日志文件常见的轮转方式：
+// 1. 文件被清空或者截断(例如copytruncate)，文件大小小于已读取的位置时，从文件开头重新读取；
+// 2. 文件被重命名后创建新的文件(例如logrotate的默认方式)，先读取完旧文件剩余的内容，新文件创建后打开新文件并从开头读取；
+//    文件被删除后重新创建同样按照轮转处理。
+// 轮转或者截断时旧文件中未以换行符结束的内容作为单独的一行回调。
+// 该方法没有放在gfile包中(gfile.Tail)：gfsnotify依赖glog，glog依赖gfile，gfile引用gfsnotify会产生循环引用。
+func (w *Watcher) Tail(ctx context.Context, path string, handler func(line string) error) error {
+    realPath := fileRealPath(path)
+    if realPath == "" {
+        return errors.New(fmt.Sprintf(`"%s" does not exist`, path))
+    }
+    if fileIsDir(realPath) {
+        return errors.New(fmt.Sprintf(`"%s" is a directory, while a file is required`, path))
+    }
+    if ctx == nil {
+        ctx = context.Background()
+    }
+    notify := make(chan struct{}, 1)
+    // 先添加监听再读取已有的内容，避免丢失期间写入的内容
+    callback, err := w.Add(fileDir(realPath), func(event *Event) {
+        if event.Path == realPath || event.OldPath == realPath {
+            select {
+                case notify <- struct{}{}:
+                default:
+            }
+        }
+    }, false, WatchOption{ IgnoreChmod : true })
+    if err != nil {
+        return err
+    }
+    defer w.RemoveCallback(callback)
+
+    t := &tailFile {
+        path    : realPath,
+        handler : handler,
+    }
+    defer t.close()
+    if err := t.open(); err != nil {
+        return err
+    }
+    if err := t.read(); err != nil {
+        return err
+    }
+    recheck := time.NewTimer(TAIL_RECHECK_INTERVAL*time.Millisecond)
+    defer recheck.Stop()
+    for {
+        select {
+            case <- ctx.Done():
+                return ctx.Err()
+            case <- w.closeChan:
+                return ErrWatcherClosed
+            case <- notify:
+                if err := t.check(); err != nil {
+                    return err
+                }
+                if !recheck.Stop() {
+                    select {
+                        case <- recheck.C:
+                        default:
+                    }
+                }
+                recheck.Reset(TAIL_RECHECK_INTERVAL*time.Millisecond)
+            case <- recheck.C:
+                if err := t.check(); err != nil {
+                    return err
+                }
+        }
+    }
+}
+
+// 类似tail -f，读取文件已有的内容并跟踪文件的追加写入，按行回调handler，详见Watcher.Tail
+func Tail(ctx context.Context, path string, handler func(line string) error) error {
+    w, err := getWatcherByPath(path)
+    if err != nil {
+        return err
+    }
+    return w.Tail(ctx, path, handler)
+}
+
+// 打开跟踪的文件，从文件开头读取
+func (t *tailFile) open() error {
+    file, err := os.Open(t.path)
+    if err != nil {
+        return err
+    }
+    info, err := file.Stat()
+    if err != nil {
+        file.Close()
+        return err
+    }
+    t.file   = file
+    t.info   = info
+    t.reader = bufio.NewReader(file)
+    t.offset = 0
+    return nil
+}
+
+// 关闭当前打开的文件
+func (t *tailFile) close() {
+    if t.file != nil {
+        t.file.Close()
+        t.file = nil
+    }
+}
+
+// 文件发生变化时检查文件状态并读取新的内容
+func (t *tailFile) check() error {
+    // 无论文件是否已被替换，都先读取完当前打开的文件中新写入的内容
+    if err := t.read(); err != nil {
+        return err
+    }
+    info, err := os.Stat(t.path)
+    if err != nil {
+        // 文件已被重命名或者删除，等待新的文件被创建
+        if os.IsNotExist(err) {
+            return nil
+        }
+        return err
+    }
+    switch {
+        // 文件已被替换(轮转)，打开新的文件
+        case !os.SameFile(t.info, info):
+            if err := t.flush(); err != nil {
+                return err
+            }
+            t.close()
+            if err := t.open(); err != nil {
+                if os.IsNotExist(err) {
+                    return nil
+                }
+                return err
+            }
+        // 文件被截断，从文件开头重新读取
+        case info.Size() < t.offset:
+            if err := t.flush(); err != nil {
+                return err
+            }
+            if _, err := t.file.Seek(0, io.SeekStart); err != nil {
+                return err
+            }
+            t.reader.Reset(t.file)
+            t.offset = 0
+        default:
+            return nil
+    }
+    return t.read()
+}
+
+// 读取当前打开的文件中新写入的内容，按行回调
+func (t *tailFile) read() error {
+    if t.file == nil {
+        return nil
+    }
+    for {
+        data, err := t.reader.ReadString('\n')
+        t.offset += int64(len(data))
+        if err != nil {
+            t.partial += data
+            if err == io.EOF {
+                return nil
+            }
+            return err
+        }
+        line := t.partial + data
+        t.partial = ""
+        if err := t.handler(strings.TrimRight(line, "\r\n")); err != nil {
+            return err
+        }
+    }
+}
+
+// 将未以换行符结束的内容作为单独的一行回调
+func (t *tailFile) flush() error {
+    if t.partial == "" {
+        return nil
+    }
+    line := t.partial
+    t.partial = ""
+    return t.handler(strings.TrimRight(line, "\r"))
+}
@@ -0,0 +1,974 @@
+// Copyright 2018 gf Author(https://gitee.com/johng/gf). All Rights Reserved.
+//
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT was not distributed with this file,
+// You can obtain one at https://gitee.com/johng/gf.
+
+// go test *.go -v
+
+package gfsnotify
+
+import (
+    "context"
+    "errors"
+    "io/ioutil"
+    "os"
+    "runtime"
+    "strings"
+    "sync"
+    "testing"
+    "time"
+    "gitee.com/johng/gf/g/container/glist"
+    "gitee.com/johng/gf/g/container/gtype"
+)
+
+// 创建临时的监听目录，返回目录路径(已转换为绝对路径)
+func newTestDir(t *testing.T) string {
+    dir, err := ioutil.TempDir("", "gfsnotify")
+    if err != nil {
+        t.Fatal(err)
+    }
+    return fileRealPath(dir)
+}
+
+// 在被监听的目录下新建文件，随后追加写入，需要能够收到该文件的WRITE事件
+func TestWatcher_CreateThenWrite(t *testing.T) {
+    dir := newTestDir(t)
+    defer os.RemoveAll(dir)
+    w, err := New()
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer w.Close()
+
+    path  := dir + string(os.PathSeparator) + "test.txt"
+    write := gtype.NewBool()
+    if _, err := w.Add(dir, func(event *Event) {
+        if event.Path == path && event.IsWrite() {
+            write.Set(true)
+        }
+    }); err != nil {
+        t.Fatal(err)
+    }
+    if err := ioutil.WriteFile(path, nil, 0644); err != nil {
+        t.Fatal(err)
+    }
+    // 等待新建事件处理完成(新建的文件将会被添加到监听中)
+    time.Sleep(100*time.Millisecond)
+    if !w.callbacks.Contains(path) {
+        t.Errorf(`newly created file "%s" is not watched`, path)
+    }
+    f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+    if err != nil {
+        t.Fatal(err)
+    }
+    f.WriteString("gf")
+    f.Close()
+    time.Sleep(100*time.Millisecond)
+    if !write.Val() {
+        t.Errorf(`WRITE event for "%s" not received`, path)
+    }
+}
+
+// 一次性监听在连续的多个事件下只会回调一次，并且不影响同一路径下的其他回调
+func TestWatcher_AddOnce(t *testing.T) {
+    dir := newTestDir(t)
+    defer os.RemoveAll(dir)
+    w, err := New()
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer w.Close()
+
+    path := dir + string(os.PathSeparator) + "test.txt"
+    if err := ioutil.WriteFile(path, nil, 0644); err != nil {
+        t.Fatal(err)
+    }
+    once   := gtype.NewInt()
+    always := gtype.NewInt()
+    if _, err := w.AddOnce(path, func(event *Event) {
+        once.Add(1)
+    }); err != nil {
+        t.Fatal(err)
+    }
+    if _, err := w.Add(path, func(event *Event) {
+        always.Add(1)
+    }); err != nil {
+        t.Fatal(err)
+    }
+    for i := 0; i < 100; i++ {
+        ioutil.WriteFile(path, []byte{byte(i)}, 0644)
+    }
+    time.Sleep(200*time.Millisecond)
+    if n := once.Val(); n != 1 {
+        t.Errorf(`once callback fired %d times, expected 1`, n)
+    }
+    if always.Val() == 0 {
+        t.Error(`other callback on the same path was removed`)
+    }
+    if r := w.callbacks.Get(path); r == nil || r.(*glist.List).Len() != 1 {
+        t.Error(`once callback was not detached from the path`)
+    }
+}
+
+// 回调方法中产生的panic不会影响同一事件的其他回调执行，并且会交由错误处理方法处理
+func TestWatcher_CallbackPanic(t *testing.T) {
+    dir := newTestDir(t)
+    defer os.RemoveAll(dir)
+    w, err := New()
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer w.Close()
+
+    path := dir + string(os.PathSeparator) + "test.txt"
+    if err := ioutil.WriteFile(path, nil, 0644); err != nil {
+        t.Fatal(err)
+    }
+    handled  := gtype.NewBool()
+    executed := gtype.NewBool()
+    w.SetErrorHandler(func(err error) {
+        handled.Set(true)
+    })
+    if _, err := w.Add(path, func(event *Event) {
+        panic("callback panic")
+    }); err != nil {
+        t.Fatal(err)
+    }
+    if _, err := w.Add(path, func(event *Event) {
+        executed.Set(true)
+    }); err != nil {
+        t.Fatal(err)
+    }
+    ioutil.WriteFile(path, []byte("gf"), 0644)
+    time.Sleep(100*time.Millisecond)
+    if !executed.Val() {
+        t.Error(`second callback was not executed`)
+    }
+    if !handled.Val() {
+        t.Error(`callback panic was not passed to the error handler`)
+    }
+}
+
+// 递归移除目录监听时，其中的部分子级目录已经不在底层监听中，不影响其余文件/目录的移除
+func TestWatcher_RemoveWithMissingChild(t *testing.T) {
+    dir := newTestDir(t)
+    defer os.RemoveAll(dir)
+    w, err := New()
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer w.Close()
+
+    sep := string(os.PathSeparator)
+    for _, v := range []string{"a", "b", "c"} {
+        if err := os.MkdirAll(dir + sep + v + sep + "sub", 0755); err != nil {
+            t.Fatal(err)
+        }
+    }
+    // 子级目录b被排除，不在底层监听中
+    if _, err := w.Add(dir, func(event *Event) {}, WithExclude("b")); err != nil {
+        t.Fatal(err)
+    }
+    // 模拟子级目录a已经从底层监听中移除(例如被重命名)
+    if err := w.watcher.Remove(dir + sep + "a"); err != nil {
+        t.Fatal(err)
+    }
+    if err := w.Remove(dir); err != nil {
+        t.Errorf(`unexpected error: %v`, err)
+    }
+    if paths := w.Paths(); len(paths) > 0 {
+        t.Errorf(`paths still watched after removing: %v`, paths)
+    }
+}
+
+// 名称匹配模式使用','分隔多个模式，忽略每个模式两端的空白以及空白的模式(例如结尾多余的',')
+func TestWatchOption_MatchPatterns(t *testing.T) {
+    sep := string(os.PathSeparator)
+    for _, c := range []struct {
+        option WatchOption
+        path   string
+        isDir  bool
+        expect bool
+    } {
+        { WatchOption{ Exclude : "node_modules," },       "node_modules",           true,  false },
+        { WatchOption{ Exclude : "node_modules," },       "src" + sep + "a.js",     false, true  },
+        { WatchOption{ Exclude : "node_modules,," },      "src",                    true,  true  },
+        { WatchOption{ Exclude : " .git , *.tmp " },      "a.tmp",                  false, false },
+        { WatchOption{ Exclude : " .git , *.tmp " },      ".git" + sep + "HEAD",    false, false },
+        { WatchOption{ Pattern : "*.go," },               "main.go",                false, true  },
+        { WatchOption{ Pattern : "*.go," },               "main.txt",               false, false },
+        { WatchOption{ Pattern : ", *.go , *.yaml ," },   "a.yaml",                 false, true  },
+        { WatchOption{ Pattern : "," },                   "a.go",                   false, false },
+    } {
+        root := sep + "root"
+        if accept := c.option.accept(root, root + sep + c.path, c.isDir); accept != c.expect {
+            t.Errorf(`pattern "%s", exclude "%s": unexpected result %v for "%s"`, c.option.Pattern, c.option.Exclude, accept, c.path)
+        }
+    }
+}
+
+// 非递归添加的目录监听，移除时只移除目录本身，不会影响目录下单独添加的监听，也不会产生错误
+func TestWatcher_RemoveNonRecursive(t *testing.T) {
+    dir := newTestDir(t)
+    defer os.RemoveAll(dir)
+    w, err := New()
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer w.Close()
+
+    sep  := string(os.PathSeparator)
+    sub  := dir + sep + "sub"
+    file := dir + sep + "test.txt"
+    if err := os.MkdirAll(sub, 0755); err != nil {
+        t.Fatal(err)
+    }
+    if err := ioutil.WriteFile(file, nil, 0644); err != nil {
+        t.Fatal(err)
+    }
+    if _, err := w.Add(dir, func(event *Event) {}, false); err != nil {
+        t.Fatal(err)
+    }
+    if _, err := w.Add(file, func(event *Event) {}); err != nil {
+        t.Fatal(err)
+    }
+    if paths := w.Paths(); len(paths) != 2 {
+        t.Fatalf(`unexpected watched paths: %v`, paths)
+    }
+    if err := w.Remove(dir); err != nil {
+        t.Errorf(`unexpected error: %v`, err)
+    }
+    if paths := w.Paths(); len(paths) != 1 || paths[0] != file {
+        t.Errorf(`unexpected watched paths after removing: %v`, paths)
+    }
+    if err := w.RemoveSingle(file); err != nil {
+        t.Errorf(`unexpected error: %v`, err)
+    }
+    if paths := w.Paths(); len(paths) != 0 {
+        t.Errorf(`unexpected watched paths after removing: %v`, paths)
+    }
+}
+
+// 关闭监听管理对象后，所有内部的goroutine都需要退出，重复关闭是安全的
+func TestWatcher_CloseNoLeak(t *testing.T) {
+    dir := newTestDir(t)
+    defer os.RemoveAll(dir)
+
+    path := dir + string(os.PathSeparator) + "test.txt"
+    if err := ioutil.WriteFile(path, nil, 0644); err != nil {
+        t.Fatal(err)
+    }
+    count := runtime.NumGoroutine()
+    for i := 0; i < 10; i++ {
+        w, err := New()
+        if err != nil {
+            t.Fatal(err)
+        }
+        if _, err := w.Add(dir, func(event *Event) {}); err != nil {
+            t.Fatal(err)
+        }
+        ioutil.WriteFile(path, []byte("gf"), 0644)
+        if err := w.Close(); err != nil {
+            t.Error(err)
+        }
+        if err := w.Close(); err != nil {
+            t.Error(err)
+        }
+    }
+    // 等待异步执行的回调方法及底层fsnotify对象的goroutine退出
+    for i := 0; i < 50 && runtime.NumGoroutine() > count; i++ {
+        time.Sleep(10*time.Millisecond)
+    }
+    if n := runtime.NumGoroutine(); n > count {
+        t.Errorf(`goroutine leak: %d before, %d after closing`, count, n)
+    }
+}
+
+// 限制递归监听的深度，超出深度的目录不会被添加监听(包括新建的目录)
+func TestWatcher_MaxDepth(t *testing.T) {
+    dir := newTestDir(t)
+    defer os.RemoveAll(dir)
+    w, err := New()
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer w.Close()
+
+    sep := string(os.PathSeparator)
+    l1  := dir + sep + "1"
+    l2  := l1  + sep + "2"
+    l3  := l2  + sep + "3"
+    l4  := l3  + sep + "4"
+    if err := os.MkdirAll(l4, 0755); err != nil {
+        t.Fatal(err)
+    }
+    if _, err := w.Add(dir, func(event *Event) {}, WithMaxDepth(2)); err != nil {
+        t.Fatal(err)
+    }
+    checkPaths := func(expect []string) {
+        paths := w.Paths()
+        if len(paths) != len(expect) {
+            t.Fatalf(`unexpected watched paths: %v, expect: %v`, paths, expect)
+        }
+        for i, v := range expect {
+            if paths[i] != v {
+                t.Fatalf(`unexpected watched paths: %v, expect: %v`, paths, expect)
+            }
+        }
+    }
+    checkPaths([]string{dir, l1, l2})
+    // 新建的目录同样受到深度限制
+    if err := os.Mkdir(l1 + sep + "new", 0755); err != nil {
+        t.Fatal(err)
+    }
+    if err := os.Mkdir(l2 + sep + "new", 0755); err != nil {
+        t.Fatal(err)
+    }
+    time.Sleep(100*time.Millisecond)
+    checkPaths([]string{dir, l1, l2, l1 + sep + "new"})
+}
+
+// 将已经包含文件的目录移动到被监听的目录下，目录中已经存在的文件也需要收到新建事件
+func TestWatcher_CreateDirWithContents(t *testing.T) {
+    dir := newTestDir(t)
+    defer os.RemoveAll(dir)
+    src := newTestDir(t)
+    defer os.RemoveAll(src)
+    w, err := New()
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer w.Close()
+
+    sep := string(os.PathSeparator)
+    if err := os.MkdirAll(src + sep + "sub", 0755); err != nil {
+        t.Fatal(err)
+    }
+    if err := ioutil.WriteFile(src + sep + "sub" + sep + "test.txt", nil, 0644); err != nil {
+        t.Fatal(err)
+    }
+    moved   := dir + sep + "sub"
+    path    := moved + sep + "test.txt"
+    created := gtype.NewInt()
+    if _, err := w.Add(dir, func(event *Event) {
+        if event.Path == path && event.IsCreate() {
+            created.Add(1)
+        }
+    }); err != nil {
+        t.Fatal(err)
+    }
+    if err := os.Rename(src + sep + "sub", moved); err != nil {
+        t.Fatal(err)
+    }
+    time.Sleep(100*time.Millisecond)
+    if n := created.Val(); n != 1 {
+        t.Errorf(`CREATE event for "%s" received %d times, expected 1`, path, n)
+    }
+    if !w.callbacks.Contains(path) {
+        t.Errorf(`file "%s" in the moved directory is not watched`, path)
+    }
+}
+
+// Windows下的路径逐级向上检索回调时需要在卷根目录处结束，并且卷根目录本身的回调同样能够被检索到
+func TestWatcher_SearchCallbacksWindowsRoot(t *testing.T) {
+    w, err := New()
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer w.Close()
+
+    // 按照Windows的路径规则获取上级目录，卷根目录的上级目录为其自身
+    windowsDir := func(path string) string {
+        index := strings.LastIndex(path, `\`)
+        switch {
+            case index < 0:
+                return "."
+            case index == 2 && path[1] == ':':
+                return path[0 : 3]
+            default:
+                return path[0 : index]
+        }
+    }
+    list := glist.New()
+    list.PushBack(&Callback{ Path : `C:\` })
+    w.callbacks.Set(`C:\`, list)
+
+    if r := w.searchCallbacks(`C:\data\conf\app.toml`, windowsDir); len(r) != 1 {
+        t.Errorf(`callbacks of the volume root not found, got %d`, len(r))
+    }
+    if r := w.searchCallbacks(`C:\`, windowsDir); len(r) != 1 {
+        t.Errorf(`callbacks of the volume root itself not found, got %d`, len(r))
+    }
+    done := make(chan []interface{})
+    go func() {
+        done <- w.searchCallbacks(`D:\data\app.toml`, windowsDir)
+    }()
+    select {
+        case r := <- done:
+            if len(r) != 0 {
+                t.Errorf(`unexpected callbacks found on another volume: %d`, len(r))
+            }
+        case <- time.After(time.Second):
+            t.Fatal(`searching callbacks does not terminate at the volume root`)
+    }
+}
+
+// 同一目录下的两个文件分别注册回调，每一个回调只会收到其注册的文件本身的事件
+func TestWatcher_ExactFileCallbacks(t *testing.T) {
+    dir := newTestDir(t)
+    defer os.RemoveAll(dir)
+    w, err := New()
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer w.Close()
+
+    sep   := string(os.PathSeparator)
+    paths := []string{dir + sep + "a.txt", dir + sep + "b.txt"}
+    for _, path := range paths {
+        if err := ioutil.WriteFile(path, nil, 0644); err != nil {
+            t.Fatal(err)
+        }
+    }
+    counts := []*gtype.Int{gtype.NewInt(), gtype.NewInt()}
+    others := gtype.NewInt()
+    for i, path := range paths {
+        i, path := i, path
+        if _, err := w.Add(path, func(event *Event) {
+            if event.Path == path {
+                counts[i].Add(1)
+            } else {
+                others.Add(1)
+            }
+        }); err != nil {
+            t.Fatal(err)
+        }
+    }
+    for _, path := range paths {
+        if err := ioutil.WriteFile(path, []byte("gf"), 0644); err != nil {
+            t.Fatal(err)
+        }
+    }
+    time.Sleep(100*time.Millisecond)
+    for i, path := range paths {
+        if counts[i].Val() == 0 {
+            t.Errorf(`no event received for "%s"`, path)
+        }
+    }
+    if n := others.Val(); n != 0 {
+        t.Errorf(`%d events of the sibling file were delivered`, n)
+    }
+}
+
+// 可控的时间源，时间只会在调用Advance时前进，到期的定时器在Advance中同步执行
+type testClock struct {
+    mu     sync.Mutex
+    now    time.Time
+    timers []*testTimer
+}
+
+// 可控时间源的定时器
+type testTimer struct {
+    clock *testClock
+    when  time.Time
+    f     func()
+    alive bool
+}
+
+func newTestClock() *testClock {
+    return &testClock{ now : time.Unix(0, 0) }
+}
+
+func (c *testClock) Now() time.Time {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    return c.now
+}
+
+func (c *testClock) AfterFunc(d time.Duration, f func()) clockTimer {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    timer := &testTimer{ clock : c, when : c.now.Add(d), f : f, alive : true }
+    c.timers = append(c.timers, timer)
+    return timer
+}
+
+// 时间前进d，并执行所有到期的定时器
+func (c *testClock) Advance(d time.Duration) {
+    c.mu.Lock()
+    c.now = c.now.Add(d)
+    fired := make([]func(), 0)
+    for _, timer := range c.timers {
+        if timer.alive && !timer.when.After(c.now) {
+            timer.alive = false
+            fired = append(fired, timer.f)
+        }
+    }
+    c.mu.Unlock()
+    for _, f := range fired {
+        f()
+    }
+}
+
+func (t *testTimer) Stop() bool {
+    t.clock.mu.Lock()
+    defer t.clock.mu.Unlock()
+    alive  := t.alive
+    t.alive = false
+    return alive
+}
+
+func (t *testTimer) Reset(d time.Duration) bool {
+    t.clock.mu.Lock()
+    defer t.clock.mu.Unlock()
+    alive  := t.alive
+    t.alive = true
+    t.when  = t.clock.now.Add(d)
+    return alive
+}
+
+// 使用可控的时间源测试事件合并：窗口期内的多次写入只会在窗口结束后回调一次
+func TestWatcher_DebounceWithClock(t *testing.T) {
+    dir := newTestDir(t)
+    defer os.RemoveAll(dir)
+    clock  := newTestClock()
+    w, err := New(withClock(clock))
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer w.Close()
+
+    path := dir + string(os.PathSeparator) + "test.txt"
+    if err := ioutil.WriteFile(path, nil, 0644); err != nil {
+        t.Fatal(err)
+    }
+    events := make(chan *Event, 10)
+    if _, err := w.Add(path, func(event *Event) {
+        events <- event
+    }, WithDebounce(100*time.Millisecond)); err != nil {
+        t.Fatal(err)
+    }
+    for i := 0; i < 10; i++ {
+        ioutil.WriteFile(path, []byte{byte(i)}, 0644)
+    }
+    // 等待底层事件全部进入合并窗口(真实时间)，合并窗口本身使用可控的时间源计时
+    time.Sleep(100*time.Millisecond)
+    clock.Advance(99*time.Millisecond)
+    select {
+        case event := <- events:
+            t.Fatalf(`unexpected callback before the debounce window ends: %s`, event.String())
+        case <- time.After(50*time.Millisecond):
+    }
+    clock.Advance(time.Millisecond)
+    select {
+        case event := <- events:
+            if event.Path != path {
+                t.Errorf(`unexpected event path "%s"`, event.Path)
+            }
+        case <- time.After(time.Second):
+            t.Fatal(`no callback after the debounce window ends`)
+    }
+    select {
+        case event := <- events:
+            t.Errorf(`unexpected extra callback: %s`, event.String())
+        case <- time.After(50*time.Millisecond):
+    }
+}
+
+// WatchModified忽略CHMOD事件，并且编辑器写入临时文件后重命名覆盖的保存方式仍然能够继续监听
+func TestWatcher_WatchModifiedRenameSave(t *testing.T) {
+    dir := newTestDir(t)
+    defer os.RemoveAll(dir)
+    w, err := New()
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer w.Close()
+
+    sep  := string(os.PathSeparator)
+    path := dir + sep + "app.conf"
+    if err := ioutil.WriteFile(path, []byte("1"), 0644); err != nil {
+        t.Fatal(err)
+    }
+    count := gtype.NewInt()
+    if _, err := w.WatchModified(path, func(event *Event) {
+        if event.Path == path && event.IsWrite() {
+            count.Add(1)
+        }
+    }, WithDebounce(20*time.Millisecond)); err != nil {
+        t.Fatal(err)
+    }
+    if err := os.Chmod(path, 0600); err != nil {
+        t.Fatal(err)
+    }
+    time.Sleep(100*time.Millisecond)
+    if n := count.Val(); n != 0 {
+        t.Fatalf(`expected no callback for CHMOD, got %d`, n)
+    }
+    for i, content := range []string{"22", "333"} {
+        temp := dir + sep + ".app.conf.swp"
+        if err := ioutil.WriteFile(temp, []byte(content), 0644); err != nil {
+            t.Fatal(err)
+        }
+        if err := os.Rename(temp, path); err != nil {
+            t.Fatal(err)
+        }
+        time.Sleep(100*time.Millisecond)
+        if n := count.Val(); n != i + 1 {
+            t.Fatalf(`expected %d callbacks after rename-save, got %d`, i + 1, n)
+        }
+    }
+}
+
+// 监听目录内的重命名关联为一个MOVE事件(同时包含新旧路径)，移出监听目录时回调旧路径的REMOVE事件
+func TestWatcher_MoveEvents(t *testing.T) {
+    dir := newTestDir(t)
+    defer os.RemoveAll(dir)
+    out := newTestDir(t)
+    defer os.RemoveAll(out)
+    sep := string(os.PathSeparator)
+    if err := ioutil.WriteFile(dir + sep + "a.txt", nil, 0644); err != nil {
+        t.Fatal(err)
+    }
+    w, err := New()
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer w.Close()
+
+    events := make(chan *Event, 10)
+    if _, err := w.Add(dir, func(event *Event) {
+        events <- event
+    }); err != nil {
+        t.Fatal(err)
+    }
+    receive := func() []*Event {
+        array := make([]*Event, 0)
+        for {
+            select {
+                case event := <- events:
+                    array = append(array, event)
+                case <- time.After(3*RENAME_CORRELATE_INTERVAL*time.Millisecond):
+                    return array
+            }
+        }
+    }
+    if err := os.Rename(dir + sep + "a.txt", dir + sep + "b.txt"); err != nil {
+        t.Fatal(err)
+    }
+    array := receive()
+    if len(array) != 1 || !array[0].IsMove() || array[0].Path != dir + sep + "b.txt" || array[0].OldPath != dir + sep + "a.txt" {
+        t.Fatalf(`expected a single MOVE event from a.txt to b.txt, got %v`, array)
+    }
+    if err := os.Rename(dir + sep + "b.txt", out + sep + "b.txt"); err != nil {
+        t.Fatal(err)
+    }
+    array = receive()
+    if len(array) != 1 || !array[0].IsRemove() || array[0].Path != dir + sep + "b.txt" {
+        t.Fatalf(`expected a single REMOVE event for b.txt, got %v`, array)
+    }
+}
+
+// 对重叠的目录树分别递归添加监听，通过RemoveCallback移除其中一个回调对象时，
+// 只移除该回调对象添加的监听(包括添加之后新建的目录)，另一个回调对象的监听不受影响
+func TestWatcher_RemoveCallbackOverlapping(t *testing.T) {
+    dir := newTestDir(t)
+    defer os.RemoveAll(dir)
+    w, err := New()
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer w.Close()
+
+    sep := string(os.PathSeparator)
+    sub := dir + sep + "sub"
+    if err := os.MkdirAll(sub, 0755); err != nil {
+        t.Fatal(err)
+    }
+    countA := gtype.NewInt()
+    countB := gtype.NewInt()
+    a, err := w.Add(dir, func(event *Event) {
+        countA.Add(1)
+    })
+    if err != nil {
+        t.Fatal(err)
+    }
+    b, err := w.Add(sub, func(event *Event) {
+        countB.Add(1)
+    })
+    if err != nil {
+        t.Fatal(err)
+    }
+    // 添加监听之后新建的目录同样属于各自回调对象的监听
+    if err := os.MkdirAll(sub + sep + "new", 0755); err != nil {
+        t.Fatal(err)
+    }
+    time.Sleep(100*time.Millisecond)
+    if paths := a.Paths(); len(paths) != 3 || paths[0] != dir || paths[2] != sub + sep + "new" {
+        t.Fatalf(`unexpected paths of the first callback: %v`, paths)
+    }
+    if paths := b.Paths(); len(paths) != 2 || paths[0] != sub || paths[1] != sub + sep + "new" {
+        t.Fatalf(`unexpected paths of the second callback: %v`, paths)
+    }
+
+    if err := w.RemoveCallback(a); err != nil {
+        t.Fatal(err)
+    }
+    if paths := w.Paths(); len(paths) != 2 || paths[0] != sub || paths[1] != sub + sep + "new" {
+        t.Fatalf(`unexpected watched paths after removing the first callback: %v`, paths)
+    }
+    countA.Set(0)
+    countB.Set(0)
+    if err := ioutil.WriteFile(sub + sep + "new" + sep + "test.txt", []byte("gf"), 0644); err != nil {
+        t.Fatal(err)
+    }
+    if err := ioutil.WriteFile(dir + sep + "test.txt", []byte("gf"), 0644); err != nil {
+        t.Fatal(err)
+    }
+    time.Sleep(100*time.Millisecond)
+    if n := countA.Val(); n != 0 {
+        t.Errorf(`%d events were delivered to the removed callback`, n)
+    }
+    if n := countB.Val(); n == 0 {
+        t.Errorf(`no event was delivered to the remaining callback`)
+    }
+
+    if err := w.RemoveCallback(b); err != nil {
+        t.Fatal(err)
+    }
+    if paths := w.Paths(); len(paths) != 0 {
+        t.Errorf(`unexpected watched paths after removing both callbacks: %v`, paths)
+    }
+}
+
+// AddPaths批量添加监听，部分路径失败时返回包含失败路径的错误，其他路径正常添加，通过同一个回调对象移除所有路径的监听
+func TestWatcher_AddPaths(t *testing.T) {
+    dir := newTestDir(t)
+    defer os.RemoveAll(dir)
+    w, err := New()
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer w.Close()
+
+    sep     := string(os.PathSeparator)
+    a       := dir + sep + "a"
+    b       := dir + sep + "b"
+    missing := dir + sep + "missing"
+    for _, v := range []string{a, b} {
+        if err := os.MkdirAll(v, 0755); err != nil {
+            t.Fatal(err)
+        }
+    }
+    count := gtype.NewInt()
+    callback, err := w.AddPaths([]string{a, missing, b}, func(event *Event) {
+        count.Add(1)
+    })
+    if err == nil || !strings.Contains(err.Error(), missing) || strings.Contains(err.Error(), a) {
+        t.Fatalf(`unexpected error: %v`, err)
+    }
+    if callback == nil {
+        t.Fatal("expected callback for the paths added successfully")
+    }
+    if paths := callback.Paths(); len(paths) != 2 || paths[0] != a || paths[1] != b {
+        t.Fatalf(`unexpected paths: %v`, paths)
+    }
+    for _, v := range []string{a, b} {
+        if err := ioutil.WriteFile(v + sep + "test.txt", []byte("gf"), 0644); err != nil {
+            t.Fatal(err)
+        }
+    }
+    time.Sleep(100*time.Millisecond)
+    if count.Val() < 2 {
+        t.Fatalf(`expected events from both paths, got %d`, count.Val())
+    }
+
+    if err := w.RemoveCallback(callback); err != nil {
+        t.Fatal(err)
+    }
+    if paths := w.Paths(); len(paths) != 0 {
+        t.Errorf(`unexpected watched paths after removing the callback: %v`, paths)
+    }
+    if err := RemoveCallbackById(callback.Id); err == nil {
+        t.Errorf(`callback id should be removed`)
+    }
+    if _, err := w.AddPaths([]string{missing}, func(event *Event) {}); err == nil {
+        t.Errorf(`expected error when all paths fail`)
+    }
+}
+
+// AddGlob只监听可能存在满足规则的文件的目录，只回调满足规则的文件的事件，新建的满足规则的目录会自动添加监听
+func TestWatcher_AddGlob(t *testing.T) {
+    dir := newTestDir(t)
+    defer os.RemoveAll(dir)
+    w, err := New()
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer w.Close()
+
+    sep := string(os.PathSeparator)
+    for _, v := range []string{"x" + sep + "conf", "x" + sep + "other"} {
+        if err := os.MkdirAll(dir + sep + v, 0755); err != nil {
+            t.Fatal(err)
+        }
+    }
+    for _, v := range []string{"a.yaml", "a.txt"} {
+        if err := ioutil.WriteFile(dir + sep + "x" + sep + "conf" + sep + v, []byte("gf"), 0644); err != nil {
+            t.Fatal(err)
+        }
+    }
+    paths := glist.New()
+    _, err = w.AddGlob(dir + "/*/conf/*.yaml", func(event *Event) {
+        paths.PushBack(event.Path)
+    })
+    if err != nil {
+        t.Fatal(err)
+    }
+    watched := strings.Join(w.Paths(), ",")
+    expect  := strings.Join([]string{dir, dir + sep + "x", dir + sep + "x" + sep + "conf", dir + sep + "x" + sep + "conf" + sep + "a.yaml"}, ",")
+    if watched != expect {
+        t.Fatalf(`unexpected watched paths: %s`, watched)
+    }
+
+    // 不满足规则的文件的事件不会回调
+    if err := ioutil.WriteFile(dir + sep + "x" + sep + "conf" + sep + "a.txt", []byte("gf"), 0644); err != nil {
+        t.Fatal(err)
+    }
+    if err := ioutil.WriteFile(dir + sep + "x" + sep + "other" + sep + "b.yaml", []byte("gf"), 0644); err != nil {
+        t.Fatal(err)
+    }
+    if err := ioutil.WriteFile(dir + sep + "x" + sep + "conf" + sep + "a.yaml", []byte("gf"), 0644); err != nil {
+        t.Fatal(err)
+    }
+    // 新建满足规则的目录后，其下新建的文件同样能够被监听到
+    if err := os.MkdirAll(dir + sep + "y" + sep + "conf", 0755); err != nil {
+        t.Fatal(err)
+    }
+    time.Sleep(100*time.Millisecond)
+    if err := ioutil.WriteFile(dir + sep + "y" + sep + "conf" + sep + "b.yaml", []byte("gf"), 0644); err != nil {
+        t.Fatal(err)
+    }
+    time.Sleep(100*time.Millisecond)
+
+    received := make(map[string]bool)
+    for _, v := range paths.FrontAll() {
+        received[v.(string)] = true
+    }
+    for path := range received {
+        if !strings.HasSuffix(path, ".yaml") || strings.Contains(path, "other") {
+            t.Errorf(`unexpected event for "%s"`, path)
+        }
+    }
+    for _, path := range []string{dir + sep + "x" + sep + "conf" + sep + "a.yaml", dir + sep + "y" + sep + "conf" + sep + "b.yaml"} {
+        if !received[path] {
+            t.Errorf(`expected event for "%s", received %v`, path, received)
+        }
+    }
+
+    for pattern, cases := range map[string]map[string]bool {
+        "**/*.yaml"    : { "a.yaml" : true, "x/y/a.yaml" : true, "a.txt" : false },
+        "conf/**/*.yml": { "conf/a.yml" : true, "conf/x/a.yml" : true, "other/a.yml" : false },
+    } {
+        glob := strings.Split(pattern, "/")
+        for path, match := range cases {
+            if matchGlob(glob, strings.Split(path, "/"), false) != match {
+                t.Errorf(`unexpected match result of "%s" for pattern "%s"`, path, pattern)
+            }
+        }
+    }
+    if _, err := w.AddGlob(dir + "/[", func(event *Event) {}); err == nil {
+        t.Errorf(`expected error for invalid glob pattern`)
+    }
+
+    // 调用方传入的options有剩余容量时，追加的参数不能写入调用方切片的底层数组
+    options := make([]interface{}, 1, 3)
+    options[0] = WithIgnoreChmod(true)
+    if _, err := w.AddGlob(dir + "/*/conf/*.txt", func(event *Event) {}, options...); err != nil {
+        t.Fatal(err)
+    }
+    if spare := options[:3]; spare[1] != nil || spare[2] != nil {
+        t.Errorf(`AddGlob modified the options passed in: %v`, spare)
+    }
+}
+
+// Tail读取已有的内容并跟踪追加写入，文件被截断时从开头重新读取，文件被重命名轮转时读取完旧文件后打开新的文件
+func TestWatcher_TailRotate(t *testing.T) {
+    dir := newTestDir(t)
+    defer os.RemoveAll(dir)
+    w, err := New()
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer w.Close()
+
+    sep  := string(os.PathSeparator)
+    path := dir + sep + "app.log"
+    if err := ioutil.WriteFile(path, []byte("a\nb"), 0644); err != nil {
+        t.Fatal(err)
+    }
+    lines  := make(chan string, 10)
+    result := make(chan error, 1)
+    ctx, cancel := context.WithCancel(context.Background())
+    go func() {
+        result <- w.Tail(ctx, path, func(line string) error {
+            lines <- line
+            return nil
+        })
+    }()
+    expect := func(expected...string) {
+        for _, v := range expected {
+            select {
+                case line := <- lines:
+                    if line != v {
+                        t.Fatalf(`expected line "%s", got "%s"`, v, line)
+                    }
+                case <- time.After(2*time.Second):
+                    t.Fatalf(`timeout waiting for line "%s"`, v)
+            }
+        }
+    }
+    expect("a")
+    file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+    if err != nil {
+        t.Fatal(err)
+    }
+    file.WriteString("1\nc\n")
+    expect("b1", "c")
+    // 截断
+    file.Truncate(0)
+    file.WriteString("d\n")
+    expect("d")
+    // 重命名轮转：旧文件在新文件创建之前写入的内容仍然会被读取
+    file.WriteString("e\n")
+    expect("e")
+    if err := os.Rename(path, path + ".1"); err != nil {
+        t.Fatal(err)
+    }
+    time.Sleep(100*time.Millisecond)
+    file.WriteString("f\n")
+    file.Close()
+    if err := ioutil.WriteFile(path, []byte("g\n"), 0644); err != nil {
+        t.Fatal(err)
+    }
+    expect("f", "g")
+
+    cancel()
+    select {
+        case err := <- result:
+            if err != context.Canceled {
+                t.Fatalf(`expected context.Canceled, got %v`, err)
+            }
+        case <- time.After(2*time.Second):
+            t.Fatal("timeout waiting for Tail to return")
+    }
+
+    // 回调方法返回错误时停止跟踪并返回该错误
+    stop := errors.New("stop")
+    if err := w.Tail(nil, path, func(line string) error {
+        return stop
+    }); err != stop {
+        t.Fatalf(`expected the handler error, got %v`, err)
+    }
+}
@@ -0,0 +1,139 @@
+// Copyright 2018 gf Author(https://gitee.com/johng/gf). All Rights Reserved.
+//
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT was not distributed with this file,
+// You can obtain one at https://gitee.com/johng/gf.
+
+package gfsnotify
+
+import (
+    "errors"
+    "fmt"
+    "gitee.com/johng/gf/g/container/glist"
+    "gitee.com/johng/gf/g/container/gtype"
+    "gitee.com/johng/gf/g/os/gtime"
+    "path/filepath"
+    "strings"
+)
+
+// 添加对不存在路径的等待监听：回调对象先注册到目标路径(不添加底层监听)，
+// 同时监听目标路径最近的已存在的上级目录，当目标路径被创建后，转换为对目标路径的直接监听，并回调CREATE事件。
+func (w *Watcher) addWaitForCreate(path string, callbackFunc func(event *Event), recursive bool, option WatchOption) (callback *Callback, err error) {
+    path, err = filepath.Abs(path)
+    if err != nil {
+        return nil, err
+    }
+    ancestor := fileNearestAncestor(path)
+    if ancestor == "" {
+        return nil, errors.New(fmt.Sprintf(`no existing ancestor directory for "%s"`, path))
+    }
+    // 使用上级目录的真实路径拼接目标路径，保证与底层事件的路径一致
+    relative, _ := filepath.Rel(ancestor, path)
+    callback = &Callback {
+        Id        : int(gtime.Nanosecond()),
+        Func      : callbackFunc,
+        Path      : filepath.Join(fileRealPath(ancestor), relative),
+        watcher   : w,
+        subs      : glist.New(),
+        option    : option,
+        recursive : recursive,
+        waiting   : gtype.NewBool(true),
+    }
+    w.callbacks.LockFunc(func(m map[string]interface{}) {
+        var result interface{}
+        if v, ok := m[callback.Path]; !ok {
+            result  = glist.New()
+            m[callback.Path] = result
+        } else {
+            result = v
+        }
+        callback.elem = result.(*glist.List).PushBack(callback)
+    })
+    callbackIdMap.Set(callback.Id, callback)
+    w.watchAncestor(callback)
+    return callback, nil
+}
+
+// 监听等待创建的目标路径最近的已存在的上级目录，如果目标路径已存在，那么直接转换为对目标路径的监听
+func (w *Watcher) watchAncestor(callback *Callback) {
+    for callback.waiting.Val() {
+        ancestor := fileNearestAncestor(callback.Path)
+        if ancestor == callback.Path {
+            w.promoteCallback(callback, true)
+            return
+        }
+        w.removeWaiters(callback)
+        sub, err := w.addWatch(ancestor, func(event *Event) {
+            w.onAncestorEvent(callback, event)
+        }, WatchOption{}, callback)
+        if err == nil {
+            sub.waiter = true
+        }
+        // 添加监听后再次检查，避免在添加监听期间目标路径(或者中间目录)已被创建而丢失事件
+        if fileNearestAncestor(callback.Path) == ancestor {
+            break
+        }
+    }
+    // 期间已经转换为直接监听，那么移除可能残留的上级目录监听
+    if !callback.waiting.Val() {
+        w.removeWaiters(callback)
+    }
+}
+
+// 上级目录的事件处理，当目标路径的中间目录被创建时，将监听转移到更接近目标路径的目录
+func (w *Watcher) onAncestorEvent(callback *Callback, event *Event) {
+    if !callback.waiting.Val() || !event.IsCreate() {
+        return
+    }
+    if strings.HasPrefix(callback.Path, event.Path + string(filepath.Separator)) {
+        w.watchAncestor(callback)
+    }
+}
+
+// 将等待创建的回调对象转换为对目标路径的直接监听，synthetic表示是否需要主动回调CREATE事件
+// (目标路径的创建事件未经过事件循环，例如在添加上级目录监听期间被创建)
+func (w *Watcher) promoteCallback(callback *Callback, synthetic bool) {
+    if !callback.waiting.Set(false) {
+        return
+    }
+    w.removeWaiters(callback)
+    callback.linkParent, _ = w.addUnderlyingWatch(callback.Path, callback.option.Symlink)
+    if callback.recursive && fileIsDir(callback.Path) {
+        root     := callback.Path
+        paths, _ := fileScanDirFunc(root, callback.option.Symlink != SYMLINK_NOFOLLOW, func(path string, isDir bool) bool {
+            return callback.option.acceptWatch(root, path, isDir)
+        })
+        for _, v := range paths {
+            w.addWatch(v, callback.Func, callback.option, callback)
+        }
+    }
+    if synthetic {
+        w.dispatch(callback, &Event {
+            time    : w.millisecond(),
+            Path    : callback.Path,
+            Op      : CREATE,
+            Watcher : w,
+        })
+    }
+}
+
+// 移除等待创建的回调对象的上级目录监听
+func (w *Watcher) removeWaiters(callback *Callback) {
+    for _, v := range callback.subs.FrontAll() {
+        if sub := v.(*Callback); sub.waiter {
+            w.removeCallback(sub)
+        }
+    }
+}
+
+// 获取给定路径最近的已存在的路径(路径本身存在时返回路径本身)，不存在时返回空字符串
+func fileNearestAncestor(path string) string {
+    for !fileExists(path) {
+        parent := fileDir(path)
+        if parent == path {
+            return ""
+        }
+        path = parent
+    }
+    return path
+}
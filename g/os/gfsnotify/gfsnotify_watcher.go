@@ -10,43 +10,85 @@ import (
     "errors"
     "fmt"
     "gitee.com/johng/gf/g/container/glist"
+    "gitee.com/johng/gf/g/container/gtype"
     "gitee.com/johng/gf/g/os/gtime"
+    "path/filepath"
+    "reflect"
+    "sort"
+    "strings"
+    "syscall"
 )
 
-// 关闭监听管理对象
-func (w *Watcher) Close() {
-    w.watcher.Close()
-    w.events.Close()
+// 关闭监听管理对象，未处理的事件以及等待合并回调的事件将会被丢弃，返回关闭底层fsnotify对象时的错误，重复调用是安全的。
+// 关闭时会等待监听循环及事件循环退出后再释放资源，因此不能在错误处理方法或者OnOverflow回调中调用(会造成死锁)。
+func (w *Watcher) Close() error {
+    if w.closed.Set(true) {
+        return nil
+    }
     close(w.closeChan)
+    err := w.watcher.Close()
+    // 事件队列由监听循环在退出时关闭，事件循环在事件队列关闭后退出
+    w.loopWg.Wait()
+    w.cancelRename()
+    w.cancelDebounces()
+    // 清除所有的回调注册(包括尚在等待创建的回调)，并从全局的ID映射中移除
+    w.callbacks.LockFunc(func(m map[string]interface{}) {
+        for path, v := range m {
+            for _, r := range v.(*glist.List).FrontAll() {
+                callback := r.(*Callback)
+                if callback.waiting != nil {
+                    callback.waiting.Set(false)
+                }
+                if callback.parent == nil {
+                    callbackIdMap.Remove(callback.Id)
+                }
+                if callback.group != nil {
+                    callbackIdMap.Remove(callback.group.Id)
+                }
+            }
+            delete(m, path)
+        }
+    })
+    return err
+}
+
+// 阻塞等待，直到监听管理对象被关闭(调用Close或者绑定的ctx被取消)
+func (w *Watcher) Wait() {
+    <- w.closeChan
 }
 
 // 添加对指定文件/目录的监听，并给定回调函数
-func (w *Watcher) addWatch(path string, calbackFunc func(event *Event), parentCallback *Callback) (callback *Callback, err error) {
-    // 这里统一转换为当前系统的绝对路径，便于统一监控文件名称
-    t := fileRealPath(path)
-    if t == "" {
-        return nil, errors.New(fmt.Sprintf(`"%s" does not exist`, path))
-    }
-    path = t
-    // 添加成功后会注册该callback id到全局的哈希表，并绑定到父级的注册回调中
+func (w *Watcher) addWatch(path string, calbackFunc func(event *Event), option WatchOption, parentCallback *Callback) (callback *Callback, err error) {
+    // 这里统一转换为当前系统的绝对路径，便于统一监控文件名称；RawPath时不检查路径是否存在
+    if option.RawPath {
+        t, e := filepath.Abs(path)
+        if e != nil {
+            return nil, e
+        }
+        path = t
+    } else {
+        t := fileRealPath(path)
+        if t == "" {
+            return nil, errors.New(fmt.Sprintf(`"%s" does not exist`, path))
+        }
+        path = t
+    }
+    // 添加成功后会注册该callback id到全局的哈希表
     defer func() {
-        if err == nil {
-            if parentCallback == nil {
-                // 只有主callback才记录到id map中，因为子callback是自动管理的无需添加到全局id映射map中
-                callbackIdMap.Set(callback.Id, callback)
-            }
-            if parentCallback != nil {
-                // 添加到直属父级的subs属性中，建立关联关系，便于后续删除
-                parentCallback.subs.PushBack(callback)
-            }
+        if err == nil && parentCallback == nil {
+            // 只有主callback才记录到id map中，因为子callback是自动管理的无需添加到全局id映射map中
+            callbackIdMap.Set(callback.Id, callback)
         }
     }()
     callback = &Callback {
-        Id     : int(gtime.Nanosecond()),
-        Func   : calbackFunc,
-        Path   : path,
-        subs   : glist.New(),
-        parent : parentCallback,
+        Id      : int(gtime.Nanosecond()),
+        Func    : calbackFunc,
+        Path    : path,
+        watcher : w,
+        subs    : glist.New(),
+        parent  : parentCallback,
+        option  : option,
+        file    : !fileIsDir(path),
     }
     // 注册回调函数
     w.callbacks.LockFunc(func(m map[string]interface{}) {
@@ -58,111 +100,376 @@ func (w *Watcher) addWatch(path string, calbackFunc func(event *Event), parentCa
             result = v
         }
         callback.elem = result.(*glist.List).PushBack(callback)
+        // 添加到直属父级的subs属性中，建立关联关系，便于后续删除；
+        // 需要在注册回调的锁中设置，保证事件循环检索到该callback时能够读取到完整的关联关系
+        if parentCallback != nil {
+            callback.parentElem = parentCallback.subs.PushBack(callback)
+        }
     })
-    // 添加底层监听
-    w.watcher.Add(path)
+    // 添加底层监听，监听数量达到系统限制时交由错误处理方法处理
+    linkParent, e := w.addUnderlyingWatch(path, option.Symlink)
+    if linkParent != "" {
+        w.callbacks.LockFunc(func(m map[string]interface{}) {
+            callback.linkParent = linkParent
+        })
+    }
+    if e != nil && isWatchLimitError(e) {
+        w.handleError(&WatchLimitError{ Path : path, Count : w.WatchCount(), Err : e })
+    }
+    // RawPath时底层监听失败需要返回错误，并移除已注册的回调
+    if e != nil && option.RawPath {
+        w.removeCallback(callback)
+        return nil, e
+    }
     return
 }
 
-// 添加监控，path参数支持文件或者目录路径，recursive为非必需参数，默认为递归添加监控(当path为目录时)。
+// 添加监控，path参数支持文件或者目录路径，recursive表示当path为目录时是否递归添加监控。
 // 如果添加目录，这里只会返回目录的callback，按照callback删除时会递归删除。
-func (w *Watcher) addWithCallback(parentCallback *Callback, path string, callbackFunc func(event *Event), recursive...bool) (callback *Callback, err error) {
+func (w *Watcher) addWithCallback(parentCallback *Callback, path string, callbackFunc func(event *Event), recursive bool, option WatchOption) (callback *Callback, err error) {
     // 首先添加这个目录
-    if callback, err = w.addWatch(path, callbackFunc, parentCallback); err != nil {
+    if callback, err = w.addWatch(path, callbackFunc, option, parentCallback); err != nil {
         return nil, err
     }
-    // 其次递归添加其下的文件/目录
-    if fileIsDir(path) && (len(recursive) == 0 || recursive[0]) {
-        paths, _ := fileScanDir(path, "*", true)
+    // 其次递归添加其下的文件/目录，不满足配置项过滤规则或者超出最大深度的文件/目录不会被添加
+    if recursive && fileIsDir(path) {
+        callback.recursive = true
+        root     := callback.rootPath()
+        paths, _ := fileScanDirFunc(path, option.Symlink != SYMLINK_NOFOLLOW, func(path string, isDir bool) bool {
+            return option.acceptWatch(root, path, isDir)
+        })
         for _, v := range paths {
-            w.addWatch(v, callbackFunc, callback)
+            w.addWatch(v, callbackFunc, option, callback)
         }
     }
     return
 }
 
-// 添加监控，path参数支持文件或者目录路径，recursive为非必需参数，默认为递归添加监控(当path为目录时)。
+// 添加监控，path参数支持文件或者目录路径。
+// options为非必需参数，支持bool类型(是否递归监听，当path为目录时默认递归添加监控)及WatchOption类型(监听配置项)，
+// 例如：Add(path, callback, false, WithDebounce(100*time.Millisecond))。
 // 如果添加目录，这里只会返回目录的callback，按照callback删除时会递归删除。
-func (w *Watcher) Add(path string, callbackFunc func(event *Event), recursive...bool) (callback *Callback, err error) {
-    return w.addWithCallback(nil, path, callbackFunc, recursive...)
+func (w *Watcher) Add(path string, callbackFunc func(event *Event), options...interface{}) (callback *Callback, err error) {
+    recursive, option, err := parseWatchOptions(options, w.recursive.Val())
+    if err != nil {
+        return nil, err
+    }
+    if option.WaitForCreate && !fileExists(path) {
+        return w.addWaitForCreate(path, callbackFunc, recursive, option)
+    }
+    if callback, err = w.addWithCallback(nil, path, callbackFunc, recursive, option); err == nil && option.InitialEvent {
+        w.sendInitialEvents(callback)
+    }
+    return
 }
 
-// 递归移除对指定文件/目录的所有监听回调
-func (w *Watcher) Remove(path string) error {
-    if fileIsDir(path) {
-        paths, _ := fileScanDir(path, "*", true)
-        paths     = append(paths, path)
-        for _, v := range paths {
-            if err := w.removeAll(v); err != nil {
-                return err
+// 对回调对象已添加监听的每一个路径(包括递归添加的子级路径)回调一次初始事件，只回调给该回调对象，不经过事件循环
+func (w *Watcher) sendInitialEvents(callback *Callback) {
+    // 子级路径均以回调对象的路径为前缀，因此排序后回调对象的路径始终在最前
+    for _, path := range callback.Paths() {
+        if !callback.option.acceptOp(CREATE) || !callback.option.accept(callback.Path, path, fileIsDir(path)) {
+            continue
+        }
+        w.dispatch(callback, &Event {
+            time    : w.millisecond(),
+            Path    : path,
+            Op      : CREATE,
+            Initial : true,
+            Watcher : w,
+        })
+    }
+}
+
+// 添加监听，如果该路径已经存在相同回调函数的注册(通过Add/AddUnique添加的主callback)，那么不再重复注册，直接返回已存在的回调对象。
+// 由于Go的函数值不能直接比较，这里使用reflect获取的函数入口地址进行比较：
+// 同一个具名函数或者方法表达式会被认为是相同的回调函数；同一处函数字面量(闭包)创建的多个函数值即使捕获的变量不同，
+// 也会被认为是相同的回调函数，因此对于闭包需要注意该语义。
+func (w *Watcher) AddUnique(path string, callbackFunc func(event *Event), options...interface{}) (callback *Callback, err error) {
+    if callback = w.findCallback(fileAbsPath(path), callbackFunc); callback != nil {
+        return callback, nil
+    }
+    return w.Add(path, callbackFunc, options...)
+}
+
+// 查找指定路径下相同回调函数的主callback，不存在时返回nil
+func (w *Watcher) findCallback(path string, callbackFunc func(event *Event)) *Callback {
+    if r := w.callbacks.Get(path); r != nil {
+        pointer := reflect.ValueOf(callbackFunc).Pointer()
+        for _, v := range r.(*glist.List).FrontAll() {
+            if callback := v.(*Callback); callback.parent == nil && reflect.ValueOf(callback.Func).Pointer() == pointer {
+                return callback
             }
         }
-        return nil
-    } else {
+    }
+    return nil
+}
+
+// 添加一次性的监听，回调函数只会在第一次匹配的事件时执行一次，随后自动移除该回调(同一路径下的其他回调不受影响)。
+// 如果添加的是递归监听的目录，那么目录下任意位置的第一次事件都会触发回调。options参数同Add方法。
+func (w *Watcher) AddOnce(path string, callbackFunc func(event *Event), options...interface{}) (callback *Callback, err error) {
+    fired := gtype.NewBool()
+    ready := make(chan struct{})
+    callback, err = w.Add(path, func(event *Event) {
+        if fired.Set(true) {
+            return
+        }
+        // 等待Add方法返回，保证能够获取到回调对象
+        <- ready
+        w.RemoveCallback(callback)
+        callbackFunc(event)
+    }, options...)
+    close(ready)
+    return
+}
+
+// 使用同一个回调函数批量添加多个文件/目录的监听，options参数同Add方法。
+// 返回的回调对象管理所有添加成功的路径(见Callback.Paths)，通过RemoveCallback移除该回调对象时会一并移除所有路径的监听。
+// 部分路径添加失败时不会影响其他路径的添加，返回的错误信息中包含所有添加失败的路径；所有路径均添加失败时返回的回调对象为nil。
+func (w *Watcher) AddPaths(paths []string, callbackFunc func(event *Event), options...interface{}) (callback *Callback, err error) {
+    return w.addPaths(paths, callbackFunc, options, func(path string) (*Watcher, error) {
+        return w, nil
+    })
+}
+
+// 批量添加监听，getWatcher用于获取每一个路径所属的监听对象
+func (w *Watcher) addPaths(paths []string, callbackFunc func(event *Event), options []interface{}, getWatcher func(path string) (*Watcher, error)) (*Callback, error) {
+    if len(paths) == 0 {
+        return nil, errors.New("no path given")
+    }
+    if _, _, err := parseWatchOptions(options, w.recursive.Val()); err != nil {
+        return nil, err
+    }
+    group := &Callback {
+        Id      : int(gtime.Nanosecond()),
+        Func    : callbackFunc,
+        watcher : w,
+        subs    : glist.New(),
+        multi   : true,
+    }
+    errs := make([]string, 0)
+    for _, path := range paths {
+        watcher, err := getWatcher(path)
+        if err == nil {
+            var sub *Callback
+            if sub, err = watcher.Add(path, callbackFunc, options...); err == nil {
+                watcher.callbacks.LockFunc(func(m map[string]interface{}) {
+                    sub.group      = group
+                    sub.parentElem = group.subs.PushBack(sub)
+                })
+                continue
+            }
+        }
+        errs = append(errs, fmt.Sprintf(`add "%s" failed: %v`, path, err))
+    }
+    err := error(nil)
+    if len(errs) > 0 {
+        err = errors.New(strings.Join(errs, "; "))
+    }
+    if group.subs.Len() == 0 {
+        return nil, err
+    }
+    callbackIdMap.Set(group.Id, group)
+    return group, err
+}
+
+// 递归移除对指定文件/目录的所有监听回调，递归与否与添加监听时保持一致：
+// 如果该路径的回调都是非递归添加的，那么只移除该路径本身的监听(同RemoveSingle)。
+// 移除过程中的错误不会中断移除，而是继续移除其余的文件/目录，最终返回合并的错误信息；
+// 已经不在底层监听中的文件/目录(例如已被重命名或者删除)不会被视为错误。
+func (w *Watcher) Remove(path string) error {
+    path = fileAbsPath(path)
+    if !w.isRecursive(path) {
         return w.removeAll(path)
     }
+    // 按照已注册的回调检索子级路径，而不是检索磁盘文件，保证已经不存在的子级路径也能被移除
+    paths  := []string{path}
+    prefix := path + string(filepath.Separator)
+    for _, v := range w.callbacks.Keys() {
+        if strings.HasPrefix(v, prefix) {
+            paths = append(paths, v)
+        }
+    }
+    errs := make([]string, 0)
+    for _, v := range paths {
+        if err := w.removeAll(v); err != nil {
+            errs = append(errs, err.Error())
+        }
+    }
+    if len(errs) > 0 {
+        return errors.New(strings.Join(errs, "; "))
+    }
+    return nil
+}
+
+// 只移除对指定文件/目录本身的所有监听回调(包括这些回调自动管理的子级回调)，
+// 不会移除该目录下单独添加的其他文件/目录的监听。
+func (w *Watcher) RemoveSingle(path string) error {
+    return w.removeAll(fileAbsPath(path))
+}
+
+// 判断指定路径的监听是否为递归监听，只要其中一个回调为递归添加即为递归监听；没有注册回调的路径默认为递归
+func (w *Watcher) isRecursive(path string) bool {
+    r := w.callbacks.Get(path)
+    if r == nil {
+        return true
+    }
+    for _, v := range r.(*glist.List).FrontAll() {
+        if v.(*Callback).recursive {
+            return true
+        }
+    }
+    return false
 }
 
-// 移除对指定文件/目录的所有监听
+// 移除对指定文件/目录的所有监听，不在底层监听中的错误将会被忽略
 func (w *Watcher) removeAll(path string) error {
-    // 首先移除所有该path的回调注册
+    // 首先移除所有该path的回调注册，当最后一个回调被移除时会同时移除底层的监听
     if r := w.callbacks.Get(path); r != nil {
+        errs := make([]string, 0)
         list := r.(*glist.List)
         for {
             if r := list.PopFront(); r != nil {
-                w.removeCallback(r.(*Callback))
+                if err := w.removeCallback(r.(*Callback)); err != nil && !isNotWatchedError(err) {
+                    errs = append(errs, err.Error())
+                }
             } else {
                 break
             }
         }
+        w.callbacks.Remove(path)
+        if len(errs) > 0 {
+            return errors.New(strings.Join(errs, "; "))
+        }
+        return nil
+    }
+    // 没有回调注册时，直接移除底层的监听
+    if err := w.watcher.Remove(path); err != nil && !isNotWatchedError(err) {
+        return err
     }
-    // 其次移除该path的监听注册
-    w.callbacks.Remove(path)
-    // 最后移除底层的监听
-    return w.watcher.Remove(path)
+    return nil
 }
 
-// 根据指定的回调函数ID，移出指定的inotify回调函数
-func (w *Watcher) RemoveCallback(callbackId int) error {
-    callback := (*Callback)(nil)
-    if r := callbackIdMap.Get(callbackId); r != nil {
-        callback = r.(*Callback)
-    }
-    if callback == nil {
-        return errors.New(fmt.Sprintf(`callback for id %d not found`, callbackId))
+// 判断是否为文件/目录不在底层监听中的错误
+func isNotWatchedError(err error) bool {
+    return err == syscall.EINVAL || strings.Contains(err.Error(), "non-existent")
+}
+
+// 根据Add返回的回调对象，移除指定的监听回调，同一路径下的其他回调不受影响；
+// 如果该回调为目录的递归监听回调，那么其自动管理的子级回调(见Callback.Paths)也会一并移除，
+// 只有当路径上不再有任何回调时才会移除底层监听，因此与Remove(path)不同，不会影响其他调用方对重叠路径的监听。
+func (w *Watcher) RemoveCallback(callback *Callback) error {
+    if callback == nil || callback.watcher != w {
+        return errors.New("callback does not belong to current watcher")
     }
-    w.removeCallback(callback)
-    return nil
+    return w.removeCallback(callback)
 }
 
-// 移除对指定文件/目录的所有监听
-func (w *Watcher) removeCallback(callback *Callback) error {
-    if r := w.callbacks.Get(callback.Path); r != nil {
-        list := r.(*glist.List)
-        list.Remove(callback.elem)
-        // 如果存在子级callback，那么也一并递归删除
-        if callback.subs.Len() > 0 {
-            for {
-                if r := callback.subs.PopFront(); r != nil {
-                    w.removeCallback(r.(*Callback))
-                } else {
-                    break
+// 移除指定的回调，当该文件/目录的所有回调都被移除时，同时移除底层的监听
+func (w *Watcher) removeCallback(callback *Callback) (err error) {
+    // 批量添加的回调对象本身没有注册监听，只需要移除其管理的每一个路径的主callback(可能属于不同的监听对象)
+    if callback.multi {
+        errs := make([]string, 0)
+        for {
+            if r := callback.subs.PopFront(); r != nil {
+                sub := r.(*Callback)
+                if err := sub.watcher.removeCallback(sub); err != nil && !isNotWatchedError(err) {
+                    errs = append(errs, err.Error())
+                }
+            } else {
+                break
+            }
+        }
+        callbackIdMap.Remove(callback.Id)
+        if len(errs) > 0 {
+            return errors.New(strings.Join(errs, "; "))
+        }
+        return nil
+    }
+    // 如果存在子级callback，那么也一并递归删除
+    for {
+        if r := callback.subs.PopFront(); r != nil {
+            w.removeCallback(r.(*Callback))
+        } else {
+            break
+        }
+    }
+    if callback.parent == nil {
+        callbackIdMap.Remove(callback.Id)
+        if callback.group != nil {
+            callback.group.subs.Remove(callback.parentElem)
+        }
+    } else if callback.parentElem != nil {
+        callback.parent.subs.Remove(callback.parentElem)
+    }
+    w.callbacks.LockFunc(func(m map[string]interface{}) {
+        if r, ok := m[callback.Path]; ok {
+            list := r.(*glist.List)
+            list.Remove(callback.elem)
+            if list.Len() == 0 {
+                delete(m, callback.Path)
+                // 等待创建的callback、只监听符号链接本身的callback并没有添加底层监听，
+                // 被符号链接的监听引用的目录需要保留底层监听
+                switch {
+                    case callback.waiting != nil && callback.waiting.Val():
+                    case callback.linkParent != "" && callback.option.Symlink == SYMLINK_NOFOLLOW:
+                    case w.linkParents[callback.Path] > 0:
+                    default:
+                        err = w.watcher.Remove(callback.Path)
                 }
             }
+        } else {
+            err = errors.New(fmt.Sprintf(`callbacks not found for "%s"`, callback.Path))
         }
-        // 如果该文件/目录的所有回调都被删除，那么移除监听
-        if list.Len() == 0 {
-            return w.watcher.Remove(callback.Path)
+        if callback.linkParent != "" {
+            w.removeLinkParent(m, callback.linkParent)
         }
+    })
+    return
+}
+
+// 设置底层监听错误(例如inotify监听数量达到系统限制)的处理方法，
+// 处理方法在监听循环中同步执行，以保证与事件的先后顺序一致；未设置时默认使用日志对象输出错误信息。
+func (w *Watcher) SetErrorHandler(handler func(err error)) {
+    w.errorHandler.Set(handler)
+}
+
+// 设置添加目录监听时未指定是否递归(bool参数)的默认值，默认为递归监听，对已经添加的监听不生效
+func (w *Watcher) SetDefaultRecursive(recursive bool) {
+    w.recursive.Set(recursive)
+}
+
+// 处理底层监听错误
+func (w *Watcher) handleError(err error) {
+    w.stats.errors.Add(1)
+    if handler, ok := w.errorHandler.Val().(func(err error)); ok && handler != nil {
+        handler(err)
     } else {
-        return errors.New(fmt.Sprintf(`callbacks not found for "%s"`, callback.Path))
+        w.getLogger().Error(err)
     }
-    return nil
 }
 
-// 监听循环
+// 获取当前已注册监听的文件/目录数量
+func (w *Watcher) WatchCount() int {
+    return w.callbacks.Size()
+}
+
+// 获取当前已注册监听的文件/目录路径列表(快照，按照路径排序)
+func (w *Watcher) Paths() []string {
+    paths := w.callbacks.Keys()
+    sort.Strings(paths)
+    return paths
+}
+
+// 监听循环，该循环是事件队列及过滤缓存唯一的写入方，因此退出时由其关闭事件队列及过滤缓存，同时通知事件循环退出
 func (w *Watcher) startWatchLoop() {
+    w.loopWg.Add(1)
     go func() {
+        defer func() {
+            w.events.close()
+            w.loopWg.Done()
+        }()
+        // 重复事件过滤，键名为事件的字符串表示，键值为过滤的截止时间(毫秒)
+        filter := make(map[string]int64)
         for {
             select {
                 // 关闭事件
@@ -170,75 +477,236 @@ func (w *Watcher) startWatchLoop() {
                     return
 
                 // 监听事件
-                case ev := <- w.watcher.Events:
+                case ev, ok := <- w.watcher.eventChan():
+                    // 底层fsnotify对象已关闭
+                    if !ok {
+                        return
+                    }
+                    w.stats.received.Add(1)
+                    w.debugLog("watch loop:", ev.String())
                     key := ev.String()
-                    if !w.cache.Contains(key) {
-                        w.cache.Set(key, struct {}{}, REPEAT_EVENT_FILTER_INTERVAL)
-                        w.events.Push(&Event{
+                    now := w.millisecond()
+                    if expire, ok := filter[key]; !ok || expire < now {
+                        filter[key] = now + REPEAT_EVENT_FILTER_INTERVAL
+                        // 过滤记录较多时清理已过期的记录
+                        if len(filter) > 1024 {
+                            for k, v := range filter {
+                                if v < now {
+                                    delete(filter, k)
+                                }
+                            }
+                        }
+                        w.events.push(&Event{
                             event   : ev,
+                            time    : now,
                             Path    : ev.Name,
                             Op      : Op(ev.Op),
                             Watcher : w,
                         })
                     }
 
-                case err := <- w.watcher.Errors:
-                    panic("error : " + err.Error());
+                case err, ok := <- w.watcher.errorChan():
+                    if !ok {
+                        return
+                    }
+                    if isWatchLimitError(err) {
+                        err = &WatchLimitError{ Count : w.WatchCount(), Err : err }
+                    }
+                    w.handleError(err)
             }
         }
     }()
 }
 
-// 检索给定path的回调方法**列表**
-func (w *Watcher) getCallbacks(path string) *glist.List {
-    for path != "/" {
+// 检索给定path的回调方法**列表**，返回的是回调对象的快照
+func (w *Watcher) getCallbacks(path string) []interface{} {
+    return w.searchCallbacks(path, fileDir)
+}
+
+// 从给定path开始逐级向上检索回调方法列表，dir为获取上级目录的方法。
+// 上级目录与自身相同时表示已经到达根目录(Unix下为"/"，Windows下为"C:\"等卷根目录)，根目录本身同样会被检索。
+func (w *Watcher) searchCallbacks(path string, dir func(path string) string) []interface{} {
+    for {
         if l := w.callbacks.Get(path); l != nil {
-            return l.(*glist.List)
-        } else {
-            path = fileDir(path)
+            return l.(*glist.List).FrontAll()
+        }
+        parent := dir(path)
+        if parent == path || parent == "" || parent == "." {
+            return nil
         }
+        path = parent
     }
-    return nil
 }
 
 // 事件循环
 func (w *Watcher) startEventLoop() {
+    w.loopWg.Add(1)
     go func() {
+        defer w.loopWg.Done()
         for {
-            if v := w.events.Pop(); v != nil {
-                event := v.(*Event)
+            if event := w.events.pop(); event != nil {
+                // 监听对象关闭后，丢弃队列中剩余未处理的事件
+                if w.closed.Val() {
+                    continue
+                }
+                w.stats.dispatched.Add(1)
+                w.debugLog("event loop:", event.String())
+                // 需要在删除监听之前获取回调列表的快照，保证真实删除的事件也能通知到对应的回调
+                callbacks := w.getCallbacks(event.Path)
+                // 关联重命名事件：真实的重命名事件等待与随后的新建事件关联为移动事件，暂不回调
+                if w.correlateRename(event, callbacks) {
+                    continue
+                }
+                // 符号链接被重新指向时，转换为监听新的目标
+                w.refollowLink(event, callbacks)
+                // 原始的删除事件，用于配置了RawRemove的回调
+                rawEvent := event
                 // 如果是删除操作，那么需要判断是否文件真正不存在了
                 if event.IsRemove() {
                     if fileExists(event.Path) {
                         // 如果是文件删除事件，判断该文件是否存在，如果存在，那么将此事件认为“假删除”，
-                        // 并重新添加监控(底层fsnotify会自动删除掉监控，这里重新添加回去)
+                        // 并重新添加监控(底层fsnotify会自动删除掉监控，这里重新添加回去)，
+                        // 已注册的回调对象保持不变，因此会继续收到后续的事件通知
                         w.watcher.Add(event.Path)
-                        // 修改事件操作为重命名(相当于重命名为自身名称，最终名称没变)
-                        event.Op = RENAME
+                        // 修改事件操作为重命名(相当于重命名为自身名称，最终名称没变)，
+                        // 这里使用事件的副本进行修改，原始的事件仍然可以回调给配置了RawRemove的回调
+                        renamed   := *event
+                        renamed.Op = RENAME
+                        event      = &renamed
                     } else {
                         // 如果是真实删除，那么递归删除监控信息
                         w.Remove(event.Path)
                     }
                 }
-                callbacks := w.getCallbacks(event.Path)
-                // 如果创建了新的目录，那么将这个目录递归添加到监控中
-                if event.IsCreate() && fileIsDir(event.Path) {
-                    for _, v := range callbacks.FrontAll() {
+                isDir    := fileIsDir(event.Path)
+                isCreate := event.IsCreate() || event.IsMove()
+                // 如果创建了新的文件/目录，那么复用其父级目录的回调，将新的文件添加到监控中，新的目录递归添加到监控中(被排除的文件/目录除外)。
+                // 部分平台下目录的监听并不能保证新建文件的后续写入事件能够送达，因此新建的文件也需要显式添加监听。
+                // 如果该路径已经存在注册的回调，表示回调列表并非来自父级目录，那么不需要重复添加。
+                // 新建目录的递归监听添加完成后才会继续处理后续的事件，新建目录的回调对象记录在created中，
+                // 用于在新建事件回调之后补发目录中已经存在的文件/目录的新建事件。
+                created := make([]*Callback, 0)
+                if isCreate && !w.callbacks.Contains(event.Path) {
+                    for _, v := range callbacks {
                         callback := v.(*Callback)
-                        w.addWithCallback(callback, event.Path, callback.Func)
+                        // 等待创建的上级目录监听只关注目标路径，不需要递归添加
+                        if callback.waiter || !callback.option.acceptWatch(callback.rootPath(), event.Path, isDir) {
+                            continue
+                        }
+                        if isDir {
+                            if sub, err := w.addWithCallback(callback, event.Path, callback.Func, true, callback.option); err == nil {
+                                created = append(created, sub)
+                            }
+                        } else {
+                            w.addWatch(event.Path, callback.Func, callback.option, callback)
+                        }
                     }
                 }
-                // 执行回调处理，异步处理
-                if callbacks != nil {
-                    go func(callbacks *glist.List) {
-                        for _, v := range callbacks.FrontAll() {
-                            go v.(*Callback).Func(event)
+                // 如果创建的是等待创建的路径，那么转换为对该路径的直接监听(CREATE事件在随后正常回调)
+                if isCreate {
+                    for _, v := range callbacks {
+                        if callback := v.(*Callback); callback.waiting != nil && callback.Path == event.Path {
+                            w.promoteCallback(callback, false)
                         }
-                    }(callbacks)
+                    }
+                }
+                // 执行回调处理，异步处理，不满足配置项过滤规则的事件不会回调
+                for _, v := range callbacks {
+                    callback := v.(*Callback)
+                    event    := event
+                    if callback.option.RawRemove {
+                        event = rawEvent
+                    }
+                    w.handleCallback(callback, event, isDir)
+                }
+                for _, sub := range created {
+                    w.sendCreatedEvents(sub, event)
                 }
             } else {
                 break
             }
         }
     }()
-}
\ No newline at end of file
+}
+
+// 按照回调对象的配置项处理事件，不满足配置项过滤规则的事件不会回调；
+// 对文件注册的回调只处理该文件本身的事件，对目录注册的回调处理目录下所有的事件。
+func (w *Watcher) handleCallback(callback *Callback, event *Event, isDir bool) {
+    if callback.file && event.Path != callback.Path {
+        return
+    }
+    if !callback.option.acceptOp(event.Op) || !callback.option.accept(callback.rootPath(), event.Path, isDir) {
+        return
+    }
+    if callback.option.Debounce > 0 {
+        w.debounceEvent(callback, event)
+    } else {
+        w.dispatch(callback, event)
+    }
+}
+
+// 新建的目录(例如从未监听的位置移动到监听目录下，或者创建后立即写入了文件)在添加监听之前已经存在的文件/目录不会产生事件，
+// 因此对新建目录的回调对象递归添加监听的每一个子级路径补发一次新建事件(按照路径排序)。
+// 添加监听之后才创建的文件/目录可能会同时收到底层的新建事件，即同一路径可能会收到两次新建事件。
+func (w *Watcher) sendCreatedEvents(callback *Callback, event *Event) {
+    created := make([]*Callback, 0)
+    subs    := callback.subs.FrontAll()
+    for len(subs) > 0 {
+        sub    := subs[0].(*Callback)
+        subs    = append(subs[1:], sub.subs.FrontAll()...)
+        created = append(created, sub)
+    }
+    sort.Slice(created, func(i, j int) bool {
+        return created[i].Path < created[j].Path
+    })
+    for _, sub := range created {
+        w.handleCallback(sub, &Event {
+            time    : event.time,
+            Path    : sub.Path,
+            Op      : CREATE,
+            Watcher : w,
+        }, fileIsDir(sub.Path))
+    }
+}
+
+// 执行回调方法，回调方法中产生的panic会被捕获并交由错误处理方法处理，不会影响事件循环及其他回调的执行
+func (w *Watcher) callFunc(callback *Callback, event *Event) {
+    defer func() {
+        if r := recover(); r != nil {
+            w.handleError(errors.New(fmt.Sprintf(`callback panic on "%s" with op %v: %v`, event.Path, event.Op, r)))
+        }
+    }()
+    w.stats.invoked.Add(1)
+    callback.Func(event)
+}
+
+// 获取回调对象添加监听的所有路径(快照，按照路径排序)，包括递归监听时自动添加的子级路径，
+// 以及添加监听之后新建的文件/目录，通过RemoveCallback移除该回调对象时会移除这些路径上属于该回调对象的监听，
+// 其他回调对象对相同路径的监听不受影响。
+func (c *Callback) Paths() []string {
+    paths := make([]string, 0)
+    if !c.multi {
+        paths = append(paths, c.Path)
+    }
+    subs  := c.subs.FrontAll()
+    for len(subs) > 0 {
+        sub  := subs[0].(*Callback)
+        subs  = append(subs[1:], sub.subs.FrontAll()...)
+        paths = append(paths, sub.Path)
+    }
+    sort.Strings(paths)
+    return paths
+}
+
+// 获取回调对象注册监听时的根路径(即主callback的监听路径)
+func (c *Callback) rootPath() string {
+    return c.root().Path
+}
+
+// 获取回调对象所属的主callback(自动管理的子级callback与其主callback属于同一次注册)
+func (c *Callback) root() *Callback {
+    for c.parent != nil {
+        c = c.parent
+    }
+    return c
+}
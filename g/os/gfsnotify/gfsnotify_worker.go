@@ -0,0 +1,62 @@
+// Copyright 2018 gf Author(https://gitee.com/johng/gf). All Rights Reserved.
+//
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT was not distributed with this file,
+// You can obtain one at https://gitee.com/johng/gf.
+
+package gfsnotify
+
+import (
+    "gitee.com/johng/gf/g/encoding/ghash"
+)
+
+const (
+    DEFAULT_WORKER_QUEUE_SIZE = 100 // 每一个回调工作协程的待执行队列大小
+)
+
+// 启动n个回调工作协程，n <= 0时不启动(每一次回调使用单独的goroutine执行)。
+// 工作协程在监听管理对象关闭时退出，待执行队列中尚未执行的回调将被丢弃。
+func (w *Watcher) startWorkers(n int) {
+    if n <= 0 {
+        return
+    }
+    w.workers = make([]chan serialTask, n)
+    for i := 0; i < n; i++ {
+        w.workers[i] = make(chan serialTask, DEFAULT_WORKER_QUEUE_SIZE)
+        go w.runWorker(w.workers[i])
+    }
+}
+
+// 工作协程，依次执行待执行队列中的回调
+func (w *Watcher) runWorker(tasks chan serialTask) {
+    for {
+        select {
+            case <- w.closeChan:
+                return
+            case task := <- tasks:
+                w.stats.pending.Add(-1)
+                w.stats.busy.Add(1)
+                w.callFunc(task.callback, task.event)
+                w.stats.busy.Add(-1)
+        }
+    }
+}
+
+// 按照事件路径的哈希将回调分配到对应的工作协程，同一路径的回调始终由同一个工作协程执行；
+// 待执行队列满时阻塞等待，监听管理对象关闭时丢弃该回调
+func (w *Watcher) dispatchToWorker(callback *Callback, event *Event) {
+    tasks := w.workers[ghash.BKDRHash([]byte(event.Path)) % uint32(len(w.workers))]
+    task  := serialTask{callback, event}
+    w.stats.pending.Add(1)
+    select {
+        case tasks <- task:
+            return
+        default:
+    }
+    w.stats.blocked.Add(1)
+    select {
+        case tasks <- task:
+        case <- w.closeChan:
+            w.stats.pending.Add(-1)
+    }
+}
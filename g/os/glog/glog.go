@@ -10,6 +10,7 @@
 package glog
 
 import (
+    "context"
     "gitee.com/johng/gf/g/container/gtype"
     "io"
 )
@@ -24,10 +25,19 @@ const (
     LEVEL_CRIT
 )
 
+const (
+    LEVEL_NONE = 0                                    // 不记录任何分级日志(Print等不分级的方法不受影响)
+    LEVEL_DEV  = LEVEL_ALL                            // 开发环境常用的日志等级，记录所有等级的日志
+    LEVEL_PROD = LEVEL_WARN | LEVEL_ERRO | LEVEL_CRIT // 生产环境常用的日志等级，只记录WARN及以上等级的日志
+)
+
 var (
     // glog默认的日志等级，影响全局
     defaultLevel = gtype.NewInt(LEVEL_ALL)
 
+    // glog默认的日志输出格式，影响全局
+    defaultFormat = gtype.NewInt(FORMAT_TEXT)
+
     // 默认的日志对象
     logger = New()
 )
@@ -42,17 +52,87 @@ func SetFile(file string) {
     logger.SetFile(file)
 }
 
+// 设置日志文件的切分配置(按天和/或按大小切分)
+func SetRotate(config RotateConfig) {
+    logger.SetRotate(config)
+}
+
+// 设置日志的异步写入，开启后进程退出前需要调用Flush保证缓冲队列中的日志全部写入
+func SetAsync(config AsyncConfig) {
+    logger.SetAsync(config)
+}
+
+// 等待异步写入缓冲队列中的日志全部写入
+func Flush() {
+    logger.Flush()
+}
+
+// 设置日志的调用位置输出标识(FLAG_FILE|FLAG_SHORT|FLAG_LINE|FLAG_FUNC的组合)
+func SetFlags(flags int) {
+    logger.SetFlags(flags)
+}
+
+// 设置检索调用位置时向上跳过的调用层级数
+func SetCallerSkip(skip int) {
+    logger.SetCallerSkip(skip)
+}
+
 // 设置全局的日志记录等级
 func SetLevel(level int) {
     logger.SetLevel(level)
     defaultLevel.Set(level)
 }
 
+// 设置全局的最低日志记录等级，低于该等级的日志不会被记录，
+// 例如：SetMinLevel(LEVEL_WARN)表示只记录WARN、ERRO、CRIT等级的日志
+func SetMinLevel(level int) {
+    SetLevel(MinLevel(level))
+}
+
+// 根据最低日志记录等级(单个LEVEL_*等级)生成日志等级掩码，该掩码包含大于等于level的所有日志等级，可用于SetLevel
+func MinLevel(level int) int {
+    if level <= 0 {
+        return LEVEL_ALL
+    }
+    return LEVEL_ALL &^ (level - 1)
+}
+
+// 设置全局的日志输出格式(FORMAT_TEXT/FORMAT_JSON)
+func SetFormat(format int) {
+    logger.SetFormat(format)
+    defaultFormat.Set(format)
+}
+
+// 获取全局的日志输出格式
+func GetFormat() int {
+    return defaultFormat.Val()
+}
+
+// 返回一个新的日志对象，该日志对象输出的每一条日志都会带上给定的结构化字段
+func WithFields(fields map[string]interface{}) *Logger {
+    return logger.WithFields(fields)
+}
+
+// 返回一个新的日志对象，该日志对象输出的每一条日志都会带上上下文中的请求ID及链路追踪ID结构化字段，详见Logger.Ctx
+func Ctx(ctx context.Context) *Logger {
+    return logger.Ctx(ctx)
+}
+
 // 可自定义IO接口，IO可以是文件输出、标准输出、网络输出
 func SetWriter(writer io.Writer) {
     logger.SetWriter(writer)
 }
 
+// 设置多个自定义的IO接口，日志内容将同时写入到所有的IO接口
+func SetWriters(writers...io.Writer) {
+    logger.SetWriters(writers...)
+}
+
+// 添加附加的IO接口，可选参数level用于设置该IO接口输出的日志等级
+func AddWriter(writer io.Writer, level...int) {
+    logger.AddWriter(writer, level...)
+}
+
 // 返回自定义的IO，默认为nil
 func GetWriter() io.Writer {
     return logger.GetWriter()
@@ -0,0 +1,44 @@
+// Copyright 2018 gf Author(https://gitee.com/johng/gf). All Rights Reserved.
+//
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT was not distributed with this file,
+// You can obtain one at https://gitee.com/johng/gf.
+// 命名的分类日志对象.
+
+package glog
+
+import (
+    "sync"
+)
+
+const (
+    gLEVEL_INHERIT = -1 // 分类日志对象未设置日志等级时使用全局的日志记录等级
+)
+
+var (
+    // 命名的分类日志对象
+    categories   = make(map[string]*Logger)
+    categoriesMu = sync.RWMutex{}
+)
+
+// 获取命名的分类日志对象(例如：glog.Category("gfsnotify"))，同一名称返回同一个日志对象，用于各个子模块独立配置日志等级及输出。
+// 分类日志对象在第一次获取时从全局日志对象复制配置，未通过SetLevel设置日志等级时，日志等级跟随全局的日志记录等级。
+// 注意与Cat方法的区别：Cat是链式操作，用于设置日志文件的分类目录。
+func Category(name string) *Logger {
+    categoriesMu.RLock()
+    l, ok := categories[name]
+    categoriesMu.RUnlock()
+    if ok {
+        return l
+    }
+    categoriesMu.Lock()
+    defer categoriesMu.Unlock()
+    if l, ok := categories[name]; ok {
+        return l
+    }
+    l    = logger.Clone()
+    l.pr = nil
+    l.level.Set(gLEVEL_INHERIT)
+    categories[name] = l
+    return l
+}
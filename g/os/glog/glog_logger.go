@@ -12,27 +12,32 @@ import (
     "gitee.com/johng/gf/g/container/gtype"
     "gitee.com/johng/gf/g/os/gfile"
     "gitee.com/johng/gf/g/os/gmlock"
-    "gitee.com/johng/gf/g/os/gtime"
     "gitee.com/johng/gf/g/util/gregex"
     "io"
     "os"
     "runtime"
     "strings"
     "sync"
-    "time"
 )
 
 type Logger struct {
     mu           sync.RWMutex
-    pr           *Logger             // 父级Logger
-    io           io.Writer           // 日志内容写入的IO接口
-    path         *gtype.String       // 日志写入的目录路径
-    file         *gtype.String       // 日志文件名称格式
-    level        *gtype.Int          // 日志输出等级
-    btSkip       *gtype.Int          // 错误产生时的backtrace回调信息skip条数
-    btEnabled    *gtype.Bool         // 是否当打印错误时同时开启backtrace打印
-    printHeader  *gtype.Bool         // 是否不打印前缀信息(时间，级别等)
-    alsoStdPrint *gtype.Bool         // 控制台打印开关，当输出到文件/自定义输出时也同时打印到终端
+    pr           *Logger                // 父级Logger
+    io           io.Writer              // 日志内容写入的IO接口
+    path         *gtype.String          // 日志写入的目录路径
+    file         *gtype.String          // 日志文件名称格式
+    level        *gtype.Int             // 日志输出等级
+    btSkip       *gtype.Int             // 错误产生时的backtrace回调信息skip条数
+    btEnabled    *gtype.Bool            // 是否当打印错误时同时开启backtrace打印
+    printHeader  *gtype.Bool            // 是否不打印前缀信息(时间，级别等)
+    alsoStdPrint *gtype.Bool            // 控制台打印开关，当输出到文件/自定义输出时也同时打印到终端
+    rotate       RotateConfig           // 日志文件切分配置
+    format       *gtype.Int             // 日志输出格式(FORMAT_TEXT/FORMAT_JSON)
+    fields       map[string]interface{} // 每一条日志都会输出的结构化字段(WithFields)
+    async        *asyncWriter           // 异步写入对象，未开启异步写入时为nil
+    writers      []levelWriter          // 附加的IO接口(AddWriter)，写入时复制，不修改已有的切片
+    flags        *gtype.Int             // 调用位置输出标识(FLAG_*)
+    callerSkip   *gtype.Int             // 检索调用位置时向上跳过的调用层级数
 }
 
 const (
@@ -65,6 +70,9 @@ func New() *Logger {
         btEnabled    : gtype.NewBool(true),
         printHeader  : gtype.NewBool(true),
         alsoStdPrint : gtype.NewBool(true),
+        format       : gtype.NewInt(defaultFormat.Val()),
+        flags        : gtype.NewInt(),
+        callerSkip   : gtype.NewInt(),
     }
 }
 
@@ -80,6 +88,13 @@ func (l *Logger) Clone() *Logger {
         btEnabled    : l.btEnabled.Clone(),
         printHeader  : l.printHeader.Clone(),
         alsoStdPrint : l.alsoStdPrint.Clone(),
+        rotate       : l.GetRotate(),
+        format       : l.format.Clone(),
+        fields       : l.getFields(),
+        async        : l.getAsync(),
+        writers      : l.getWriters(),
+        flags        : l.flags.Clone(),
+        callerSkip   : l.callerSkip.Clone(),
     }
 }
 
@@ -88,17 +103,25 @@ func (l *Logger) SetLevel(level int) {
     l.level.Set(level)
 }
 
-// 获取日志记录等级
+// 设置最低日志记录等级，低于该等级的日志不会被记录
+func (l *Logger) SetMinLevel(level int) {
+    l.level.Set(MinLevel(level))
+}
+
+// 获取日志记录等级，分类日志对象未设置日志等级时返回全局的日志记录等级
 func (l *Logger) GetLevel() int {
-    return l.level.Val()
+    if level := l.level.Val(); level != gLEVEL_INHERIT {
+        return level
+    }
+    return logger.GetLevel()
 }
 
 // 快捷方法，打开或关闭DEBU日志信息
 func (l *Logger) SetDebug(debug bool) {
     if debug {
-        l.level.Set(l.level.Val() | LEVEL_DEBU)
+        l.level.Set(l.GetLevel() | LEVEL_DEBU)
     } else {
-        l.level.Set(l.level.Val() & ^LEVEL_DEBU)
+        l.level.Set(l.GetLevel() & ^LEVEL_DEBU)
     }
 }
 
@@ -126,14 +149,12 @@ func (l *Logger) GetWriter() io.Writer {
     return r
 }
 
-// 获取默认的文件IO
-func (l *Logger) getFilePointer() *os.File {
+// 获取默认的文件IO，size为即将写入的内容长度(用于按大小切分日志文件)
+func (l *Logger) getFilePointer(size int) *os.File {
     if path := l.path.Val(); path != "" {
-        // 文件名称中使用"{}"包含的内容使用gtime格式化
-        file, _ := gregex.ReplaceStringFunc(`{.+?}`, l.file.Val(), func(s string) string {
-            return gtime.Now().Format(strings.Trim(s, "{}"))
-        })
-        fpath   := path + gfile.Separator + file
+        rotate := l.GetRotate()
+        fpath  := l.getFilePath(path, rotate)
+        l.rotateBySize(fpath, size, rotate)
         if fp, err := gfile.OpenWithFlagPerm(fpath, gDEFAULT_FILE_POOL_FLAGS, 0666); err == nil {
             return fp
         } else {
@@ -172,32 +193,42 @@ func (l *Logger) SetStdPrint(enabled bool) {
 }
 
 // 这里的写锁保证统一时刻只会写入一行日志，防止串日志的情况
-func (l *Logger) print(std io.Writer, s string) {
-    // 优先使用自定义的IO输出
-    if l.printHeader.Val() {
-        s = l.format(s)
+// 参数level为日志等级名称(不分级的日志为空)，backtrace为调用回溯信息(为空时不输出)，
+// 日志内容按照设置的日志格式(文本/JSON)格式化后输出
+func (l *Logger) print(std io.Writer, level, s, backtrace string) {
+    caller := l.getCaller()
+    if l.GetFormat() == FORMAT_JSON {
+        s = l.formatJson(level, caller, s, backtrace)
+    } else {
+        s = l.formatText(level, caller, s, backtrace)
     }
-    writer := l.GetWriter()
-    if writer == nil {
-        // 如果设置的writer为空，那么其次判断是否有文件输出设置
-        // 内部使用了内存锁，保证在glog中对同一个日志文件的并发写入不会串日志(并发安全)
-        if f := l.getFilePointer(); f != nil {
-            defer f.Close()
-            key := l.path.Val()
-            gmlock.Lock(key)
-            _, err := io.WriteString(f, s)
-            gmlock.Unlock(key)
-            if err != nil {
-                fmt.Fprintln(os.Stderr, err.Error())
+    // 开启异步写入时，格式化后的日志内容写入缓冲队列，由后台goroutine执行写入
+    l.doWrite(func() {
+        // 优先使用自定义的IO输出
+        writer := l.GetWriter()
+        if writer == nil {
+            // 如果设置的writer为空，那么其次判断是否有文件输出设置
+            // 内部使用了内存锁，保证在glog中对同一个日志文件的并发写入不会串日志(并发安全)，
+            // 日志文件的切分同样在锁中执行，切分时不会有日志写入到被重命名的文件中
+            if path := l.path.Val(); path != "" {
+                gmlock.Lock(path)
+                if f := l.getFilePointer(len(s)); f != nil {
+                    if _, err := io.WriteString(f, s); err != nil {
+                        fmt.Fprintln(os.Stderr, err.Error())
+                    }
+                    f.Close()
+                }
+                gmlock.Unlock(path)
             }
+            // 当没有设置writer时，需要判断是否允许输出到标准输出
+            if l.alsoStdPrint.Val() {
+                l.doStdLockPrint(std, s)
+            }
+        } else {
+            l.doStdLockPrint(writer, s)
         }
-        // 当没有设置writer时，需要判断是否允许输出到标准输出
-        if l.alsoStdPrint.Val() {
-            l.doStdLockPrint(std, s)
-        }
-    } else {
-        l.doStdLockPrint(writer, s)
-    }
+        l.printWriters(level, s)
+    })
 }
 
 // 并发安全打印到标准输出
@@ -210,26 +241,19 @@ func (l *Logger) doStdLockPrint(std io.Writer, s string) {
 }
 
 // 核心打印数据方法(标准输出)
-func (l *Logger) stdPrint(s string) {
-    l.print(os.Stdout, s)
+func (l *Logger) stdPrint(level, s string) {
+    l.print(os.Stdout, level, s, "")
 }
 
 // 核心打印数据方法(标准错误)
-func (l *Logger) errPrint(s string) {
+func (l *Logger) errPrint(level, s string) {
     // 记录调用回溯信息
+    backtrace := ""
     if l.btEnabled.Val() {
-        tracestr := l.GetBacktrace()
-        if tracestr != "" {
-            backtrace := "Backtrace:" + ln + tracestr
-            if s[len(s) - 1] == byte('\n') {
-                s = s + backtrace + ln
-            } else {
-                s = s + ln + backtrace + ln
-            }
-        }
+        backtrace = l.GetBacktrace()
     }
     // 防止串日志情况，这里不使用stderr，而是使用stdout
-    l.print(os.Stdout, s)
+    l.print(os.Stdout, level, s, backtrace)
 }
 
 // 直接打印回溯信息，参数skip表示调用端往上多少级开始回溯
@@ -271,179 +295,183 @@ func (l *Logger) GetBacktrace(skip...int) string {
     return backtrace
 }
 
-func (l *Logger) format(s string) string {
-    return time.Now().Format("2006-01-02 15:04:05.000 ") + s
-}
-
 func (l *Logger) Print(v ...interface{}) {
-    l.stdPrint(fmt.Sprintln(v...))
+    l.stdPrint("", fmt.Sprintln(v...))
 }
 
 func (l *Logger) Printf(format string, v ...interface{}) {
-    l.stdPrint(fmt.Sprintf(format, v...))
+    l.stdPrint("", fmt.Sprintf(format, v...))
 }
 
 func (l *Logger) Println(v ...interface{}) {
-    l.stdPrint(fmt.Sprintln(v...))
+    l.stdPrint("", fmt.Sprintln(v...))
 }
 
 func (l *Logger) Printfln(format string, v ...interface{}) {
-    l.stdPrint(fmt.Sprintf(format + ln, v...))
+    l.stdPrint("", fmt.Sprintf(format + ln, v...))
 }
 
 func (l *Logger) Fatal(v ...interface{}) {
-    l.errPrint(fmt.Sprintln(v...))
+    l.errPrint("", fmt.Sprintln(v...))
+    l.Flush()
     os.Exit(1)
 }
 
 func (l *Logger) Fatalf(format string, v ...interface{}) {
-    l.errPrint(fmt.Sprintf(format, v...))
+    l.errPrint("", fmt.Sprintf(format, v...))
+    l.Flush()
     os.Exit(1)
 }
 
 func (l *Logger) Fatalln(v ...interface{}) {
-    l.errPrint(fmt.Sprintln(v...))
+    l.errPrint("", fmt.Sprintln(v...))
+    l.Flush()
     os.Exit(1)
 }
 
 func (l *Logger) Fatalfln(format string, v ...interface{}) {
-    l.errPrint(fmt.Sprintf(format + ln, v...))
+    l.errPrint("", fmt.Sprintf(format + ln, v...))
+    l.Flush()
     os.Exit(1)
 }
 
 func (l *Logger) Panic(v ...interface{}) {
     s := fmt.Sprintln(v...)
-    l.errPrint(s)
+    l.errPrint("", s)
+    l.Flush()
     panic(s)
 }
 
 func (l *Logger) Panicf(format string, v ...interface{}) {
     s := fmt.Sprintf(format, v...)
-    l.errPrint(s)
+    l.errPrint("", s)
+    l.Flush()
     panic(s)
 }
 
 func (l *Logger) Panicln(v ...interface{}) {
     s := fmt.Sprintln(v...)
-    l.errPrint(s)
+    l.errPrint("", s)
+    l.Flush()
     panic(s)
 }
 
 func (l *Logger) Panicfln(format string, v ...interface{}) {
     s := fmt.Sprintf(format + ln, v...)
-    l.errPrint(s)
+    l.errPrint("", s)
+    l.Flush()
     panic(s)
 }
 
 func (l *Logger) Info(v ...interface{}) {
     if l.checkLevel(LEVEL_INFO) {
-        l.stdPrint("[INFO] " + fmt.Sprintln(v...))
+        l.stdPrint("INFO", fmt.Sprintln(v...))
     }
 }
 
 func (l *Logger) Infof(format string, v ...interface{}) {
     if l.checkLevel(LEVEL_INFO) {
-        l.stdPrint("[INFO] " + fmt.Sprintf(format, v...))
+        l.stdPrint("INFO", fmt.Sprintf(format, v...))
     }
 }
 
 func (l *Logger) Infofln(format string, v ...interface{}) {
     if l.checkLevel(LEVEL_INFO) {
-        l.stdPrint("[INFO] " + fmt.Sprintf(format, v...) + ln)
+        l.stdPrint("INFO", fmt.Sprintf(format, v...) + ln)
     }
 }
 
 func (l *Logger) Debug(v ...interface{}) {
     if l.checkLevel(LEVEL_DEBU) {
-        l.stdPrint("[DEBU] " + fmt.Sprintln(v...))
+        l.stdPrint("DEBU", fmt.Sprintln(v...))
     }
 }
 
 func (l *Logger) Debugf(format string, v ...interface{}) {
     if l.checkLevel(LEVEL_DEBU) {
-        l.stdPrint("[DEBU] " + fmt.Sprintf(format, v...))
+        l.stdPrint("DEBU", fmt.Sprintf(format, v...))
     }
 }
 
 func (l *Logger) Debugfln(format string, v ...interface{}) {
     if l.checkLevel(LEVEL_DEBU) {
-        l.stdPrint("[DEBU] " + fmt.Sprintf(format, v...) + ln)
+        l.stdPrint("DEBU", fmt.Sprintf(format, v...) + ln)
     }
 }
 
 func (l *Logger) Notice(v ...interface{}) {
     if l.checkLevel(LEVEL_NOTI) {
-        l.errPrint("[NOTI] " + fmt.Sprintln(v...))
+        l.errPrint("NOTI", fmt.Sprintln(v...))
     }
 }
 
 func (l *Logger) Noticef(format string, v ...interface{}) {
     if l.checkLevel(LEVEL_NOTI) {
-        l.errPrint("[NOTI] " + fmt.Sprintf(format, v...))
+        l.errPrint("NOTI", fmt.Sprintf(format, v...))
     }
 }
 
 func (l *Logger) Noticefln(format string, v ...interface{}) {
     if l.checkLevel(LEVEL_NOTI) {
-        l.errPrint("[NOTI] " + fmt.Sprintf(format, v...) + ln)
+        l.errPrint("NOTI", fmt.Sprintf(format, v...) + ln)
     }
 }
 
 func (l *Logger) Warning(v ...interface{}) {
     if l.checkLevel(LEVEL_WARN) {
-        l.errPrint("[WARN] " + fmt.Sprintln(v...))
+        l.errPrint("WARN", fmt.Sprintln(v...))
     }
 }
 
 func (l *Logger) Warningf(format string, v ...interface{}) {
     if l.checkLevel(LEVEL_WARN) {
-        l.errPrint("[WARN] " + fmt.Sprintf(format, v...))
+        l.errPrint("WARN", fmt.Sprintf(format, v...))
     }
 }
 
 func (l *Logger) Warningfln(format string, v ...interface{}) {
     if l.checkLevel(LEVEL_WARN) {
-        l.errPrint("[WARN] " + fmt.Sprintf(format, v...) + ln)
+        l.errPrint("WARN", fmt.Sprintf(format, v...) + ln)
     }
 }
 
 func (l *Logger) Error(v ...interface{}) {
     if l.checkLevel(LEVEL_ERRO) {
-        l.errPrint("[ERRO] " + fmt.Sprintln(v...))
+        l.errPrint("ERRO", fmt.Sprintln(v...))
     }
 }
 
 func (l *Logger) Errorf(format string, v ...interface{}) {
     if l.checkLevel(LEVEL_ERRO) {
-        l.errPrint("[ERRO] " + fmt.Sprintf(format, v...))
+        l.errPrint("ERRO", fmt.Sprintf(format, v...))
     }
 }
 
 func (l *Logger) Errorfln(format string, v ...interface{}) {
     if l.checkLevel(LEVEL_ERRO) {
-        l.errPrint("[ERRO] " + fmt.Sprintf(format, v...) + ln)
+        l.errPrint("ERRO", fmt.Sprintf(format, v...) + ln)
     }
 }
 
 func (l *Logger) Critical(v ...interface{}) {
     if l.checkLevel(LEVEL_CRIT) {
-        l.errPrint("[CRIT] " + fmt.Sprintln(v...))
+        l.errPrint("CRIT", fmt.Sprintln(v...))
     }
 }
 
 func (l *Logger) Criticalf(format string, v ...interface{}) {
     if l.checkLevel(LEVEL_CRIT) {
-        l.errPrint("[CRIT] " + fmt.Sprintf(format, v...))
+        l.errPrint("CRIT", fmt.Sprintf(format, v...))
     }
 }
 
 func (l *Logger) Criticalfln(format string, v ...interface{}) {
     if l.checkLevel(LEVEL_CRIT) {
-        l.errPrint("[CRIT] " + fmt.Sprintf(format, v...) + ln)
+        l.errPrint("CRIT", fmt.Sprintf(format, v...) + ln)
     }
 }
 
 // 判断给定level是否满足
 func (l *Logger) checkLevel(level int) bool {
-    return l.level.Val() & level > 0
+    return l.GetLevel() & level > 0
 }
\ No newline at end of file
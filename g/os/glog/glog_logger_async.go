@@ -0,0 +1,151 @@
+// Copyright 2018 gf Author(https://gitee.com/johng/gf). All Rights Reserved.
+//
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT was not distributed with this file,
+// You can obtain one at https://gitee.com/johng/gf.
+// 日志异步写入.
+
+package glog
+
+import (
+    "sync"
+    "gitee.com/johng/gf/g/container/gtype"
+)
+
+const (
+    gDEFAULT_ASYNC_SIZE = 10000 // 异步写入默认的缓冲队列大小
+)
+
+// 日志异步写入配置
+type AsyncConfig struct {
+    Enabled bool // 是否开启异步写入
+    Size    int  // 缓冲队列大小(日志条数)，0表示使用默认大小(10000)
+    Drop    bool // 缓冲队列已满时是否丢弃新的日志，默认阻塞等待直到队列有空闲位置
+}
+
+// 异步写入对象，日志在调用端格式化完成后(包括时间及调用回溯信息)写入缓冲队列，由后台goroutine按顺序写入
+type asyncWriter struct {
+    mu      sync.RWMutex  // 写入缓冲队列时加读锁，关闭缓冲队列时加写锁
+    queue   chan func()   // 缓冲队列
+    done    chan struct{} // 后台写入循环退出时关闭
+    closed  bool          // 缓冲队列是否已关闭
+    drop    bool          // 队列已满时是否丢弃
+    dropped *gtype.Int64  // 丢弃的日志条数
+}
+
+// 设置日志的异步写入。
+// 开启异步写入后，日志写入不再阻塞调用端(缓冲队列已满且为阻塞策略时除外)，适用于对延迟敏感的场景，
+// 但是进程异常退出(例如被kill)时缓冲队列中尚未写入的日志将会丢失，因此正常退出前需要调用Flush保证日志全部写入；
+// Fatal/Panic方法在退出前会自动调用Flush。重复设置时会先将原有缓冲队列中的日志全部写入，再关闭原有的缓冲队列及其后台goroutine。
+func (l *Logger) SetAsync(config AsyncConfig) {
+    var writer *asyncWriter
+    if config.Enabled {
+        size := config.Size
+        if size <= 0 {
+            size = gDEFAULT_ASYNC_SIZE
+        }
+        writer = &asyncWriter {
+            queue   : make(chan func(), size),
+            done    : make(chan struct{}),
+            drop    : config.Drop,
+            dropped : gtype.NewInt64(),
+        }
+        go writer.loop()
+    }
+    l.mu.Lock()
+    old    := l.async
+    l.async = writer
+    l.mu.Unlock()
+    // 替换之后新的写入不会再进入原有的缓冲队列，等待其中的日志全部写入后关闭；
+    // 替换之前获取到原有写入对象的并发写入(以及共享该写入对象的Clone对象)在关闭之后直接同步写入
+    if old != nil {
+        old.close()
+    }
+}
+
+// 等待缓冲队列中的日志全部写入，未开启异步写入时直接返回
+func (l *Logger) Flush() {
+    if writer := l.getAsync(); writer != nil {
+        writer.flush()
+    }
+}
+
+// 获取异步写入时由于缓冲队列已满而丢弃的日志条数
+func (l *Logger) GetAsyncDropped() int64 {
+    if writer := l.getAsync(); writer != nil {
+        return writer.dropped.Val()
+    }
+    return 0
+}
+
+// 获取异步写入对象，未开启时返回nil
+func (l *Logger) getAsync() *asyncWriter {
+    l.mu.RLock()
+    r := l.async
+    l.mu.RUnlock()
+    return r
+}
+
+// 执行写入操作，开启异步写入时写入缓冲队列，否则直接执行
+func (l *Logger) doWrite(write func()) {
+    if writer := l.getAsync(); writer != nil {
+        writer.push(write)
+    } else {
+        write()
+    }
+}
+
+// 后台写入循环，缓冲队列关闭并且其中的日志全部写入后退出
+func (w *asyncWriter) loop() {
+    for write := range w.queue {
+        write()
+    }
+    close(w.done)
+}
+
+// 写入缓冲队列，缓冲队列已关闭时直接同步写入
+func (w *asyncWriter) push(write func()) {
+    w.mu.RLock()
+    defer w.mu.RUnlock()
+    if w.closed {
+        write()
+        return
+    }
+    if w.drop {
+        select {
+            case w.queue <- write:
+            default:
+                w.dropped.Add(1)
+        }
+    } else {
+        w.queue <- write
+    }
+}
+
+// 写入一个标记到缓冲队列(阻塞写入)，并等待该标记之前的日志全部写入；缓冲队列已关闭时其中的日志已全部写入，直接返回
+func (w *asyncWriter) flush() {
+    w.mu.RLock()
+    if w.closed {
+        w.mu.RUnlock()
+        return
+    }
+    done := make(chan struct{})
+    w.queue <- func() {
+        close(done)
+    }
+    w.mu.RUnlock()
+    <-done
+}
+
+// 关闭缓冲队列，等待其中的日志全部写入并且后台写入循环退出；关闭时等待正在进行的写入完成，重复关闭时直接返回
+func (w *asyncWriter) close() {
+    w.mu.Lock()
+    if w.closed {
+        w.mu.Unlock()
+        return
+    }
+    w.closed = true
+    close(w.queue)
+    w.mu.Unlock()
+    <-w.done
+}
@@ -0,0 +1,103 @@
+// Copyright 2018 gf Author(https://gitee.com/johng/gf). All Rights Reserved.
+//
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT was not distributed with this file,
+// You can obtain one at https://gitee.com/johng/gf.
+// 日志调用位置信息.
+
+package glog
+
+import (
+    "runtime"
+    "strconv"
+    "strings"
+    "gitee.com/johng/gf/g/util/gregex"
+)
+
+const (
+    FLAG_FILE  = 1 << iota // 输出调用端的文件绝对路径
+    FLAG_SHORT             // 输出调用端的文件名称(不包含目录，优先于FLAG_FILE)
+    FLAG_LINE              // 输出调用端的行号(需要同时设置FLAG_FILE或者FLAG_SHORT)
+    FLAG_FUNC              // 输出调用端的方法名称
+)
+
+const (
+    gMAX_CALLER_DEPTH = 32 // 检索调用端时最大的调用栈深度
+)
+
+// 设置日志的调用位置输出标识(FLAG_FILE|FLAG_SHORT|FLAG_LINE|FLAG_FUNC的组合)，默认为0表示不输出调用位置，
+// 例如：SetFlags(FLAG_SHORT|FLAG_LINE)将在每条日志中输出"file.go:10"
+func (l *Logger) SetFlags(flags int) {
+    l.flags.Set(flags)
+}
+
+// 获取日志的调用位置输出标识
+func (l *Logger) GetFlags() int {
+    return l.flags.Val()
+}
+
+// 设置检索调用位置时向上跳过的调用层级数，用于在业务封装的日志方法中输出真实的调用位置，
+// 例如：封装方法func log(v...interface{}) { glog.Info(v...) }需要设置为1
+func (l *Logger) SetCallerSkip(skip int) {
+    l.callerSkip.Set(skip)
+}
+
+// 获取调用位置信息，未设置输出标识时返回空字符串(不执行调用栈检索)。
+// 调用端为调用栈中第一个非glog包的源码文件(glog包的测试文件同样作为调用端)，再按照设置的callerSkip向上跳过指定层级。
+func (l *Logger) getCaller() string {
+    flags := l.flags.Val()
+    if flags == 0 {
+        return ""
+    }
+    pcs    := make([]uintptr, gMAX_CALLER_DEPTH)
+    frames := runtime.CallersFrames(pcs[0 : runtime.Callers(2, pcs)])
+    skip   := l.callerSkip.Val()
+    found  := false
+    for {
+        frame, more := frames.Next()
+        if !found && !isGlogFile(frame.File) {
+            found = true
+        }
+        if found {
+            if skip == 0 {
+                return formatCaller(flags, frame)
+            }
+            skip--
+        }
+        if !more {
+            break
+        }
+    }
+    return ""
+}
+
+// 判断是否为glog包的源码文件(不包括测试文件)，调用栈中的这些文件不作为调用端
+func isGlogFile(file string) bool {
+    return gregex.IsMatchString(`/g/os/glog/glog[^/]*\.go$`, file) && !strings.HasSuffix(file, "_test.go")
+}
+
+// 按照输出标识格式化调用位置，例如：/path/to/file.go:10 main.main
+func formatCaller(flags int, frame runtime.Frame) string {
+    caller := ""
+    if flags & (FLAG_FILE | FLAG_SHORT) > 0 {
+        caller = frame.File
+        if flags & FLAG_SHORT > 0 {
+            for i := len(caller) - 1; i >= 0; i-- {
+                if caller[i] == '/' || caller[i] == '\\' {
+                    caller = caller[i + 1:]
+                    break
+                }
+            }
+        }
+        if flags & FLAG_LINE > 0 {
+            caller += ":" + strconv.Itoa(frame.Line)
+        }
+    }
+    if flags & FLAG_FUNC > 0 {
+        if caller != "" {
+            caller += " "
+        }
+        caller += frame.Function
+    }
+    return caller
+}
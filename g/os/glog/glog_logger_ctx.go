@@ -0,0 +1,62 @@
+// Copyright 2018 gf Author(https://gitee.com/johng/gf). All Rights Reserved.
+//
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT was not distributed with this file,
+// You can obtain one at https://gitee.com/johng/gf.
+// 上下文日志(请求ID/链路追踪ID).
+
+package glog
+
+import (
+    "context"
+)
+
+// 上下文变量键名类型，避免与其他包的上下文键名冲突
+type CtxKey string
+
+const (
+    CTX_KEY_REQUEST_ID CtxKey = "request_id" // 上下文中的请求ID键名，对应日志的request_id字段
+    CTX_KEY_TRACE_ID   CtxKey = "trace_id"   // 上下文中的链路追踪ID键名，对应日志的trace_id字段
+)
+
+// 从上下文中提取的日志字段，字段名称与键名一致
+var ctxKeys = []CtxKey{ CTX_KEY_REQUEST_ID, CTX_KEY_TRACE_ID }
+
+// 返回一个新的日志对象，该日志对象输出的每一条日志都会带上上下文中的请求ID(request_id)及链路追踪ID(trace_id)结构化字段，
+// 上下文的键名可以是CTX_KEY_REQUEST_ID/CTX_KEY_TRACE_ID，也可以是字符串"request_id"/"trace_id"(便于与其他框架设置的上下文兼容)。
+// ctx为nil，或者上下文中不存在对应的值(或者值为空字符串)时不输出对应的字段，例如：glog.Ctx(r.Context()).Info("user login")
+func (l *Logger) Ctx(ctx context.Context) *Logger {
+    return l.WithFields(ctxFields(ctx))
+}
+
+// 在上下文中设置请求ID，通过Ctx获取的日志对象会输出request_id字段
+func WithRequestId(ctx context.Context, id string) context.Context {
+    return context.WithValue(ctx, CTX_KEY_REQUEST_ID, id)
+}
+
+// 在上下文中设置链路追踪ID，通过Ctx获取的日志对象会输出trace_id字段
+func WithTraceId(ctx context.Context, id string) context.Context {
+    return context.WithValue(ctx, CTX_KEY_TRACE_ID, id)
+}
+
+// 获取上下文中的日志字段
+func ctxFields(ctx context.Context) map[string]interface{} {
+    fields := make(map[string]interface{})
+    if ctx == nil {
+        return fields
+    }
+    for _, key := range ctxKeys {
+        value := ctx.Value(key)
+        if value == nil {
+            value = ctx.Value(string(key))
+        }
+        if value == nil {
+            continue
+        }
+        if s, ok := value.(string); ok && s == "" {
+            continue
+        }
+        fields[string(key)] = value
+    }
+    return fields
+}
@@ -0,0 +1,128 @@
+// Copyright 2018 gf Author(https://gitee.com/johng/gf). All Rights Reserved.
+//
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT was not distributed with this file,
+// You can obtain one at https://gitee.com/johng/gf.
+// 日志输出格式及结构化字段.
+
+package glog
+
+import (
+    "encoding/json"
+    "fmt"
+    "sort"
+    "strings"
+    "time"
+)
+
+const (
+    FORMAT_TEXT = iota // 文本格式(默认)，例如：2018-01-02 15:04:05.000 [INFO] message
+    FORMAT_JSON        // JSON格式，每一条日志为一个JSON对象，包含time、level、msg及结构化字段
+)
+
+const (
+    gTIME_FORMAT = "2006-01-02 15:04:05.000"
+)
+
+// 设置日志的输出格式(FORMAT_TEXT/FORMAT_JSON)
+func (l *Logger) SetFormat(format int) {
+    l.format.Set(format)
+}
+
+// 获取日志的输出格式
+func (l *Logger) GetFormat() int {
+    return l.format.Val()
+}
+
+// 返回一个新的日志对象，该日志对象输出的每一条日志都会带上给定的结构化字段(以及当前日志对象已有的字段)，
+// 与链式操作不同，WithFields总是返回新的日志对象，不会修改当前日志对象的字段
+func (l *Logger) WithFields(fields map[string]interface{}) *Logger {
+    logger := l.Clone()
+    logger.mu.Lock()
+    if logger.fields == nil {
+        logger.fields = make(map[string]interface{}, len(fields))
+    }
+    for k, v := range fields {
+        logger.fields[k] = v
+    }
+    logger.mu.Unlock()
+    return logger
+}
+
+// 获取结构化字段的拷贝
+func (l *Logger) getFields() map[string]interface{} {
+    l.mu.RLock()
+    defer l.mu.RUnlock()
+    if l.fields == nil {
+        return nil
+    }
+    m := make(map[string]interface{}, len(l.fields))
+    for k, v := range l.fields {
+        m[k] = v
+    }
+    return m
+}
+
+// 按照文本格式格式化日志内容，结构化字段按照键名排序以key=value的形式输出在日志内容之后
+func (l *Logger) formatText(level, caller, s, backtrace string) string {
+    if caller != "" {
+        s = caller + ": " + s
+    }
+    if level != "" {
+        s = "[" + level + "] " + s
+    }
+    if fields := l.getFields(); len(fields) > 0 {
+        keys := make([]string, 0, len(fields))
+        for k, _ := range fields {
+            keys = append(keys, k)
+        }
+        sort.Strings(keys)
+        buffer := strings.TrimRight(s, "\r\n")
+        for _, k := range keys {
+            buffer += fmt.Sprintf(" %s=%v", k, fields[k])
+        }
+        s = buffer + ln
+    }
+    if backtrace != "" {
+        backtrace = "Backtrace:" + ln + backtrace
+        if len(s) > 0 && s[len(s) - 1] == byte('\n') {
+            s = s + backtrace + ln
+        } else {
+            s = s + ln + backtrace + ln
+        }
+    }
+    if l.printHeader.Val() {
+        s = time.Now().Format(gTIME_FORMAT + " ") + s
+    }
+    return s
+}
+
+// 按照JSON格式格式化日志内容，每一条日志为一行JSON对象，结构化字段不会覆盖time、level、caller、msg、backtrace等内置字段
+func (l *Logger) formatJson(level, caller, s, backtrace string) string {
+    data := l.getFields()
+    if data == nil {
+        data = make(map[string]interface{})
+    }
+    if l.printHeader.Val() {
+        data["time"] = time.Now().Format(gTIME_FORMAT)
+    }
+    if level != "" {
+        data["level"] = level
+    }
+    if caller != "" {
+        data["caller"] = caller
+    }
+    data["msg"] = strings.TrimRight(s, "\r\n")
+    if backtrace != "" {
+        data["backtrace"] = strings.Split(strings.TrimRight(backtrace, "\r\n"), ln)
+    }
+    content, err := json.Marshal(data)
+    if err != nil {
+        // 结构化字段无法转换为JSON时(例如包含chan类型)，使用字符串形式输出字段的值
+        for k, v := range data {
+            data[k] = fmt.Sprintf("%v", v)
+        }
+        content, _ = json.Marshal(data)
+    }
+    return string(content) + ln
+}
@@ -0,0 +1,106 @@
+// Copyright 2018 gf Author(https://gitee.com/johng/gf). All Rights Reserved.
+//
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT was not distributed with this file,
+// You can obtain one at https://gitee.com/johng/gf.
+// 日志文件切分.
+
+package glog
+
+import (
+    "fmt"
+    "os"
+    "sort"
+    "strconv"
+    "strings"
+    "gitee.com/johng/gf/g/os/gfile"
+    "gitee.com/johng/gf/g/os/gtime"
+    "gitee.com/johng/gf/g/util/gregex"
+)
+
+// 日志文件切分配置，零值表示不切分
+type RotateConfig struct {
+    Daily   bool  // 是否按天切分，开启后日志文件名称自动加上日期，例如：app.log将写入到app-2024-01-02.log
+    Size    int64 // 按照文件大小切分(字节)，写入的日志将导致文件大小超过Size时，将当前文件重命名为备份文件后写入新的文件，0表示不按大小切分
+    Backups int   // 按大小切分时保留的备份文件数量，超过数量时删除最早的备份文件，0表示不限制
+}
+
+// 设置日志文件的切分配置
+func (l *Logger) SetRotate(config RotateConfig) {
+    l.mu.Lock()
+    l.rotate = config
+    l.mu.Unlock()
+}
+
+// 获取日志文件的切分配置
+func (l *Logger) GetRotate() RotateConfig {
+    l.mu.RLock()
+    r := l.rotate
+    l.mu.RUnlock()
+    return r
+}
+
+// 获取当前写入的日志文件绝对路径，文件名称中使用"{}"包含的内容使用gtime格式化
+func (l *Logger) getFilePath(path string, rotate RotateConfig) string {
+    file, _ := gregex.ReplaceStringFunc(`{.+?}`, l.file.Val(), func(s string) string {
+        return gtime.Now().Format(strings.Trim(s, "{}"))
+    })
+    if rotate.Daily {
+        ext  := gfile.Ext(file)
+        file  = fmt.Sprintf("%s-%s%s", file[0 : len(file) - len(ext)], gtime.Now().Format("Y-m-d"), ext)
+    }
+    return path + gfile.Separator + file
+}
+
+// 按照文件大小切分日志文件，size为即将写入的内容长度。
+// 需要在日志文件的互斥锁中执行，保证切分时其他写入方不会写入到被重命名的文件中(同一进程内)。
+func (l *Logger) rotateBySize(fpath string, size int, rotate RotateConfig) {
+    if rotate.Size <= 0 {
+        return
+    }
+    info, err := os.Stat(fpath)
+    if err != nil || info.Size() == 0 || info.Size() + int64(size) <= rotate.Size {
+        return
+    }
+    backup := backupPath(fpath)
+    if err := os.Rename(fpath, backup); err != nil {
+        fmt.Fprintln(os.Stderr, fmt.Sprintf(`glog rotate "%s" failed: %s`, fpath, err.Error()))
+        return
+    }
+    if rotate.Backups > 0 {
+        l.removeBackups(fpath, rotate.Backups)
+    }
+}
+
+// 生成备份文件路径，备份文件名称为：原文件名称.日期时间.毫秒，例如：app.log.20240102150405.000；
+// 同一毫秒内多次切分时加上递增的序号，例如：app.log.20240102150405.000.001。
+// 序号总是大于同一毫秒内已有备份文件的序号(而不是使用第一个不存在的名称)，
+// 保证按照名称排序与切分的先后顺序一致，删除备份文件后不会重新使用排在前面的名称。
+func backupPath(fpath string) string {
+    backup := fpath + "." + gtime.Now().Format("YmdHis.u")
+    files, _ := gfile.Glob(backup + "*")
+    if len(files) == 0 {
+        return backup
+    }
+    index := 0
+    for _, file := range files {
+        if n, err := strconv.Atoi(strings.TrimPrefix(file, backup + ".")); err == nil && n > index {
+            index = n
+        }
+    }
+    return fmt.Sprintf("%s.%03d", backup, index + 1)
+}
+
+// 删除超出保留数量的备份文件(按照文件名称中的时间排序，删除最早的备份文件)
+func (l *Logger) removeBackups(fpath string, backups int) {
+    files, err := gfile.Glob(fpath + ".*")
+    if err != nil || len(files) <= backups {
+        return
+    }
+    sort.Strings(files)
+    for _, file := range files[0 : len(files) - backups] {
+        if err := os.Remove(file); err != nil {
+            fmt.Fprintln(os.Stderr, err.Error())
+        }
+    }
+}
@@ -0,0 +1,93 @@
+// Copyright 2018 gf Author(https://gitee.com/johng/gf). All Rights Reserved.
+//
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT was not distributed with this file,
+// You can obtain one at https://gitee.com/johng/gf.
+// 多路日志输出.
+
+package glog
+
+import (
+    "io"
+)
+
+// 日志等级名称与日志等级的映射
+var levelValues = map[string]int {
+    "DEBU" : LEVEL_DEBU,
+    "INFO" : LEVEL_INFO,
+    "NOTI" : LEVEL_NOTI,
+    "WARN" : LEVEL_WARN,
+    "ERRO" : LEVEL_ERRO,
+    "CRIT" : LEVEL_CRIT,
+}
+
+// 带日志等级过滤的附加输出
+type levelWriter struct {
+    writer io.Writer // 输出的IO接口
+    level  int       // 输出的日志等级(LEVEL_*掩码)，0表示输出所有的日志(包括Print等不分级的日志)
+}
+
+// 同时写入到多个IO接口，某个IO接口写入失败时不影响其他IO接口的写入
+type multiWriter []io.Writer
+
+func (m multiWriter) Write(p []byte) (n int, err error) {
+    for _, w := range m {
+        if _, e := w.Write(p); e != nil && err == nil {
+            err = e
+        }
+    }
+    return len(p), err
+}
+
+// 设置多个自定义的IO接口，日志内容将同时写入到所有的IO接口(替代SetWriter设置的IO接口)，
+// 例如：SetWriters(os.Stdout, file)同时输出到标准输出及文件
+func (l *Logger) SetWriters(writers...io.Writer) {
+    if len(writers) == 1 {
+        l.SetWriter(writers[0])
+    } else if len(writers) > 1 {
+        l.SetWriter(multiWriter(writers))
+    } else {
+        l.SetWriter(nil)
+    }
+}
+
+// 添加附加的IO接口，日志内容在写入到默认输出(文件/标准输出或者SetWriter设置的IO接口)的同时也写入到该IO接口，
+// 可选参数level用于设置该IO接口输出的日志等级，例如：AddWriter(errFile, LEVEL_ERRO|LEVEL_CRIT)只输出错误日志，
+// 不设置日志等级时输出所有的日志(包括Print等不分级的日志)。
+// 附加IO接口写入失败时错误信息输出到标准错误，不影响其他IO接口的写入
+func (l *Logger) AddWriter(writer io.Writer, level...int) {
+    item := levelWriter{writer : writer}
+    if len(level) > 0 {
+        item.level = level[0]
+    }
+    l.mu.Lock()
+    writers := make([]levelWriter, len(l.writers), len(l.writers) + 1)
+    copy(writers, l.writers)
+    l.writers = append(writers, item)
+    l.mu.Unlock()
+}
+
+// 清除所有附加的IO接口
+func (l *Logger) ClearWriters() {
+    l.mu.Lock()
+    l.writers = nil
+    l.mu.Unlock()
+}
+
+// 获取附加的IO接口列表(只读)
+func (l *Logger) getWriters() []levelWriter {
+    l.mu.RLock()
+    r := l.writers
+    l.mu.RUnlock()
+    return r
+}
+
+// 写入到附加的IO接口，level为日志等级名称(不分级的日志为空)
+func (l *Logger) printWriters(level, s string) {
+    for _, item := range l.getWriters() {
+        if item.level != 0 && item.level & levelValues[level] == 0 {
+            continue
+        }
+        l.doStdLockPrint(item.writer, s)
+    }
+}
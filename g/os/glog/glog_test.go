@@ -0,0 +1,409 @@
+// Copyright 2018 gf Author(https://gitee.com/johng/gf). All Rights Reserved.
+//
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT was not distributed with this file,
+// You can obtain one at https://gitee.com/johng/gf.
+
+// go test *.go -v
+
+package glog
+
+import (
+    "bytes"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "reflect"
+    "runtime"
+    "sort"
+    "strings"
+    "sync"
+    "testing"
+    "time"
+    "gitee.com/johng/gf/g/os/gfile"
+    "gitee.com/johng/gf/g/os/gtime"
+)
+
+// 并发安全的内存输出，用于检查写入的日志内容
+type testBuffer struct {
+    mu  sync.Mutex
+    buf bytes.Buffer
+}
+
+func (b *testBuffer) Write(p []byte) (int, error) {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+    return b.buf.Write(p)
+}
+
+func (b *testBuffer) String() string {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+    return b.buf.String()
+}
+
+// 按行返回写入的日志内容
+func (b *testBuffer) Lines() []string {
+    s := strings.TrimSpace(b.String())
+    if s == "" {
+        return nil
+    }
+    return strings.Split(s, "\n")
+}
+
+// 新建写入到内存输出的日志对象，不输出日志头信息，便于检查日志内容
+func testLogger() (*Logger, *testBuffer) {
+    buffer := &testBuffer{}
+    l      := New()
+    l.SetWriter(buffer)
+    l.printHeader.Set(false)
+    return l, buffer
+}
+
+// 异步写入时Flush返回后缓冲队列中的日志已按顺序全部写入
+func TestLogger_AsyncFlush(t *testing.T) {
+    l, buffer := testLogger()
+    l.SetAsync(AsyncConfig{ Enabled : true, Size : 10 })
+    defer l.SetAsync(AsyncConfig{})
+    for i := 0; i < 100; i++ {
+        l.Printf("line %d\n", i)
+    }
+    l.Flush()
+    lines := buffer.Lines()
+    if len(lines) != 100 {
+        t.Fatalf("unexpected line count %d, expected 100", len(lines))
+    }
+    for i, line := range lines {
+        if !strings.HasSuffix(line, fmt.Sprintf("line %d", i)) {
+            t.Fatalf(`unexpected line %d: "%s"`, i, line)
+        }
+    }
+}
+
+// 缓冲队列已满并且为丢弃策略时丢弃新的日志并计数
+func TestLogger_AsyncDrop(t *testing.T) {
+    l, buffer := testLogger()
+    block     := make(chan struct{})
+    l.SetAsync(AsyncConfig{ Enabled : true, Size : 2, Drop : true })
+    defer l.SetAsync(AsyncConfig{})
+    // 阻塞后台写入循环，之后的日志只能进入缓冲队列
+    l.doWrite(func() {
+        <-block
+    })
+    time.Sleep(10*time.Millisecond)
+    for i := 0; i < 5; i++ {
+        l.Printf("line %d\n", i)
+    }
+    if dropped := l.GetAsyncDropped(); dropped != 3 {
+        t.Errorf("unexpected dropped count %d, expected 3", dropped)
+    }
+    close(block)
+    l.Flush()
+    if lines := buffer.Lines(); len(lines) != 2 {
+        t.Errorf("unexpected lines %v, expected 2 lines", lines)
+    }
+}
+
+// 重复设置异步写入时关闭原有的缓冲队列及其后台goroutine，且不会丢失并发写入的日志
+func TestLogger_AsyncReplace(t *testing.T) {
+    l, buffer := testLogger()
+    l.SetAsync(AsyncConfig{ Enabled : true })
+    old   := l.getAsync()
+    clone := l.Clone()
+    base  := runtime.NumGoroutine()
+    for i := 0; i < 10; i++ {
+        l.SetAsync(AsyncConfig{ Enabled : true })
+    }
+    select {
+        case <-old.done:
+        default:
+            t.Fatal("the replaced async writer was not closed")
+    }
+    if n := runtime.NumGoroutine(); n > base {
+        t.Errorf("goroutines leaked after replacing the async writer: %d > %d", n, base)
+    }
+    // 共享原有写入对象的Clone对象在原有缓冲队列关闭之后直接同步写入
+    clone.Print("clone")
+    if lines := buffer.Lines(); len(lines) != 1 || lines[0] != "clone" {
+        t.Errorf("unexpected lines %v after writing to the closed async writer", lines)
+    }
+
+    // 并发写入的同时重复设置异步写入
+    wg := sync.WaitGroup{}
+    for i := 0; i < 4; i++ {
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            for j := 0; j < 250; j++ {
+                l.Print("concurrent")
+            }
+        }()
+    }
+    for i := 0; i < 20; i++ {
+        l.SetAsync(AsyncConfig{ Enabled : i % 2 == 0, Size : 16 })
+    }
+    wg.Wait()
+    l.SetAsync(AsyncConfig{})
+    if lines := buffer.Lines(); len(lines) != 1001 {
+        t.Errorf("unexpected line count %d, expected 1001", len(lines))
+    }
+}
+
+// SetMinLevel及日志等级预设：低于最低等级的日志不记录，LEVEL_NONE不影响Print等不分级的日志
+func TestLogger_MinLevel(t *testing.T) {
+    for _, c := range []struct {
+        level  int
+        expect string
+    } {
+        { MinLevel(LEVEL_DEBU), "print,DEBU,INFO,NOTI,WARN,ERRO,CRIT" },
+        { MinLevel(LEVEL_WARN), "print,WARN,ERRO,CRIT" },
+        { MinLevel(LEVEL_CRIT), "print,CRIT" },
+        { LEVEL_DEV,            "print,DEBU,INFO,NOTI,WARN,ERRO,CRIT" },
+        { LEVEL_PROD,           "print,WARN,ERRO,CRIT" },
+        { LEVEL_NONE,           "print" },
+    } {
+        l, buffer := testLogger()
+        l.SetBacktrace(false)
+        l.SetLevel(c.level)
+        l.Print("print")
+        l.Debug("DEBU")
+        l.Info("INFO")
+        l.Notice("NOTI")
+        l.Warning("WARN")
+        l.Error("ERRO")
+        l.Critical("CRIT")
+        names := make([]string, 0)
+        for _, line := range buffer.Lines() {
+            names = append(names, line[strings.LastIndex(line, " ") + 1:])
+        }
+        if s := strings.Join(names, ","); s != c.expect {
+            t.Errorf(`level %d: unexpected logs "%s", expected "%s"`, c.level, s, c.expect)
+        }
+    }
+    l := New()
+    l.SetMinLevel(LEVEL_ERRO)
+    if level := l.GetLevel(); level != LEVEL_ERRO | LEVEL_CRIT {
+        t.Errorf("unexpected level %d after SetMinLevel(LEVEL_ERRO)", level)
+    }
+}
+
+// 新建写入到临时目录中日志文件的日志对象，不输出日志头信息及标准输出
+func testFileLogger(t *testing.T, file string) (*Logger, string) {
+    dir := t.TempDir()
+    l   := New()
+    if err := l.SetPath(dir); err != nil {
+        t.Fatal(err)
+    }
+    l.SetFile(file)
+    l.SetStdPrint(false)
+    l.printHeader.Set(false)
+    return l, dir
+}
+
+// 按照切分的先后顺序(备份文件在前，当前日志文件在最后)读取日志文件的所有行
+func testRotatedLines(t *testing.T, fpath string) (lines []string, backups int) {
+    files, err := gfile.Glob(fpath + ".*")
+    if err != nil {
+        t.Fatal(err)
+    }
+    sort.Strings(files)
+    for _, file := range append(files, fpath) {
+        lines = append(lines, strings.Split(strings.TrimSpace(gfile.GetContents(file)), "\n")...)
+    }
+    return lines, len(files)
+}
+
+// 按大小切分时同一毫秒内发生多次切分，删除的总是最早的备份文件，保留的日志是连续的最新的日志
+func TestLogger_RotateBySize(t *testing.T) {
+    l, dir := testFileLogger(t, "app.log")
+    l.SetRotate(RotateConfig{ Size : 30, Backups : 2 })
+    // 每条日志8字节，每个文件最多写入3条日志
+    for i := 0; i < 30; i++ {
+        l.Printf("line %02d\n", i)
+    }
+    lines, backups := testRotatedLines(t, dir + gfile.Separator + "app.log")
+    if backups != 2 {
+        t.Errorf("unexpected backup count %d, expected 2", backups)
+    }
+    expect := make([]string, 0)
+    for i := 30 - len(lines); i < 30; i++ {
+        expect = append(expect, fmt.Sprintf("line %02d", i))
+    }
+    if strings.Join(lines, ",") != strings.Join(expect, ",") || len(lines) < 7 {
+        t.Errorf("unexpected lines kept after rotation: %v", lines)
+    }
+
+    // 不限制备份文件数量时不会丢失日志
+    l, dir = testFileLogger(t, "app.log")
+    l.SetRotate(RotateConfig{ Size : 30 })
+    for i := 0; i < 30; i++ {
+        l.Printf("line %02d\n", i)
+    }
+    lines, backups = testRotatedLines(t, dir + gfile.Separator + "app.log")
+    if len(lines) != 30 || lines[0] != "line 00" || lines[29] != "line 29" || backups != 9 {
+        t.Errorf("unexpected lines %v in %d backups", lines, backups)
+    }
+}
+
+// 按天切分时日志文件名称加上当天的日期
+func TestLogger_RotateDaily(t *testing.T) {
+    l, dir := testFileLogger(t, "app.log")
+    l.SetRotate(RotateConfig{ Daily : true })
+    l.Print("daily")
+    fpath := dir + gfile.Separator + "app-" + gtime.Now().Format("Y-m-d") + ".log"
+    if content := gfile.GetContents(fpath); content != "daily\n" {
+        t.Errorf(`unexpected content "%s" of "%s"`, content, fpath)
+    }
+}
+
+
+// JSON格式输出内置字段及结构化字段，结构化字段不会覆盖内置字段；WithFields不修改原有的日志对象
+func TestLogger_JsonWithFields(t *testing.T) {
+    l, buffer := testLogger()
+    l.SetFormat(FORMAT_JSON)
+    l.SetBacktrace(false)
+    fields := l.WithFields(map[string]interface{}{ "user" : "john", "msg" : "field" })
+    fields.WithFields(map[string]interface{}{ "id" : 10 }).Info("hello")
+    fields.Print("print")
+    l.Print("origin")
+    lines := buffer.Lines()
+    if len(lines) != 3 {
+        t.Fatalf("unexpected lines %v", lines)
+    }
+    for i, expect := range []map[string]interface{} {
+        { "level" : "INFO", "msg" : "hello", "user" : "john", "id" : float64(10) },
+        { "msg" : "print", "user" : "john" },
+        { "msg" : "origin" },
+    } {
+        data := make(map[string]interface{})
+        if err := json.Unmarshal([]byte(lines[i]), &data); err != nil {
+            t.Fatalf(`invalid JSON line "%s": %v`, lines[i], err)
+        }
+        if !reflect.DeepEqual(data, expect) {
+            t.Errorf("unexpected JSON line %v, expected %v", data, expect)
+        }
+    }
+
+    // 无法转换为JSON的字段值使用字符串形式输出
+    l.WithFields(map[string]interface{}{ "ch" : make(chan int) }).Print("chan")
+    data := make(map[string]interface{})
+    if err := json.Unmarshal([]byte(buffer.Lines()[3]), &data); err != nil || data["msg"] != "chan" {
+        t.Errorf("unexpected JSON line for the unsupported field: %v, %v", data, err)
+    }
+
+    // 文本格式按照键名排序输出在日志内容之后
+    l, buffer = testLogger()
+    l.WithFields(map[string]interface{}{ "b" : 2, "a" : 1 }).Print("text")
+    if lines := buffer.Lines(); len(lines) != 1 || lines[0] != "text a=1 b=2" {
+        t.Errorf("unexpected text lines %v", lines)
+    }
+}
+
+// SetWriters同时写入到多个IO接口，AddWriter按照日志等级过滤附加IO接口的输出
+func TestLogger_Writers(t *testing.T) {
+    l, _   := testLogger()
+    l.SetBacktrace(false)
+    a, b   := &testBuffer{}, &testBuffer{}
+    errs   := &testBuffer{}
+    all    := &testBuffer{}
+    l.SetWriters(a, b)
+    l.AddWriter(errs, LEVEL_ERRO | LEVEL_CRIT)
+    l.AddWriter(all)
+    l.Print("print")
+    l.Info("info")
+    l.Error("error")
+    for name, c := range map[string]struct {
+        buffer *testBuffer
+        expect string
+    } {
+        "a"    : { a,    "print,[INFO] info,[ERRO] error" },
+        "b"    : { b,    "print,[INFO] info,[ERRO] error" },
+        "errs" : { errs, "[ERRO] error" },
+        "all"  : { all,  "print,[INFO] info,[ERRO] error" },
+    } {
+        if s := strings.Join(c.buffer.Lines(), ","); s != c.expect {
+            t.Errorf(`%s: unexpected logs "%s", expected "%s"`, name, s, c.expect)
+        }
+    }
+    l.ClearWriters()
+    l.Print("cleared")
+    if lines := all.Lines(); len(lines) != 3 {
+        t.Errorf("unexpected logs %v after ClearWriters", lines)
+    }
+
+    // 某个IO接口写入失败时不影响其他IO接口的写入
+    l.SetWriters(testFailWriter{}, a)
+    l.Print("failed")
+    if lines := a.Lines(); len(lines) != 5 || lines[4] != "failed" {
+        t.Errorf("unexpected logs %v after a failed writer", lines)
+    }
+}
+
+// 总是写入失败的IO接口
+type testFailWriter struct {}
+
+func (testFailWriter) Write(p []byte) (int, error) {
+    return 0, errors.New("write failed")
+}
+
+// 同一名称返回同一个分类日志对象，未设置日志等级时跟随全局的日志记录等级，设置后独立于全局的日志记录等级
+func TestCategory(t *testing.T) {
+    defer SetLevel(GetLevel())
+    name := fmt.Sprintf("TestCategory_%d", gtime.Nanosecond())
+    l    := Category(name)
+    if Category(name) != l {
+        t.Fatal("Category returned different loggers for the same name")
+    }
+    buffer := &testBuffer{}
+    l.SetWriter(buffer)
+    l.printHeader.Set(false)
+    l.SetBacktrace(false)
+
+    SetLevel(LEVEL_PROD)
+    l.Info("info 1")
+    l.Error("error 1")
+    SetLevel(LEVEL_ALL)
+    l.Info("info 2")
+    // 设置分类日志对象的日志等级后不再跟随全局的日志记录等级，也不影响全局的日志记录等级
+    l.SetLevel(LEVEL_ERRO)
+    l.Info("info 3")
+    l.Error("error 3")
+    if s := strings.Join(buffer.Lines(), ","); s != "[ERRO] error 1,[INFO] info 2,[ERRO] error 3" {
+        t.Errorf(`unexpected category logs "%s"`, s)
+    }
+    if level := GetLevel(); level != LEVEL_ALL {
+        t.Errorf("unexpected global level %d after setting the category level", level)
+    }
+    if Category(name + "_other").GetWriter() == buffer {
+        t.Error("the writer of a category logger was shared with another category")
+    }
+}
+
+// 封装的日志方法，用于测试SetCallerSkip
+func testLogWrapper(l *Logger, v...interface{}) {
+    l.Print(v...)
+}
+
+// 调用位置输出调用端(而不是glog内部)的文件、行号及方法名称，SetCallerSkip跳过封装的日志方法
+func TestLogger_Caller(t *testing.T) {
+    l, buffer := testLogger()
+    l.SetFlags(FLAG_SHORT | FLAG_LINE | FLAG_FUNC)
+    _, _, line, _ := runtime.Caller(0)
+    l.Print("short")
+    l.SetFlags(FLAG_FILE)
+    l.Print("file")
+    l.SetFlags(FLAG_SHORT | FLAG_LINE)
+    l.SetCallerSkip(1)
+    testLogWrapper(l, "skip")
+    _, file, _, _ := runtime.Caller(0)
+    lines := buffer.Lines()
+    for i, expect := range []string {
+        fmt.Sprintf("glog_test.go:%d gitee.com/johng/gf/g/os/glog.TestLogger_Caller: short", line + 1),
+        file + ": file",
+        fmt.Sprintf("glog_test.go:%d: skip", line + 6),
+    } {
+        if i >= len(lines) || lines[i] != expect {
+            t.Errorf(`unexpected caller log %v, expected "%s"`, lines, expect)
+        }
+    }
+}
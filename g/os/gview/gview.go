@@ -29,10 +29,11 @@ import (
 // 视图对象
 type View struct {
     mu         sync.RWMutex
-    paths      *gspath.SPath           // 模板查找目录(绝对路径)
-    data       map[string]interface{}  // 模板变量
-    funcmap    map[string]interface{}  // FuncMap
-    delimiters []string                // 模板变量分隔符号
+    paths      *gspath.SPath            // 模板查找目录(绝对路径)
+    data       map[string]interface{}   // 模板变量
+    funcmap    map[string]interface{}   // FuncMap
+    delimiters []string                 // 模板变量分隔符号
+    cache      *gmap.StringInterfaceMap // 解析后的布局模板对象缓存(ParseLayout)
 }
 
 // 模板变量
@@ -77,6 +78,7 @@ func New(path string) *View {
         data       : make(map[string]interface{}),
         funcmap    : make(map[string]interface{}),
         delimiters : make([]string, 2),
+        cache      : gmap.NewStringInterfaceMap(),
     }
     view.SetPath(path)
     view.SetDelimiters("{{", "}}")
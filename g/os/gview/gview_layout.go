@@ -0,0 +1,107 @@
+// Copyright 2018 gf Author(https://gitee.com/johng/gf). All Rights Reserved.
+//
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT was not distributed with this file,
+// You can obtain one at https://gitee.com/johng/gf.
+
+package gview
+
+import (
+    "bytes"
+    "errors"
+    "fmt"
+    "text/template"
+    "gitee.com/johng/gf/g/os/gfile"
+    "gitee.com/johng/gf/g/os/gfcache"
+    "gitee.com/johng/gf/g/os/gfsnotify"
+)
+
+// 使用布局模板解析模板文件，返回解析后的内容。
+// 布局模板layout中通过{{template "content" .}}(或者{{block "content" .}}默认内容{{end}})引用内容区块，
+// 内容模板file中通过{{define "content"}}...{{end}}定义内容区块，同样可以定义其他区块(例如title、script)覆盖布局模板中的block；
+// layout为空时直接解析内容模板(内容模板不需要定义区块)。
+// 解析后的模板对象会被缓存，开发环境下(可以检索到main包源码目录)模板文件变化时自动清除缓存重新解析。
+func (view *View) ParseLayout(layout string, file string, params Params, funcmap...map[string]interface{}) ([]byte, error) {
+    tpl, err := view.getLayoutTemplate(layout, file, funcmap...)
+    if err != nil {
+        return nil, err
+    }
+    // 每次执行使用模板的副本，保证本次执行的临时模板函数(例如与请求绑定的函数)不影响其他执行
+    if len(funcmap) > 0 {
+        if tpl, err = tpl.Clone(); err != nil {
+            return nil, err
+        }
+        tpl = tpl.Funcs(funcmap[0])
+    }
+    view.mu.RLock()
+    vars := view.mergeVars(params)
+    view.mu.RUnlock()
+    buffer := bytes.NewBuffer(nil)
+    if err := tpl.Execute(buffer, vars); err != nil {
+        return nil, err
+    }
+    return buffer.Bytes(), nil
+}
+
+// 获取(或者解析并缓存)布局模板与内容模板组合的模板对象，执行时从布局模板开始执行
+func (view *View) getLayoutTemplate(layout string, file string, funcmap...map[string]interface{}) (*template.Template, error) {
+    key := layout + "|" + file
+    if v := view.cache.Get(key); v != nil {
+        return v.(*template.Template), nil
+    }
+    files := []string{file}
+    if layout != "" {
+        files = []string{layout, file}
+    }
+    paths := make([]string, len(files))
+    for i, name := range files {
+        if paths[i] = view.paths.Search(name); paths[i] == "" {
+            return nil, errors.New(fmt.Sprintf(`tpl "%s" not found`, name))
+        }
+    }
+    view.mu.RLock()
+    tpl := template.New(paths[0]).Delims(view.delimiters[0], view.delimiters[1]).Funcs(view.funcmap)
+    view.mu.RUnlock()
+    if len(funcmap) > 0 {
+        tpl = tpl.Funcs(funcmap[0])
+    }
+    for i, path := range paths {
+        t := tpl
+        if i > 0 {
+            t = tpl.New(path)
+        }
+        if _, err := t.Parse(gfcache.GetContents(path)); err != nil {
+            return nil, err
+        }
+    }
+    view.cache.Set(key, tpl)
+    // 开发环境下监控模板文件的变化，文件变化时清除缓存
+    if gfile.MainPkgPath() != "" {
+        for _, path := range paths {
+            gfsnotify.Add(path, func(event *gfsnotify.Event) {
+                view.cache.Remove(key)
+            }, false)
+        }
+    }
+    return tpl, nil
+}
+
+// 合并视图对象的全局模板变量与给定的模板变量(全局模板变量优先)，调用方需要加锁。
+// 注意模板变量赋值不能改变已有的params或者view.data的值，因为这两个变量都是指针，
+// 因此在必要条件下，需要合并两个map的值到一个新的map
+func (view *View) mergeVars(params Params) map[string]interface{} {
+    if len(view.data) == 0 {
+        return params
+    }
+    if len(params) == 0 {
+        return view.data
+    }
+    vars := make(map[string]interface{}, len(view.data) + len(params))
+    for k, v := range params {
+        vars[k] = v
+    }
+    for k, v := range view.data {
+        vars[k] = v
+    }
+    return vars
+}
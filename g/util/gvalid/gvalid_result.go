@@ -39,6 +39,11 @@ func (e Error) FirstString() (string) {
     return ""
 }
 
+// 实现error接口，便于作为error类型返回，错误信息同String方法
+func (e Error) Error() string {
+    return e.String()
+}
+
 // 将所有错误信息构建称字符串，多个错误信息字符串使用"; "符号分隔
 func (e Error) String() string {
     return strings.Join(e.Strings(), "; ")
@@ -0,0 +1,34 @@
+package demo
+
+import (
+    "gitee.com/johng/gf/g"
+    "gitee.com/johng/gf/g/frame/gmvc"
+)
+
+type RestMember struct {
+    gmvc.Controller
+}
+
+type RestFile struct {
+    gmvc.Controller
+}
+
+func init() {
+    g.Server().BindControllerRest("/member/:id",     &RestMember{})
+    g.Server().BindControllerRest("/download/*path", &RestFile{})
+}
+
+// RESTFul - GET /member/1
+func (c *RestMember) Get() {
+    c.Response.Write("GET member: ", c.Request.GetRouterVar("id").Int())
+}
+
+// RESTFul - DELETE /member/1
+func (c *RestMember) Delete() {
+    c.Response.Write("DELETE member: ", c.Request.GetRouterString("id"))
+}
+
+// RESTFul - GET /download/a/b/c.txt
+func (c *RestFile) Get() {
+    c.Response.Write("GET file: ", c.Request.GetRouterString("path"))
+}
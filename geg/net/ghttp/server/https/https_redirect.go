@@ -0,0 +1,22 @@
+package main
+
+import (
+    "crypto/tls"
+    "gitee.com/johng/gf/g/net/ghttp"
+)
+
+func main() {
+    s := ghttp.GetServer()
+    s.BindHandler("/", func(r *ghttp.Request){
+        r.Response.Writeln("通过HTTP访问将会自动301跳转到HTTPS！")
+    })
+    s.SetTLS("/home/john/temp/server.crt", "/home/john/temp/server.key")
+    // 在默认的TLS配置基础上进行自定义，例如仅允许TLS1.3
+    config := ghttp.DefaultTLSConfig()
+    config.MinVersion = tls.VersionTLS13
+    s.SetTLSConfig(config)
+    s.SetHTTPSPort(443)
+    // 在80端口监听HTTP请求并跳转到HTTPS
+    s.SetHTTPSRedirect(":80")
+    s.Run()
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+    "context"
+    "os"
+    "os/signal"
+    "syscall"
+    "time"
+    "gitee.com/johng/gf/g"
+    "gitee.com/johng/gf/g/net/ghttp"
+    "gitee.com/johng/gf/g/os/glog"
+)
+
+// 收到SIGTERM信号后平滑下线：不再接受新的连接，最多等待10秒处理中的请求完成
+func main() {
+    s := g.Server()
+    s.BindHandler("/", func(r *ghttp.Request) {
+        select {
+            case <- time.After(5*time.Second):
+                r.Response.Writeln("done")
+            case <- r.Context().Done():
+                glog.Println("request cancelled")
+        }
+    })
+    s.SetPort(8199)
+    s.Start()
+
+    sigChan := make(chan os.Signal, 1)
+    signal.Notify(sigChan, syscall.SIGTERM)
+    <- sigChan
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+    if err := s.Shutdown(ctx); err != nil {
+        glog.Error(err)
+    }
+}
@@ -0,0 +1,20 @@
+package main
+
+import (
+    "gitee.com/johng/gf/g"
+    "gitee.com/johng/gf/g/net/ghttp"
+    "gitee.com/johng/gf/g/os/glog"
+)
+
+// 同一Server中同时提供API服务及前端静态文件(dist目录)服务
+func main() {
+    s := g.Server()
+    s.BindHandler("/api/user", func(r *ghttp.Request) {
+        r.Response.WriteJson(g.Map{"name" : "john"})
+    })
+    if err := s.SetStaticPath("/assets", "./dist"); err != nil {
+        glog.Fatal(err)
+    }
+    s.SetPort(8199)
+    s.Run()
+}
@@ -0,0 +1,21 @@
+package main
+
+import (
+    "gitee.com/johng/gf/g"
+    "gitee.com/johng/gf/g/net/ghttp"
+    "gitee.com/johng/gf/g/os/gfile"
+)
+
+// 使用布局模板展示页面，view/layout.html为布局模板，view/index.html定义内容区块
+func main() {
+    s := g.Server()
+    s.SetViewPath(gfile.MainPkgPath() + gfile.Separator + "view")
+    s.SetViewLayout("layout.html")
+    s.BindHandler("/", func(r *ghttp.Request) {
+        r.Response.WriteTemplate("index.html", g.Map {
+            "name" : r.Get("name", "gf"),
+        })
+    })
+    s.SetPort(8199)
+    s.Run()
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+    "gitee.com/johng/gf/g"
+    "gitee.com/johng/gf/g/net/ghttp"
+    "gitee.com/johng/gf/g/os/gfsnotify"
+    "gitee.com/johng/gf/g/os/glog"
+    "time"
+)
+
+// 将指定目录的文件变化事件通过Web Socket推送给客户端
+func main() {
+    // /home/john/temp 是一个目录，当然也可以指定文件
+    path := "/home/john/temp"
+    s    := g.Server()
+    s.BindHandler("/ws", func(r *ghttp.Request) {
+        ws, err := r.WebSocket()
+        if err != nil {
+            glog.Error(err)
+            r.Exit()
+        }
+        defer ws.Close()
+        ws.KeepAlive(10*time.Second)
+        // 每一个连接订阅一次文件变化事件，连接断开时取消订阅
+        events, cancel, err := gfsnotify.Subscribe(path)
+        if err != nil {
+            glog.Error(err)
+            return
+        }
+        defer cancel()
+        // 读取客户端消息(包括pong及close消息)，客户端断开或者心跳超时时结束
+        done := make(chan struct{})
+        go func() {
+            defer close(done)
+            for {
+                if _, _, err := ws.ReadMessage(); err != nil {
+                    return
+                }
+            }
+        }()
+        // 只在当前goroutine中写入消息
+        for {
+            select {
+                case <- done:
+                    return
+                case event, ok := <- events:
+                    if !ok {
+                        return
+                    }
+                    if err := ws.WriteMessage(ghttp.WS_MSG_TEXT, []byte(event.String())); err != nil {
+                        return
+                    }
+            }
+        }
+    })
+    s.SetPort(8199)
+    s.Run()
+}
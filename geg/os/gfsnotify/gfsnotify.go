@@ -21,6 +21,9 @@ func main() {
         if event.IsRename() {
             glog.Println("重命名文件 : ", event.Path)
         }
+        if event.IsMove() {
+            glog.Println("移动文件 : ", event.OldPath, "=>", event.Path)
+        }
         if event.IsChmod() {
             glog.Println("修改权限 : ", event.Path)
         }
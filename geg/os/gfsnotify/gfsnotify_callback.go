@@ -22,12 +22,12 @@ func main() {
     }
     // 5秒后移除c1的回调函数注册，仅剩c2
     gtime.SetTimeout(5*time.Second, func() {
-        gfsnotify.RemoveCallback(c1.Id)
+        gfsnotify.RemoveCallback(c1)
         glog.Println("remove callback c1")
     })
     // 10秒后移除c2的回调函数注册，所有的回调都移除，不再有任何打印信息输出
     gtime.SetTimeout(10*time.Second, func() {
-        gfsnotify.RemoveCallback(c2.Id)
+        gfsnotify.RemoveCallback(c2)
         glog.Println("remove callback c2")
     })
 
@@ -19,7 +19,7 @@ func main() {
 
     // 20秒后移除回调函数注册，所有的回调都移除，不再有任何打印信息输出
     gtime.SetTimeout(20*time.Second, func() {
-        gfsnotify.RemoveCallback(callback.Id)
+        gfsnotify.RemoveCallback(callback)
         glog.Println("remove callback")
     })
 
@@ -0,0 +1,19 @@
+package main
+
+import (
+    "context"
+    "time"
+    "gitee.com/johng/gf/g/os/gfsnotify"
+    "gitee.com/johng/gf/g/os/glog"
+)
+
+// 跟踪日志文件的追加内容(类似tail -f)，日志文件被logrotate轮转后自动跟踪新的日志文件，1分钟后停止跟踪
+func main() {
+    ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+    defer cancel()
+    err := gfsnotify.Tail(ctx, "/var/log/app.log", func(line string) error {
+        glog.Println(line)
+        return nil
+    })
+    glog.Println("tail stopped:", err)
+}
@@ -0,0 +1,19 @@
+package main
+
+import (
+    "gitee.com/johng/gf/g"
+    "gitee.com/johng/gf/g/net/ghttp"
+    "gitee.com/johng/gf/g/os/glog"
+)
+
+// 访问 http://127.0.0.1:8199/user ，日志输出类似：2018-01-02 15:04:05.000 [INFO] user info request_id=xxx
+func main() {
+    s := g.Server()
+    s.Use(ghttp.RequestId())
+    s.BindHandler("/user", func(r *ghttp.Request) {
+        glog.Ctx(r.Context()).Info("user info")
+        r.Response.Write(r.GetRequestId())
+    })
+    s.SetPort(8199)
+    s.Run()
+}